@@ -0,0 +1,323 @@
+// Command train melatih ulang model rekomendasi jadwal murojaah dari data di Postgres.
+//
+// Sumber data:
+//   - skor efektivitas jadwal personal (1-5)
+//   - umpan balik rekomendasi yang diterapkan beserta skor efektivitasnya
+//   - tingkat penyelesaian sesi murojaah (detail log) yang dibuat dari rekomendasi atau mengikuti jadwal personal
+//
+// Hasilnya adalah q_table_model.json dan historical_best.json dengan format yang sama dengan yang dibaca
+// config.LoadQlearningModels, serta evaluasi_training.json berisi ringkasan evaluasi. Kedua file model dapat
+// langsung diunggah sebagai versi baru lewat /api/v1/model-rekomendasi.
+//
+// Contoh:
+//
+//	go run ./cmd/train -alpha 0.1 -gamma 0.1 -episode 200 -seed 42 -out ./model-baru
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/habbazettt/mahad-service-go/config"
+	"github.com/habbazettt/mahad-service-go/models"
+	"github.com/habbazettt/mahad-service-go/services"
+	"gorm.io/gorm"
+)
+
+func main() {
+	hp := hyperparameter{}
+	flag.Float64Var(&hp.Alpha, "alpha", 0.1, "learning rate (0 < alpha <= 1)")
+	flag.Float64Var(&hp.Gamma, "gamma", 0.1, "discount factor (0 <= gamma < 1)")
+	flag.IntVar(&hp.Episode, "episode", 200, "jumlah episode pemutaran ulang seluruh data")
+	flag.Int64Var(&hp.Seed, "seed", 42, "seed acak agar hasil training dapat direproduksi")
+	flag.Float64Var(&hp.Holdout, "holdout", 0.2, "porsi data yang disisihkan untuk evaluasi (0 untuk melewati evaluasi)")
+	initPath := flag.String("init", "", "path Q-Table awal (opsional), misalnya ./q_table_model.json")
+	outDir := flag.String("out", ".", "folder tujuan file model dan evaluasi")
+	flag.Parse()
+
+	if hp.Alpha <= 0 || hp.Alpha > 1 || hp.Gamma < 0 || hp.Gamma >= 1 || hp.Episode < 1 || hp.Holdout < 0 || hp.Holdout >= 1 {
+		log.Fatalf("❌ Hyperparameter tidak valid: %+v", hp)
+	}
+
+	var awal config.QTable
+	if *initPath != "" {
+		raw, err := os.ReadFile(*initPath)
+		if err != nil {
+			log.Fatalf("❌ Gagal membaca Q-Table awal: %v", err)
+		}
+		if awal, _, err = config.ParseModel(raw, nil); err != nil {
+			log.Fatalf("❌ Q-Table awal tidak valid: %v", err)
+		}
+	}
+
+	db := config.ConnectDB()
+	semua, err := muatPengalaman(db)
+	if err != nil {
+		log.Fatalf("❌ Gagal memuat data training: %v", err)
+	}
+	// Model aktif hanya dibaca agar state data training mengikuti penulisan state di model yang sedang dipakai
+	if err := config.SinkronkanModelAktif(db); err != nil {
+		log.Fatalf("❌ Gagal memuat model aktif: %v", err)
+	}
+	dikenal := config.Models.States()
+	for state := range awal {
+		dikenal = append(dikenal, state)
+	}
+	kanoniskanState(semua, dikenal)
+	data, dilewati := saringPengalaman(semua)
+	if dilewati > 0 {
+		fmt.Printf("⚠️  Melewati %d data dengan state atau jadwal tidak valid\n", dilewati)
+	}
+	if len(data) == 0 {
+		log.Fatal("❌ Tidak ada data training di database")
+	}
+	aksi := daftarAksi(data, awal)
+	fmt.Printf("ℹ️ Memuat %d data dengan %d jadwal berbeda\n", len(data), len(aksi))
+
+	ringkasan := ringkasanEvaluasi{Hyperparameter: hp, JumlahData: len(data), JumlahDataPerSumber: map[string]int{}}
+	for _, p := range data {
+		ringkasan.JumlahDataPerSumber[p.Sumber]++
+	}
+
+	if hp.Holdout > 0 {
+		rng := rand.New(rand.NewSource(hp.Seed))
+		urutan := rng.Perm(len(data))
+		jumlahUji := int(float64(len(data)) * hp.Holdout)
+		latih := make([]pengalaman, 0, len(data)-jumlahUji)
+		uji := make([]pengalaman, 0, jumlahUji)
+		for i, idx := range urutan {
+			if i < jumlahUji {
+				uji = append(uji, data[idx])
+			} else {
+				latih = append(latih, data[idx])
+			}
+		}
+		evaluasiHoldout(latihQTable(latih, aksi, awal, hp, rng), uji, &ringkasan)
+	}
+
+	// Model akhir dilatih dengan seluruh data
+	qTable := latihQTable(data, aksi, awal, hp, rand.New(rand.NewSource(hp.Seed)))
+	for _, actions := range qTable {
+		for action, v := range actions {
+			actions[action] = bulatkan(v)
+		}
+	}
+	ringkasan.State = ringkasanState(qTable, data)
+
+	qTableJSON, err := marshalModel(qTable)
+	if err != nil {
+		log.Fatalf("❌ Gagal menyusun q_table_model.json: %v", err)
+	}
+	historicalJSON, err := marshalModel(hitungHistoricalBest(data))
+	if err != nil {
+		log.Fatalf("❌ Gagal menyusun historical_best.json: %v", err)
+	}
+	// Pastikan hasil training bisa dimuat oleh service sebelum ditulis
+	if _, _, err := config.ParseModel(qTableJSON, historicalJSON); err != nil {
+		log.Fatalf("❌ Model hasil training tidak valid: %v", err)
+	}
+	evaluasiJSON, err := marshalModel(ringkasan)
+	if err != nil {
+		log.Fatalf("❌ Gagal menyusun ringkasan evaluasi: %v", err)
+	}
+
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		log.Fatalf("❌ Gagal membuat folder output: %v", err)
+	}
+	for nama, isi := range map[string][]byte{
+		"q_table_model.json":     qTableJSON,
+		"historical_best.json":   historicalJSON,
+		"evaluasi_training.json": evaluasiJSON,
+	} {
+		if err := os.WriteFile(filepath.Join(*outDir, nama), isi, 0o644); err != nil {
+			log.Fatalf("❌ Gagal menulis %s: %v", nama, err)
+		}
+	}
+
+	fmt.Println(string(evaluasiJSON))
+	fmt.Printf("\n✅ Training selesai, model ditulis ke %s\n", *outDir)
+}
+
+// muatPengalaman mengumpulkan seluruh data training. Log murojaah hari ini tidak dipakai karena masih bisa berubah.
+func muatPengalaman(db *gorm.DB) ([]pengalaman, error) {
+	var data []pengalaman
+
+	var jadwalPersonal []models.JadwalPersonal
+	if err := db.Where("efektifitas_jadwal BETWEEN 1 AND 5 AND jadwal <> ''").Order("id").Find(&jadwalPersonal).Error; err != nil {
+		return nil, fmt.Errorf("jadwal personal: %w", err)
+	}
+	for _, jp := range jadwalPersonal {
+		data = append(data, pengalaman{
+			State:   services.StateJadwalPersonal(jp),
+			Aksi:    strings.TrimSpace(jp.Jadwal),
+			Reward:  float64(jp.EfektifitasJadwal),
+			Sumber:  "jadwal_personal",
+			Dinilai: true,
+		})
+	}
+
+	var umpanBalik []struct {
+		State       string
+		Aksi        string
+		Efektivitas int
+	}
+	if err := db.Table("umpan_balik_rekomendasis AS ub").
+		Select("jr.state, jr.rekomendasi_jadwal AS aksi, ub.efektivitas").
+		Joins("JOIN jadwal_rekomendasis jr ON jr.id = ub.jadwal_rekomendasi_id").
+		Where("ub.diterapkan AND ub.efektivitas BETWEEN 1 AND 5").
+		Order("ub.id").
+		Scan(&umpanBalik).Error; err != nil {
+		return nil, fmt.Errorf("umpan balik rekomendasi: %w", err)
+	}
+	for _, ub := range umpanBalik {
+		data = append(data, pengalaman{State: ub.State, Aksi: ub.Aksi, Reward: float64(ub.Efektivitas), Sumber: "umpan_balik", Dinilai: true})
+	}
+
+	today := time.Now()
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+
+	var sesiRekomendasi []struct {
+		State               string
+		Aksi                string
+		TotalTargetHalaman  int
+		TotalSelesaiHalaman int
+	}
+	if err := db.Table("detail_logs AS dl").
+		Select("jr.state, jr.rekomendasi_jadwal AS aksi, dl.total_target_halaman, dl.total_selesai_halaman").
+		Joins("JOIN jadwal_rekomendasis jr ON jr.id = dl.jadwal_rekomendasi_id").
+		Joins("JOIN log_harians lh ON lh.id = dl.log_harian_id").
+		Where("dl.total_target_halaman > 0 AND lh.tanggal < ?", today).
+		Order("dl.id").
+		Scan(&sesiRekomendasi).Error; err != nil {
+		return nil, fmt.Errorf("sesi dari rekomendasi: %w", err)
+	}
+	for _, s := range sesiRekomendasi {
+		data = append(data, pengalaman{State: s.State, Aksi: s.Aksi, Reward: rewardPenyelesaian(s.TotalSelesaiHalaman, s.TotalTargetHalaman), Sumber: "sesi_rekomendasi"})
+	}
+
+	// Jadwal dan state diambil dari log harian, yaitu jadwal personal yang berlaku pada hari sesi tersebut
+	var sesiJadwalPersonal []struct {
+		StateJadwal string
+		Jadwal      string
+		TargetSesi  int
+		SelesaiSesi int
+	}
+	if err := db.Table("detail_logs AS dl").
+		Select("lh.state_jadwal, lh.jadwal, dl.total_target_halaman AS target_sesi, dl.total_selesai_halaman AS selesai_sesi").
+		Joins("JOIN log_harians lh ON lh.id = dl.log_harian_id").
+		Where("dl.jadwal_rekomendasi_id IS NULL AND dl.total_target_halaman > 0 AND lh.tanggal < ?", today).
+		Where("COALESCE(lh.jadwal, '') <> '' AND COALESCE(lh.state_jadwal, '') <> ''").
+		Order("dl.id").
+		Scan(&sesiJadwalPersonal).Error; err != nil {
+		return nil, fmt.Errorf("sesi jadwal personal: %w", err)
+	}
+	for _, s := range sesiJadwalPersonal {
+		data = append(data, pengalaman{
+			State:  s.StateJadwal,
+			Aksi:   strings.TrimSpace(s.Jadwal),
+			Reward: rewardPenyelesaian(s.SelesaiSesi, s.TargetSesi),
+			Sumber: "sesi_jadwal_personal",
+		})
+	}
+
+	return data, nil
+}
+
+// kanoniskanState menyamakan state yang hanya berbeda urutan atau penulisan komponen kesibukan, misalnya
+// "organisasi + kuliah" dan "Kuliah+Organisasi", sebelum data dikelompokkan per state. State yang dikenal model
+// aktif atau Q-Table awal dipakai apa adanya; selain itu komponen kesibukan diurutkan secara alfabetis.
+func kanoniskanState(data []pengalaman, dikenal []string) {
+	hasil := make(map[string]string)
+	for i := range data {
+		state, ok := hasil[data[i].State]
+		if !ok {
+			state = services.KanonisState(data[i].State, dikenal)
+			if state == data[i].State && !slices.Contains(dikenal, state) {
+				state = urutkanKomponenState(state)
+			}
+			hasil[data[i].State] = state
+		}
+		data[i].State = state
+	}
+}
+
+func urutkanKomponenState(state string) string {
+	idx := strings.LastIndex(state, "_")
+	if idx < 0 {
+		return state
+	}
+	var komponen []string
+	for _, k := range strings.Split(state[:idx], "+") {
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+			komponen = append(komponen, k)
+		}
+	}
+	sort.Strings(komponen)
+	return strings.Join(komponen, " + ") + state[idx:]
+}
+
+// saringPengalaman membuang data yang tidak bisa menjadi bagian Q-Table, misalnya state riwayat rekomendasi
+// yang dibuat dari input kosong. Formatnya mengikuti validasi config.ParseModel.
+func saringPengalaman(data []pengalaman) ([]pengalaman, int) {
+	hasil := make([]pengalaman, 0, len(data))
+	for _, p := range data {
+		idx := strings.LastIndex(p.State, "_")
+		if idx <= 0 || idx == len(p.State)-1 || strings.TrimSpace(p.Aksi) == "" {
+			continue
+		}
+		hasil = append(hasil, p)
+	}
+	return hasil, len(data) - len(hasil)
+}
+
+// rewardPenyelesaian mengubah rasio halaman selesai terhadap target menjadi reward berskala sama dengan skor efektivitas
+func rewardPenyelesaian(selesai, target int) float64 {
+	rasio := float64(selesai) / float64(target)
+	if rasio > 1 {
+		rasio = 1
+	}
+	return rasio * rewardMaksimal
+}
+
+// marshalModel menulis JSON dengan indentasi yang sama dengan file model yang ada, tanpa escape HTML
+// agar kunci seperti "Skor >=4" tetap terbaca apa adanya
+func marshalModel(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "    ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// daftarAksi mengembalikan seluruh jadwal yang muncul di data maupun di Q-Table awal, terurut agar training deterministik
+func daftarAksi(data []pengalaman, awal config.QTable) []string {
+	set := make(map[string]bool)
+	for _, p := range data {
+		set[p.Aksi] = true
+	}
+	for _, actions := range awal {
+		for action := range actions {
+			set[action] = true
+		}
+	}
+
+	aksi := make([]string, 0, len(set))
+	for a := range set {
+		aksi = append(aksi, a)
+	}
+	sort.Strings(aksi)
+	return aksi
+}
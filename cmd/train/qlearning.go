@@ -0,0 +1,184 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/habbazettt/mahad-service-go/config"
+)
+
+const (
+	rewardMaksimal = 5.0
+	skorEfektif    = 4.0
+)
+
+// pengalaman adalah satu pasangan (state, jadwal) beserta reward yang diamati dari data pengguna
+type pengalaman struct {
+	State  string
+	Aksi   string
+	Reward float64
+	Sumber string
+	// Dinilai bernilai true jika reward berasal dari skor efektivitas 1-5 yang diisi pengguna
+	Dinilai bool
+}
+
+type hyperparameter struct {
+	Alpha   float64 `json:"alpha"`
+	Gamma   float64 `json:"gamma"`
+	Episode int     `json:"episode"`
+	Seed    int64   `json:"seed"`
+	Holdout float64 `json:"holdout"`
+}
+
+// latihQTable menjalankan Q-learning tabular dengan memutar ulang seluruh pengalaman secara acak di setiap episode.
+// Aturan pembaruan sama dengan pembelajaran online di services: jadwal tidak mengubah kondisi pengguna,
+// sehingga state berikutnya adalah state yang sama.
+func latihQTable(data []pengalaman, aksi []string, awal config.QTable, hp hyperparameter, rng *rand.Rand) config.QTable {
+	qTable := make(config.QTable)
+	for state, actions := range awal {
+		qTable[state] = make(map[string]float64, len(actions))
+		for action, v := range actions {
+			qTable[state][action] = v
+		}
+	}
+	for _, p := range data {
+		if qTable[p.State] == nil {
+			qTable[p.State] = make(map[string]float64, len(aksi))
+		}
+	}
+	for _, actions := range qTable {
+		for _, a := range aksi {
+			if _, ok := actions[a]; !ok {
+				actions[a] = 0
+			}
+		}
+	}
+
+	for ep := 0; ep < hp.Episode; ep++ {
+		for _, idx := range rng.Perm(len(data)) {
+			p := data[idx]
+			actions := qTable[p.State]
+
+			maxNext := math.Inf(-1)
+			for _, v := range actions {
+				if v > maxNext {
+					maxNext = v
+				}
+			}
+			actions[p.Aksi] += hp.Alpha * (p.Reward + hp.Gamma*maxNext - actions[p.Aksi])
+		}
+	}
+	return qTable
+}
+
+// aksiTerbaik memilih jadwal dengan nilai Q tertinggi, nama jadwal dipakai sebagai pemecah seri
+func aksiTerbaik(actions map[string]float64) (string, float64) {
+	var terbaik string
+	nilai := math.Inf(-1)
+	for action, v := range actions {
+		if v > nilai || (v == nilai && action < terbaik) {
+			terbaik, nilai = action, v
+		}
+	}
+	return terbaik, nilai
+}
+
+// hitungHistoricalBest merangkum efektivitas tiap jadwal dari pengalaman yang dinilai langsung oleh pengguna
+func hitungHistoricalBest(data []pengalaman) map[string]config.HistoricalInfo {
+	hasil := make(map[string]config.HistoricalInfo)
+	for _, p := range data {
+		if !p.Dinilai {
+			continue
+		}
+		info := hasil[p.Aksi]
+		info.TotalPenggunaan++
+		if p.Reward >= skorEfektif {
+			info.PenggunaanEfektif++
+		}
+		hasil[p.Aksi] = info
+	}
+	for aksi, info := range hasil {
+		info.PersentaseEfektif = bulatkan(float64(info.PenggunaanEfektif) / float64(info.TotalPenggunaan) * 100)
+		hasil[aksi] = info
+	}
+	return hasil
+}
+
+type evaluasiState struct {
+	State        string  `json:"state"`
+	JumlahSampel int     `json:"jumlah_sampel"`
+	AksiTerbaik  string  `json:"aksi_terbaik"`
+	QTerbaik     float64 `json:"q_terbaik"`
+}
+
+type ringkasanEvaluasi struct {
+	Hyperparameter           hyperparameter  `json:"hyperparameter"`
+	JumlahData               int             `json:"jumlah_data"`
+	JumlahDataPerSumber      map[string]int  `json:"jumlah_data_per_sumber"`
+	JumlahDataUji            int             `json:"jumlah_data_uji"`
+	CakupanState             float64         `json:"cakupan_state"`     // Persentase data uji yang state-nya dikenal model
+	TingkatKecocokan         float64         `json:"tingkat_kecocokan"` // Persentase data uji yang jadwalnya sama dengan pilihan model
+	RataRataRewardCocok      *float64        `json:"rata_rata_reward_cocok,omitempty"`
+	RataRataRewardTidakCocok *float64        `json:"rata_rata_reward_tidak_cocok,omitempty"`
+	State                    []evaluasiState `json:"state"`
+}
+
+// evaluasiHoldout mengukur model pada data uji: jika jadwal pilihan model memang lebih baik,
+// reward rata-rata data uji yang cocok dengan pilihan model akan lebih tinggi dari yang tidak cocok.
+func evaluasiHoldout(qTable config.QTable, uji []pengalaman, ringkasan *ringkasanEvaluasi) {
+	ringkasan.JumlahDataUji = len(uji)
+	if len(uji) == 0 {
+		return
+	}
+
+	var dikenal, cocok, tidakCocok int
+	var totalCocok, totalTidakCocok float64
+	for _, p := range uji {
+		actions, ok := qTable[p.State]
+		if !ok {
+			continue
+		}
+		dikenal++
+		if terbaik, _ := aksiTerbaik(actions); terbaik == p.Aksi {
+			cocok++
+			totalCocok += p.Reward
+		} else {
+			tidakCocok++
+			totalTidakCocok += p.Reward
+		}
+	}
+
+	ringkasan.CakupanState = bulatkan(float64(dikenal) / float64(len(uji)) * 100)
+	if dikenal > 0 {
+		ringkasan.TingkatKecocokan = bulatkan(float64(cocok) / float64(dikenal) * 100)
+	}
+	if cocok > 0 {
+		v := bulatkan(totalCocok / float64(cocok))
+		ringkasan.RataRataRewardCocok = &v
+	}
+	if tidakCocok > 0 {
+		v := bulatkan(totalTidakCocok / float64(tidakCocok))
+		ringkasan.RataRataRewardTidakCocok = &v
+	}
+}
+
+func ringkasanState(qTable config.QTable, data []pengalaman) []evaluasiState {
+	sampel := make(map[string]int)
+	for _, p := range data {
+		sampel[p.State]++
+	}
+
+	hasil := make([]evaluasiState, 0, len(qTable))
+	for state, actions := range qTable {
+		aksi, q := aksiTerbaik(actions)
+		hasil = append(hasil, evaluasiState{State: state, JumlahSampel: sampel[state], AksiTerbaik: aksi, QTerbaik: bulatkan(q)})
+	}
+	sort.Slice(hasil, func(i, j int) bool { return hasil[i].State < hasil[j].State })
+	return hasil
+}
+
+// bulatkan menyamakan presisi angka dengan file model yang sudah ada (10 digit desimal)
+func bulatkan(v float64) float64 {
+	return math.Round(v*1e10) / 1e10
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/habbazettt/mahad-service-go/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func dataLatihUji() []pengalaman {
+	return []pengalaman{
+		{State: "kuliah_1-10 Juz", Aksi: "Setelah Isya", Reward: 5, Dinilai: true},
+		{State: "kuliah_1-10 Juz", Aksi: "Sebelum Subuh", Reward: 2, Dinilai: true},
+		{State: "kuliah_1-10 Juz", Aksi: "Setelah Isya", Reward: 4},
+		{State: "kerja_11-20 Juz", Aksi: "Setelah Maghrib", Reward: 3, Dinilai: true},
+		{State: "kerja_11-20 Juz", Aksi: "Sebelum Subuh", Reward: 1},
+	}
+}
+
+func TestLatihQTableDeterministikUntukSeed(t *testing.T) {
+	aksi := []string{"Sebelum Subuh", "Setelah Isya", "Setelah Maghrib"}
+	hp := hyperparameter{Alpha: 0.1, Gamma: 0.9, Episode: 50, Seed: 42}
+
+	pertama := latihQTable(dataLatihUji(), aksi, nil, hp, rand.New(rand.NewSource(hp.Seed)))
+	kedua := latihQTable(dataLatihUji(), aksi, nil, hp, rand.New(rand.NewSource(hp.Seed)))
+	assert.Equal(t, pertama, kedua)
+
+	// Seed berbeda mengubah urutan pemutaran ulang sehingga nilai Q ikut berbeda
+	lain := latihQTable(dataLatihUji(), aksi, nil, hp, rand.New(rand.NewSource(7)))
+	assert.NotEqual(t, pertama, lain)
+}
+
+func TestLatihQTableAwalDanAksi(t *testing.T) {
+	aksi := []string{"Sebelum Subuh", "Setelah Isya"}
+	awal := config.QTable{"organisasi_21-30 Juz": {"Setelah Isya": 2.5}}
+	hp := hyperparameter{Alpha: 0.5, Gamma: 0, Episode: 30}
+
+	qTable := latihQTable(dataLatihUji()[:2], aksi, awal, hp, rand.New(rand.NewSource(1)))
+
+	// State awal tanpa data tetap dipertahankan dan dilengkapi seluruh aksi, tanpa mengubah Q-Table awal
+	assert.Equal(t, map[string]float64{"Setelah Isya": 2.5, "Sebelum Subuh": 0}, qTable["organisasi_21-30 Juz"])
+	assert.Len(t, awal["organisasi_21-30 Juz"], 1)
+
+	// Dengan gamma 0 nilai Q mendekati reward yang diamati
+	assert.InDelta(t, 5, qTable["kuliah_1-10 Juz"]["Setelah Isya"], 1e-6)
+	assert.InDelta(t, 2, qTable["kuliah_1-10 Juz"]["Sebelum Subuh"], 1e-6)
+
+	aksiPilihan, _ := aksiTerbaik(qTable["kuliah_1-10 Juz"])
+	assert.Equal(t, "Setelah Isya", aksiPilihan)
+}
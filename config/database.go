@@ -1,6 +1,8 @@
 package config
 
 import (
+	"context"
+	"errors"
 	"os"
 	"time"
 
@@ -59,6 +61,10 @@ func MigrateDB() {
 		logrus.Fatal("❌ Database belum terhubung! Jalankan ConnectDB() terlebih dahulu.")
 	}
 
+	dedupeAbsensi()
+	dedupeSuratPeringatan()
+	dedupeQadhaHafalan()
+
 	err := DB.AutoMigrate(
 		&models.Mentor{},
 		&models.Mahasantri{},
@@ -69,14 +75,184 @@ func MigrateDB() {
 		&models.JadwalPersonal{},
 		&models.LogHarian{},
 		&models.DetailLog{},
+		&models.SuratPeringatan{},
+		&models.Qadha{},
+		&models.Halaqah{},
+		&models.HalaqahMentor{},
+		&models.HalaqahAnggota{},
+		&models.MentorAssignment{},
+		&models.RiwayatStatusMahasantri{},
+		&models.Wali{},
+		&models.WaliMahasantri{},
+		&models.QValue{},
+		&models.ModelRekomendasi{},
+		&models.UmpanBalikRekomendasi{},
+		&models.EksperimenRekomendasi{},
+		&models.VarianEksperimen{},
 	)
 	if err != nil {
 		logrus.WithError(err).Fatal("❌ Gagal melakukan migrasi database!")
 	}
 
+	// Nilai Q kini disimpan per versi model, index lama (state, action) tidak lagi unik
+	if DB.Migrator().HasIndex(&models.QValue{}, "idx_q_value_state_action") {
+		if err := DB.Migrator().DropIndex(&models.QValue{}, "idx_q_value_state_action"); err != nil {
+			logrus.WithError(err).Warn("⚠️ Gagal menghapus index lama q_values")
+		}
+	}
+
+	backfillMentorAssignment()
+	setupPencarianMahasantri()
+
 	logrus.Info("✅ Database berhasil dimigrasi!")
 }
 
+// backfillMentorAssignment membuat periode bimbingan awal untuk mahasantri yang belum memiliki riwayat mentor.
+// Periode dimulai dari data paling awal (pendaftaran, absensi, atau hafalan) yang tercatat atas nama mentor saat ini.
+func backfillMentorAssignment() {
+	result := DB.Exec(`
+		INSERT INTO mentor_assignments (mahasantri_id, mentor_id, mulai_tanggal, created_at, updated_at)
+		SELECT m.id, m.mentor_id,
+			LEAST(
+				m.created_at::date,
+				(SELECT MIN(a.tanggal) FROM absensis a WHERE a.mahasantri_id = m.id AND a.mentor_id = m.mentor_id),
+				(SELECT MIN(h.created_at)::date FROM hafalans h WHERE h.mahasantri_id = m.id AND h.mentor_id = m.mentor_id)
+			),
+			NOW(), NOW()
+		FROM mahasantris m
+		WHERE NOT EXISTS (
+			SELECT 1 FROM mentor_assignments ma WHERE ma.mahasantri_id = m.id
+		)`)
+	if result.Error != nil {
+		logrus.WithError(result.Error).Fatal("❌ Gagal mengisi riwayat mentor awal!")
+	}
+	if result.RowsAffected > 0 {
+		logrus.WithField("jumlah", result.RowsAffected).Info("✅ Riwayat mentor awal berhasil dibuat")
+	}
+}
+
+// setupPencarianMahasantri memasang ekstensi pg_trgm dan index trigram untuk pencarian nama/NIM.
+// Jika ekstensi tidak dapat dipasang (misalnya user database tanpa hak), pencarian tetap berjalan tanpa trigram.
+func setupPencarianMahasantri() {
+	if err := DB.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		logrus.WithError(err).Warn("⚠️ Ekstensi pg_trgm tidak dapat dipasang, pencarian mahasantri tanpa trigram")
+		return
+	}
+
+	for _, stmt := range []string{
+		"CREATE INDEX IF NOT EXISTS idx_mahasantris_nama_trgm ON mahasantris USING gin (nama gin_trgm_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_mahasantris_nim_trgm ON mahasantris USING gin (nim gin_trgm_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_mahasantris_nama_fts ON mahasantris USING gin (to_tsvector('simple', nama))",
+	} {
+		if err := DB.Exec(stmt).Error; err != nil {
+			logrus.WithError(err).Warn("⚠️ Gagal membuat index pencarian mahasantri")
+		}
+	}
+}
+
+// dedupeAbsensi adalah migrasi satu kali sebelum unique index absensi dibuat. Waktu dinormalisasi ke huruf kecil
+// (sama seperti penulisan baru), lalu absensi ganda disalin ke absensis_duplikat_backup sebelum dihapus dan dicatat
+// satu per satu. Setelah unique index ada, duplikat tidak mungkin muncul lagi sehingga langkah ini dilewati.
+func dedupeAbsensi() {
+	if !DB.Migrator().HasTable(&models.Absensi{}) || DB.Migrator().HasIndex(&models.Absensi{}, "idx_absensi_mahasantri_tanggal_waktu") {
+		return
+	}
+
+	type absensiGanda struct {
+		ID           uint
+		MahasantriID uint
+		Tanggal      time.Time
+		Waktu        string
+	}
+	var dihapus []absensiGanda
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`UPDATE absensis SET waktu = LOWER(TRIM(waktu)) WHERE waktu <> LOWER(TRIM(waktu))`).Error; err != nil {
+			return err
+		}
+
+		// Simpan yang terbaru (id terbesar) untuk setiap mahasantri, tanggal, dan waktu
+		const duplikat = `SELECT a.id FROM absensis a
+			JOIN absensis b ON a.mahasantri_id = b.mahasantri_id AND a.tanggal = b.tanggal AND a.waktu = b.waktu AND a.id < b.id`
+		if err := tx.Exec(`CREATE TABLE IF NOT EXISTS absensis_duplikat_backup (LIKE absensis INCLUDING DEFAULTS)`).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`INSERT INTO absensis_duplikat_backup SELECT * FROM absensis WHERE id IN (` + duplikat + `)`).Error; err != nil {
+			return err
+		}
+		return tx.Raw(`DELETE FROM absensis WHERE id IN (` + duplikat + `) RETURNING id, mahasantri_id, tanggal, waktu`).Scan(&dihapus).Error
+	})
+	if err != nil {
+		logrus.WithError(err).Fatal("❌ Gagal membersihkan absensi ganda!")
+	}
+
+	for _, a := range dihapus {
+		logrus.WithFields(logrus.Fields{
+			"id":            a.ID,
+			"mahasantri_id": a.MahasantriID,
+			"tanggal":       a.Tanggal.Format("02-01-2006"),
+			"waktu":         a.Waktu,
+		}).Warn("⚠️ Absensi ganda dihapus, salinan tersimpan di absensis_duplikat_backup")
+	}
+	if len(dihapus) > 0 {
+		logrus.WithField("jumlah", len(dihapus)).Warn("⚠️ Absensi ganda dihapus sebelum migrasi")
+	}
+}
+
+// dedupeSuratPeringatan mencabut SP ganda (level sama dalam satu semester yang belum dicabut) sebelum unique index
+// idx_sp_mahasantri_semester_level dibuat. SP tertua dipertahankan, sisanya dicabut dengan alasan yang jelas.
+func dedupeSuratPeringatan() {
+	if !DB.Migrator().HasTable(&models.SuratPeringatan{}) || DB.Migrator().HasIndex(&models.SuratPeringatan{}, "idx_sp_mahasantri_semester_level") {
+		return
+	}
+
+	result := DB.Exec(`UPDATE surat_peringatans SET status = 'dicabut', dicabut_pada = NOW(), alasan_pencabutan = 'SP ganda diterbitkan bersamaan'
+		WHERE id IN (
+			SELECT a.id FROM surat_peringatans a
+			JOIN surat_peringatans b ON a.mahasantri_id = b.mahasantri_id AND a.semester = b.semester
+				AND a.tahun_ajaran = b.tahun_ajaran AND a.level = b.level AND a.id > b.id
+			WHERE a.status <> 'dicabut' AND b.status <> 'dicabut'
+		)`)
+	if result.Error != nil {
+		logrus.WithError(result.Error).Fatal("❌ Gagal membersihkan surat peringatan ganda!")
+	}
+	if result.RowsAffected > 0 {
+		logrus.WithField("jumlah", result.RowsAffected).Warn("⚠️ Surat peringatan ganda dicabut sebelum migrasi")
+	}
+}
+
+// dedupeQadhaHafalan melepas hafalan yang dipakai lebih dari satu qadha (qadha yang lebih baru) sebelum unique index
+// idx_qadha_hafalan dibuat, agar AutoMigrate tidak gagal pada data lama
+func dedupeQadhaHafalan() {
+	if !DB.Migrator().HasTable(&models.Qadha{}) || DB.Migrator().HasIndex(&models.Qadha{}, "idx_qadha_hafalan") {
+		return
+	}
+
+	result := DB.Exec(`UPDATE qadhas SET hafalan_id = NULL
+		WHERE id IN (
+			SELECT a.id FROM qadhas a
+			JOIN qadhas b ON a.hafalan_id = b.hafalan_id AND a.id > b.id
+		)`)
+	if result.Error != nil {
+		logrus.WithError(result.Error).Fatal("❌ Gagal membersihkan hafalan ganda pada qadha!")
+	}
+	if result.RowsAffected > 0 {
+		logrus.WithField("jumlah", result.RowsAffected).Warn("⚠️ Hafalan ganda pada qadha dilepas sebelum migrasi")
+	}
+}
+
+// PingDB memastikan koneksi ke database masih dapat digunakan
+func PingDB(ctx context.Context) error {
+	if DB == nil {
+		return errors.New("database belum terhubung")
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
 func CloseDB() {
 	if DB != nil {
 		sqlDB, err := DB.DB()
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"sync"
+	"time"
+)
+
+// ModelSnapshot adalah satu versi model Q-learning yang sedang dipakai untuk rekomendasi
+type ModelSnapshot struct {
+	Versi          int
+	QTable         QTable
+	HistoricalBest []HistoricalInfo
+	AktifSejak     time.Time
+}
+
+// ModelStore menyimpan model aktif. Seluruh akses dilindungi RWMutex sehingga model dapat diganti
+// (hot reload) dan nilai Q diperbarui secara online tanpa race dengan request rekomendasi yang sedang berjalan.
+type ModelStore struct {
+	mu      sync.RWMutex
+	current *ModelSnapshot
+}
+
+// Models adalah model store yang dipakai oleh seluruh service
+var Models = &ModelStore{}
+
+// Activate mengganti model aktif secara atomik
+func (s *ModelStore) Activate(snapshot *ModelSnapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current = snapshot
+}
+
+// IsLoaded bernilai true jika sudah ada model aktif dengan Q-Table yang tidak kosong
+func (s *ModelStore) IsLoaded() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current != nil && len(s.current.QTable) > 0
+}
+
+// Versi mengembalikan versi model aktif, 0 jika belum ada model yang dimuat
+func (s *ModelStore) Versi() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.current == nil {
+		return 0
+	}
+	return s.current.Versi
+}
+
+// GetQActions mengembalikan salinan nilai Q seluruh aksi pada sebuah state beserta versi model asalnya
+func (s *ModelStore) GetQActions(state string) (map[string]float64, int, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.current == nil {
+		return nil, 0, false
+	}
+
+	actions, ok := s.current.QTable[state]
+	if !ok {
+		return nil, s.current.Versi, false
+	}
+	salinan := make(map[string]float64, len(actions))
+	for action, value := range actions {
+		salinan[action] = value
+	}
+	return salinan, s.current.Versi, true
+}
+
+// SetQValue memperbarui nilai Q di memori. Pembaruan diabaikan jika model aktif sudah berganti versi
+// sejak nilai tersebut dihitung.
+func (s *ModelStore) SetQValue(versi int, state, action string, value float64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current == nil || s.current.Versi != versi {
+		return false
+	}
+
+	if s.current.QTable[state] == nil {
+		s.current.QTable[state] = make(map[string]float64)
+	}
+	s.current.QTable[state][action] = value
+	return true
+}
+
+// States mengembalikan seluruh state pada Q-Table model aktif
+func (s *ModelStore) States() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.current == nil {
+		return nil
+	}
+
+	states := make([]string, 0, len(s.current.QTable))
+	for state := range s.current.QTable {
+		states = append(states, state)
+	}
+	return states
+}
+
+// HistoricalBest mengembalikan jadwal historis model aktif, diurutkan dari persentase efektif tertinggi
+func (s *ModelStore) HistoricalBest() []HistoricalInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.current == nil {
+		return nil
+	}
+	return append([]HistoricalInfo(nil), s.current.HistoricalBest...)
+}
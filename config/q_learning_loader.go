@@ -1,53 +1,310 @@
 package config
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"os"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/habbazettt/mahad-service-go/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type QTable map[string]map[string]float64
 
 type HistoricalInfo struct {
 	Jadwal            string  `json:"-"`
+	TotalPenggunaan   int     `json:"Total Penggunaan"`
+	PenggunaanEfektif int     `json:"Penggunaan Dianggap Efektif (Skor >=4)"`
 	PersentaseEfektif float64 `json:"Persentase Efektif (%)"`
 }
 
-var (
-	QTableModel    QTable
-	HistoricalBest []HistoricalInfo
-)
+// ErrModelVersiNotFound dikembalikan jika versi model yang diminta tidak ada
+var ErrModelVersiNotFound = errors.New("versi model tidak ditemukan")
+
+// activateMu memastikan urutan aktivasi di database sama dengan urutan penggantian model di ModelStore
+var activateMu sync.Mutex
+
+// ParseModel memvalidasi dan mengurai Q-Table serta data historis (opsional).
+// Setiap state harus berformat "<kesibukan>_<kategori hafalan>" dan memiliki minimal satu aksi dengan nilai Q yang valid.
+func ParseModel(qTableJSON, historicalJSON []byte) (QTable, []HistoricalInfo, error) {
+	var qTable QTable
+	if err := json.Unmarshal(qTableJSON, &qTable); err != nil {
+		return nil, nil, fmt.Errorf("q_table tidak valid: %w", err)
+	}
+	if len(qTable) == 0 {
+		return nil, nil, errors.New("q_table tidak boleh kosong")
+	}
+	for state, actions := range qTable {
+		idx := strings.LastIndex(state, "_")
+		if idx <= 0 || idx == len(state)-1 {
+			return nil, nil, fmt.Errorf("state %q harus berformat <kesibukan>_<kategori hafalan>", state)
+		}
+		if len(actions) == 0 {
+			return nil, nil, fmt.Errorf("state %q tidak memiliki aksi", state)
+		}
+		for action, value := range actions {
+			if strings.TrimSpace(action) == "" {
+				return nil, nil, fmt.Errorf("state %q memiliki aksi kosong", state)
+			}
+			if math.IsNaN(value) || math.IsInf(value, 0) {
+				return nil, nil, fmt.Errorf("nilai Q untuk %q pada state %q tidak valid", action, state)
+			}
+		}
+	}
+
+	var historical []HistoricalInfo
+	if len(historicalJSON) > 0 {
+		var historicalMap map[string]HistoricalInfo
+		if err := json.Unmarshal(historicalJSON, &historicalMap); err != nil {
+			return nil, nil, fmt.Errorf("historical_best tidak valid: %w", err)
+		}
+		for jadwal, info := range historicalMap {
+			if info.PersentaseEfektif < 0 || info.PersentaseEfektif > 100 {
+				return nil, nil, fmt.Errorf("persentase efektif untuk %q harus di antara 0 dan 100", jadwal)
+			}
+			info.Jadwal = jadwal
+			historical = append(historical, info)
+		}
+		sort.Slice(historical, func(i, j int) bool {
+			if historical[i].PersentaseEfektif != historical[j].PersentaseEfektif {
+				return historical[i].PersentaseEfektif > historical[j].PersentaseEfektif
+			}
+			return historical[i].Jadwal < historical[j].Jadwal
+		})
+	}
+
+	return qTable, historical, nil
+}
+
+// NewModelRekomendasi menyiapkan baris versi model baru beserta metadatanya dari isi file yang sudah divalidasi
+func NewModelRekomendasi(versi int, qTableJSON, historicalJSON []byte, qTable QTable, keterangan string) models.ModelRekomendasi {
+	hash := sha256.New()
+	hash.Write(qTableJSON)
+	hash.Write(historicalJSON)
+
+	jumlahAksi := 0
+	for _, actions := range qTable {
+		jumlahAksi += len(actions)
+	}
 
-func LoadQlearningModels() error {
+	model := models.ModelRekomendasi{
+		Versi:       versi,
+		QTable:      string(qTableJSON),
+		Checksum:    hex.EncodeToString(hash.Sum(nil)),
+		JumlahState: len(qTable),
+		JumlahAksi:  jumlahAksi,
+		Keterangan:  keterangan,
+	}
+	if len(historicalJSON) > 0 {
+		historical := string(historicalJSON)
+		model.HistoricalBest = &historical
+	}
+	return model
+}
+
+// LoadQlearningModels memuat versi model yang aktif di database ke ModelStore.
+// Jika belum ada versi sama sekali, q_table_model.json dan historical_best.json di working directory
+// disimpan sebagai versi 1.
+func LoadQlearningModels(db *gorm.DB) error {
+	var total int64
+	if err := db.Model(&models.ModelRekomendasi{}).Count(&total).Error; err != nil {
+		return fmt.Errorf("gagal memeriksa versi model: %w", err)
+	}
+	if total == 0 {
+		if err := bootstrapModelDariFile(db); err != nil {
+			return err
+		}
+	}
+
+	var versi int
+	err := db.Model(&models.ModelRekomendasi{}).
+		Where("is_active = ?", true).
+		Order("versi DESC").
+		Limit(1).
+		Pluck("versi", &versi).Error
+	if err != nil {
+		return fmt.Errorf("gagal mengambil versi model aktif: %w", err)
+	}
+	if versi == 0 {
+		// Tidak ada versi aktif, pakai versi terbaru
+		if err := db.Model(&models.ModelRekomendasi{}).Order("versi DESC").Limit(1).Pluck("versi", &versi).Error; err != nil {
+			return fmt.Errorf("gagal mengambil versi model terbaru: %w", err)
+		}
+	}
+
+	snapshot, err := ActivateModelVersion(db, versi)
+	if err != nil {
+		return err
+	}
+	logrus.WithFields(logrus.Fields{
+		"versi":        snapshot.Versi,
+		"jumlah_state": len(snapshot.QTable),
+	}).Info("Model Q-Learning berhasil dimuat")
+	return nil
+}
+
+func bootstrapModelDariFile(db *gorm.DB) error {
 	qTableFile, err := os.ReadFile("./q_table_model.json")
 	if err != nil {
 		return fmt.Errorf("gagal membaca q_table_model.json: %w", err)
 	}
-	if err := json.Unmarshal(qTableFile, &QTableModel); err != nil {
-		return fmt.Errorf("gagal unmarshal q_table_model.json: %w", err)
+	historicalFile, err := os.ReadFile("./historical_best.json")
+	if err != nil {
+		logrus.Warn("File historical_best.json tidak ditemukan. Fitur fallback historis tidak akan aktif.")
+		historicalFile = nil
 	}
-	fmt.Println("Model Q-Table berhasil dimuat.")
 
-	historicalFile, err := os.ReadFile("./historical_best.json")
+	qTable, _, err := ParseModel(qTableFile, historicalFile)
 	if err != nil {
-		fmt.Println("Peringatan: file historical_best.json tidak ditemukan. Fitur fallback historis tidak akan aktif.")
-	} else {
-		var tempHistoricalMap map[string]HistoricalInfo
-		if err := json.Unmarshal(historicalFile, &tempHistoricalMap); err != nil {
-			return fmt.Errorf("gagal unmarshal historical_best.json: %w", err)
+		return err
+	}
+
+	model := NewModelRekomendasi(1, qTableFile, historicalFile, qTable, "Model awal dari q_table_model.json")
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model).Error; err != nil {
+		return fmt.Errorf("gagal menyimpan model awal: %w", err)
+	}
+	return nil
+}
+
+// ActivateModelVersion menjadikan satu versi model aktif di database lalu memuatnya ke ModelStore.
+// Model divalidasi ulang sebelum diaktifkan, dan nilai Q hasil pembelajaran online untuk versi tersebut ikut dimuat.
+func ActivateModelVersion(db *gorm.DB, versi int) (*ModelSnapshot, error) {
+	activateMu.Lock()
+	defer activateMu.Unlock()
+
+	var snapshot *ModelSnapshot
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var model models.ModelRekomendasi
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("versi = ?", versi).First(&model).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrModelVersiNotFound
+			}
+			return err
 		}
 
-		for jadwal, info := range tempHistoricalMap {
-			info.Jadwal = jadwal
-			HistoricalBest = append(HistoricalBest, info)
+		var err error
+		snapshot, err = bacaSnapshotModel(tx, model)
+		if err != nil {
+			return err
 		}
 
-		sort.Slice(HistoricalBest, func(i, j int) bool {
-			return HistoricalBest[i].PersentaseEfektif > HistoricalBest[j].PersentaseEfektif
-		})
-		fmt.Println("Data historis untuk fallback berhasil dimuat dan diurutkan.")
+		now := time.Now()
+		if err := tx.Model(&models.ModelRekomendasi{}).Where("is_active = ? AND versi <> ?", true, versi).Update("is_active", false).Error; err != nil {
+			return err
+		}
+		if !model.IsActive {
+			if err := tx.Model(&model).Updates(map[string]interface{}{"is_active": true, "aktif_sejak": now}).Error; err != nil {
+				return err
+			}
+		} else if model.AktifSejak != nil {
+			now = *model.AktifSejak
+		}
+
+		snapshot.AktifSejak = now
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	Models.Activate(snapshot)
+	return snapshot, nil
+}
+
+// bacaSnapshotModel memvalidasi satu versi model dan menggabungkannya dengan nilai Q hasil pembelajaran online
+func bacaSnapshotModel(tx *gorm.DB, model models.ModelRekomendasi) (*ModelSnapshot, error) {
+	var historicalJSON []byte
+	if model.HistoricalBest != nil {
+		historicalJSON = []byte(*model.HistoricalBest)
+	}
+	qTable, historical, err := ParseModel([]byte(model.QTable), historicalJSON)
+	if err != nil {
+		return nil, fmt.Errorf("model versi %d tidak valid: %w", model.Versi, err)
 	}
 
+	var qValues []models.QValue
+	if err := tx.Where("model_versi = ?", model.Versi).Find(&qValues).Error; err != nil {
+		return nil, err
+	}
+	for _, q := range qValues {
+		if qTable[q.State] == nil {
+			qTable[q.State] = make(map[string]float64)
+		}
+		qTable[q.State][q.Action] = q.Value
+	}
+
+	snapshot := &ModelSnapshot{
+		Versi:          model.Versi,
+		QTable:         qTable,
+		HistoricalBest: historical,
+	}
+	if model.AktifSejak != nil {
+		snapshot.AktifSejak = *model.AktifSejak
+	}
+	return snapshot, nil
+}
+
+// SinkronkanModelAktif memuat ulang ModelStore jika versi aktif di database berbeda dengan versi di memori,
+// misalnya karena versi baru diunggah atau diaktifkan lewat instance server lain. Database tidak diubah.
+func SinkronkanModelAktif(db *gorm.DB) error {
+	activateMu.Lock()
+	defer activateMu.Unlock()
+
+	var model models.ModelRekomendasi
+	err := db.Where("is_active = ?", true).Order("versi DESC").First(&model).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if model.Versi == Models.Versi() {
+		return nil
+	}
+
+	snapshot, err := bacaSnapshotModel(db, model)
+	if err != nil {
+		return err
+	}
+	Models.Activate(snapshot)
+	logrus.WithField("versi", snapshot.Versi).Info("Model Q-Learning diperbarui mengikuti versi aktif di database")
 	return nil
 }
+
+// PantauModelAktif menjalankan SinkronkanModelAktif secara berkala agar semua instance server memakai versi model
+// yang sama. Interval dibaca dari MODEL_SYNC_INTERVAL (detik), default 30 detik.
+func PantauModelAktif(ctx context.Context, db *gorm.DB) {
+	interval := 30 * time.Second
+	if detik, err := strconv.Atoi(os.Getenv("MODEL_SYNC_INTERVAL")); err == nil && detik > 0 {
+		interval = time.Duration(detik) * time.Second
+	}
+	logrus.WithField("interval", interval.String()).Info("Sinkronisasi versi model aktif dijalankan di background")
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := SinkronkanModelAktif(db); err != nil {
+					logrus.WithError(err).Error("Gagal menyinkronkan versi model aktif")
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseModelValid(t *testing.T) {
+	qTableJSON := []byte(`{"kuliah_1-10 Juz": {"Setelah Isya": 1.5, "Sebelum Subuh": -0.25}}`)
+	historicalJSON := []byte(`{
+		"Setelah Maghrib": {"Total Penggunaan": 4, "Penggunaan Dianggap Efektif (Skor >=4)": 3, "Persentase Efektif (%)": 75},
+		"Setelah Isya": {"Total Penggunaan": 10, "Penggunaan Dianggap Efektif (Skor >=4)": 9, "Persentase Efektif (%)": 90},
+		"Sebelum Subuh": {"Total Penggunaan": 8, "Penggunaan Dianggap Efektif (Skor >=4)": 6, "Persentase Efektif (%)": 75}
+	}`)
+
+	qTable, historical, err := ParseModel(qTableJSON, historicalJSON)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.InDelta(t, 1.5, qTable["kuliah_1-10 Juz"]["Setelah Isya"], 1e-9)
+
+	// Historis diurutkan dari persentase efektif tertinggi, lalu nama jadwal
+	if assert.Len(t, historical, 3) {
+		assert.Equal(t, "Setelah Isya", historical[0].Jadwal)
+		assert.Equal(t, "Sebelum Subuh", historical[1].Jadwal)
+		assert.Equal(t, "Setelah Maghrib", historical[2].Jadwal)
+		assert.Equal(t, 8, historical[1].TotalPenggunaan)
+		assert.Equal(t, 6, historical[1].PenggunaanEfektif)
+	}
+}
+
+func TestParseModelTanpaHistoris(t *testing.T) {
+	qTable, historical, err := ParseModel([]byte(`{"kerja_21-30 Juz": {"Setelah Isya": 0}}`), nil)
+	assert.NoError(t, err)
+	assert.Len(t, qTable, 1)
+	assert.Nil(t, historical)
+}
+
+func TestParseModelTidakValid(t *testing.T) {
+	cases := []struct {
+		name       string
+		qTable     string
+		historical string
+	}{
+		{"json rusak", `{"kuliah_1-10 Juz":`, ""},
+		{"q_table kosong", `{}`, ""},
+		{"state tanpa kategori", `{"kuliah": {"Setelah Isya": 1}}`, ""},
+		{"state tanpa kesibukan", `{"_1-10 Juz": {"Setelah Isya": 1}}`, ""},
+		{"kategori kosong", `{"kuliah_": {"Setelah Isya": 1}}`, ""},
+		{"tanpa aksi", `{"kuliah_1-10 Juz": {}}`, ""},
+		{"aksi kosong", `{"kuliah_1-10 Juz": {"  ": 1}}`, ""},
+		{"historis rusak", `{"kuliah_1-10 Juz": {"Setelah Isya": 1}}`, `[1, 2]`},
+		{"persentase di atas 100", `{"kuliah_1-10 Juz": {"Setelah Isya": 1}}`, `{"Setelah Isya": {"Persentase Efektif (%)": 120}}`},
+		{"persentase negatif", `{"kuliah_1-10 Juz": {"Setelah Isya": 1}}`, `{"Setelah Isya": {"Persentase Efektif (%)": -1}}`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var historical []byte
+			if tc.historical != "" {
+				historical = []byte(tc.historical)
+			}
+			_, _, err := ParseModel([]byte(tc.qTable), historical)
+			assert.Error(t, err)
+		})
+	}
+}
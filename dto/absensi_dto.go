@@ -50,3 +50,21 @@ type AbsensiDailySummaryDTO struct {
 	Shubuh  string `json:"shubuh"`  // hadir / alpa / izin / libur / belum-absen
 	Isya    string `json:"isya"`    // hadir / alpa / izin / libur / belum-absen
 }
+
+type AbsensiBatchItemResult struct {
+	Index        int                 `json:"index"`
+	MahasantriID uint                `json:"mahasantri_id"`
+	Tanggal      string              `json:"tanggal"`
+	Waktu        string              `json:"waktu"`
+	Status       string              `json:"status"` // created / updated / skipped / error
+	Reason       string              `json:"reason,omitempty"`
+	Details      interface{}         `json:"details,omitempty"`
+	Data         *AbsensiResponseDTO `json:"data,omitempty"`
+}
+
+type AbsensiBatchSummary struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Skipped int `json:"skipped"`
+	Error   int `json:"error"`
+}
@@ -0,0 +1,47 @@
+package dto
+
+type JumlahPerKategori struct {
+	Nama   string `json:"nama"`
+	Jumlah int64  `json:"jumlah"`
+}
+
+// RingkasanSesiMurojaah merangkum sesi murojaah (detail log) beserta tingkat penyelesaiannya
+type RingkasanSesiMurojaah struct {
+	TotalSesi           int64   `json:"total_sesi"`
+	SesiSelesai         int64   `json:"sesi_selesai"`
+	TingkatPenyelesaian float64 `json:"tingkat_penyelesaian"`
+	TotalTargetHalaman  int64   `json:"total_target_halaman"`
+	TotalSelesaiHalaman int64   `json:"total_selesai_halaman"`
+	PersentaseHalaman   float64 `json:"persentase_halaman"`
+}
+
+type AnalitikStateRekomendasi struct {
+	State           string  `json:"state"`
+	TotalPermintaan int64   `json:"total_permintaan"`
+	TotalFallback   int64   `json:"total_fallback"`
+	TingkatFallback float64 `json:"tingkat_fallback"`
+	TotalDiadopsi   int64   `json:"total_diadopsi"`
+	TingkatAdopsi   float64 `json:"tingkat_adopsi"`
+}
+
+type AnalitikRekomendasiResponse struct {
+	TotalPermintaan int64                      `json:"total_permintaan"`
+	TotalFallback   int64                      `json:"total_fallback"`
+	TingkatFallback float64                    `json:"tingkat_fallback"`
+	TotalDiadopsi   int64                      `json:"total_diadopsi"`
+	TingkatAdopsi   float64                    `json:"tingkat_adopsi"`
+	PerTipe         []JumlahPerKategori        `json:"per_tipe"`
+	PerEngine       []JumlahPerKategori        `json:"per_engine"`
+	SesiAI          RingkasanSesiMurojaah      `json:"sesi_ai"`
+	SesiManual      RingkasanSesiMurojaah      `json:"sesi_manual"`
+	PerState        []AnalitikStateRekomendasi `json:"per_state"`
+}
+
+type TrenRekomendasiItem struct {
+	Periode         string                `json:"periode"` // Tanggal awal periode, format dd-mm-yyyy
+	TotalPermintaan int64                 `json:"total_permintaan"`
+	TotalFallback   int64                 `json:"total_fallback"`
+	TotalDiadopsi   int64                 `json:"total_diadopsi"`
+	SesiAI          RingkasanSesiMurojaah `json:"sesi_ai"`
+	SesiManual      RingkasanSesiMurojaah `json:"sesi_manual"`
+}
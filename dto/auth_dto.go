@@ -6,7 +6,10 @@ type RegisterMahasantriRequest struct {
 	Jurusan  string `json:"jurusan" validate:"required"`
 	Gender   string `json:"gender" validate:"required,oneof=L P"`
 	Password string `json:"password" validate:"required,min=6"`
-	MentorID uint   `json:"mentor_id" validate:"required"`
+	MentorID uint   `json:"mentor_id,omitempty"` // Kosongkan untuk penempatan otomatis ke mentor dengan beban paling ringan
+
+	TotalHafalan         *int `json:"total_hafalan,omitempty"` // Jumlah juz, dipakai jika pertimbangkan_hafalan aktif
+	PertimbangkanHafalan bool `json:"pertimbangkan_hafalan,omitempty"`
 }
 
 type RegisterMentorRequest struct {
@@ -68,3 +71,16 @@ type UserMentorWithMahasantriResponse struct {
 	UserType             string                   `json:"user_type"`
 	IsDataMurojaahFilled bool                     `json:"is_data_murojaah_filled"`
 }
+
+type LoginWaliRequest struct {
+	NoHP     string `json:"no_hp" validate:"required"`
+	Password string `json:"password" validate:"required,min=6"`
+}
+
+type UserWaliResponse struct {
+	ID         uint               `json:"id"`
+	Nama       string             `json:"nama"`
+	NoHP       string             `json:"no_hp"`
+	UserType   string             `json:"user_type"`
+	Mahasantri []AnakWaliResponse `json:"mahasantri"`
+}
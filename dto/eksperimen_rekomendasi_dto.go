@@ -0,0 +1,67 @@
+package dto
+
+import "time"
+
+type VarianEksperimenRequest struct {
+	Nama   string `json:"nama"`
+	Engine string `json:"engine"` // qlearning, historis, rule_based, atau thompson
+	Bobot  int    `json:"bobot"`  // Persentase trafik, total seluruh varian harus 100
+}
+
+type CreateEksperimenRekomendasiRequest struct {
+	Nama           string                    `json:"nama"`
+	Deskripsi      string                    `json:"deskripsi"`
+	TanggalMulai   string                    `json:"tanggal_mulai"`             // Format: dd-mm-yyyy
+	TanggalSelesai string                    `json:"tanggal_selesai,omitempty"` // Format: dd-mm-yyyy, inklusif
+	Varian         []VarianEksperimenRequest `json:"varian"`
+}
+
+type VarianEksperimenResponse struct {
+	ID     uint   `json:"id"`
+	Nama   string `json:"nama"`
+	Engine string `json:"engine"`
+	Bobot  int    `json:"bobot"`
+}
+
+type EksperimenRekomendasiResponse struct {
+	ID             uint                       `json:"id"`
+	Nama           string                     `json:"nama"`
+	Deskripsi      string                     `json:"deskripsi,omitempty"`
+	TanggalMulai   string                     `json:"tanggal_mulai"`
+	TanggalSelesai string                     `json:"tanggal_selesai,omitempty"`
+	DihentikanPada *time.Time                 `json:"dihentikan_pada,omitempty"`
+	Aktif          bool                       `json:"aktif"`
+	Varian         []VarianEksperimenResponse `json:"varian"`
+	CreatedAt      time.Time                  `json:"created_at"`
+}
+
+// MetrikProporsi adalah persentase keberhasilan beserta interval kepercayaan Wilson 95%
+type MetrikProporsi struct {
+	Berhasil   int64   `json:"berhasil"`
+	Total      int64   `json:"total"`
+	Persentase float64 `json:"persentase"`
+	BatasBawah float64 `json:"batas_bawah"`
+	BatasAtas  float64 `json:"batas_atas"`
+}
+
+// MetrikRataRata adalah rata-rata skor beserta interval kepercayaan normal 95%
+type MetrikRataRata struct {
+	Jumlah     int64    `json:"jumlah"`
+	RataRata   *float64 `json:"rata_rata,omitempty"`
+	BatasBawah *float64 `json:"batas_bawah,omitempty"`
+	BatasAtas  *float64 `json:"batas_atas,omitempty"`
+}
+
+type HasilVarianEksperimen struct {
+	VarianEksperimenResponse
+	TotalPaparan   int64          `json:"total_paparan"`   // Jumlah pengguna yang dilayani varian
+	Penerimaan     MetrikProporsi `json:"penerimaan"`      // Pengguna yang pernah mengadopsi rekomendasi dari seluruh pengguna terpapar
+	Penyelesaian   MetrikProporsi `json:"penyelesaian"`    // Pengguna dengan minimal satu sesi selesai dari pengguna yang membuat sesi
+	Efektivitas    MetrikRataRata `json:"efektivitas"`     // Rata-rata skor efektivitas 1-5 tiap pengguna
+	DinilaiEfektif MetrikProporsi `json:"dinilai_efektif"` // Pengguna dengan rata-rata skor >= 4
+}
+
+type HasilEksperimenRekomendasiResponse struct {
+	Eksperimen EksperimenRekomendasiResponse `json:"eksperimen"`
+	Varian     []HasilVarianEksperimen       `json:"varian"`
+}
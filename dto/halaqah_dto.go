@@ -0,0 +1,50 @@
+package dto
+
+type CreateHalaqahRequest struct {
+	Nama    string `json:"nama" validate:"required"`
+	Jadwal  string `json:"jadwal,omitempty"`
+	Gender  string `json:"gender" validate:"required,oneof=L P"`
+	Ruangan string `json:"ruangan,omitempty"`
+}
+
+type UpdateHalaqahRequest struct {
+	Nama    *string `json:"nama,omitempty"`
+	Jadwal  *string `json:"jadwal,omitempty"`
+	Gender  *string `json:"gender,omitempty"`
+	Ruangan *string `json:"ruangan,omitempty"`
+}
+
+type HalaqahMentorRequest struct {
+	MentorID uint   `json:"mentor_id" validate:"required"`
+	Peran    string `json:"peran" validate:"omitempty,oneof=utama pendamping pengganti"`
+}
+
+type HalaqahAnggotaRequest struct {
+	MahasantriIDs []uint `json:"mahasantri_ids" validate:"required"`
+	Tanggal       string `json:"tanggal,omitempty"` // Format: dd-mm-yyyy, default hari ini
+}
+
+type HalaqahMentorResponse struct {
+	MentorID uint   `json:"mentor_id"`
+	Nama     string `json:"nama"`
+	Peran    string `json:"peran"`
+}
+
+type HalaqahAnggotaResponse struct {
+	MahasantriID  uint   `json:"mahasantri_id"`
+	Nama          string `json:"nama"`
+	NIM           string `json:"nim"`
+	TanggalMasuk  string `json:"tanggal_masuk"`
+	TanggalKeluar string `json:"tanggal_keluar,omitempty"`
+}
+
+type HalaqahResponse struct {
+	ID            uint                     `json:"id"`
+	Nama          string                   `json:"nama"`
+	Jadwal        string                   `json:"jadwal"`
+	Gender        string                   `json:"gender"`
+	Ruangan       string                   `json:"ruangan"`
+	JumlahAnggota int                      `json:"jumlah_anggota"`
+	Mentors       []HalaqahMentorResponse  `json:"mentors"`
+	Anggota       []HalaqahAnggotaResponse `json:"anggota,omitempty"`
+}
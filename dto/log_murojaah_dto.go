@@ -42,8 +42,9 @@ type LogHarianResponse struct {
 }
 
 type MahasantriInfoForLog struct {
-	ID   uint   `json:"id"`
-	Nama string `json:"nama"`
+	ID            uint   `json:"id"`
+	Nama          string `json:"nama"`
+	QadhaTertunda int    `json:"qadha_tertunda"`
 }
 
 type LogHarianForMentorResponse struct {
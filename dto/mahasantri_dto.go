@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type MahasantriResponse struct {
 	ID                   uint                    `json:"id"`
 	Nama                 string                  `json:"nama"`
@@ -7,8 +9,24 @@ type MahasantriResponse struct {
 	Jurusan              string                  `json:"jurusan"`
 	Gender               string                  `json:"gender"`
 	MentorID             uint                    `json:"mentor_id"`
+	Status               string                  `json:"status"`
 	IsDataMurojaahFilled bool                    `json:"is_data_murojaah_filled"`
+	QadhaTertunda        int                     `json:"qadha_tertunda"`
 	JadwalPersonal       *JadwalPersonalResponse `json:"jadwal_personal,omitempty"`
+	*ProfilMahasantri
+}
+
+// ProfilMahasantri berisi data profil tambahan yang hanya ditampilkan pada endpoint terautentikasi
+type ProfilMahasantri struct {
+	Angkatan         int    `json:"angkatan,omitempty"`
+	Asrama           string `json:"asrama,omitempty"`
+	Kamar            string `json:"kamar,omitempty"`
+	NoHP             string `json:"no_hp,omitempty"`
+	NamaWali         string `json:"nama_wali,omitempty"`
+	NoHPWali         string `json:"no_hp_wali,omitempty"`
+	TanggalLahir     string `json:"tanggal_lahir,omitempty"` // Format: dd-mm-yyyy
+	FotoURL          string `json:"foto_url,omitempty"`
+	FotoThumbnailURL string `json:"foto_thumbnail_url,omitempty"`
 }
 
 type UpdateMahasantriRequest struct {
@@ -17,4 +35,142 @@ type UpdateMahasantriRequest struct {
 	Jurusan  *string `json:"jurusan,omitempty"`
 	Gender   *string `json:"gender,omitempty"`
 	MentorID *uint   `json:"mentor_id,omitempty"`
+
+	Angkatan     *int    `json:"angkatan,omitempty"`
+	Asrama       *string `json:"asrama,omitempty"`
+	Kamar        *string `json:"kamar,omitempty"`
+	NoHP         *string `json:"no_hp,omitempty"`
+	NamaWali     *string `json:"nama_wali,omitempty"`
+	NoHPWali     *string `json:"no_hp_wali,omitempty"`
+	TanggalLahir *string `json:"tanggal_lahir,omitempty"` // Format: dd-mm-yyyy, string kosong untuk menghapus
+}
+
+type TransferMentorRequest struct {
+	MentorID       uint   `json:"mentor_id" validate:"required"`
+	TanggalEfektif string `json:"tanggal_efektif,omitempty"` // Format: dd-mm-yyyy, default hari ini
+	Alasan         string `json:"alasan,omitempty"`
+}
+
+type MentorAssignmentResponse struct {
+	ID              uint   `json:"id"`
+	MentorID        uint   `json:"mentor_id"`
+	NamaMentor      string `json:"nama_mentor"`
+	MulaiTanggal    string `json:"mulai_tanggal"`
+	SelesaiTanggal  string `json:"selesai_tanggal,omitempty"`
+	Alasan          string `json:"alasan,omitempty"`
+	DipindahkanOleh *uint  `json:"dipindahkan_oleh,omitempty"`
+}
+
+type UpdateStatusMahasantriRequest struct {
+	Status         string `json:"status" validate:"required,oneof=aktif cuti lulus keluar"`
+	TanggalEfektif string `json:"tanggal_efektif,omitempty"` // Format: dd-mm-yyyy, default hari ini
+	Keterangan     string `json:"keterangan,omitempty"`
+}
+
+type RiwayatStatusMahasantriResponse struct {
+	ID             uint      `json:"id"`
+	StatusLama     string    `json:"status_lama"`
+	StatusBaru     string    `json:"status_baru"`
+	TanggalEfektif string    `json:"tanggal_efektif"`
+	Keterangan     string    `json:"keterangan,omitempty"`
+	DiubahOleh     *uint     `json:"diubah_oleh,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type KelulusanAngkatanRequest struct {
+	Angkatan       int    `json:"angkatan" validate:"required"`
+	TanggalEfektif string `json:"tanggal_efektif,omitempty"` // Format: dd-mm-yyyy, default hari ini
+	Keterangan     string `json:"keterangan,omitempty"`
+}
+
+type KelulusanMahasantriItem struct {
+	ID   uint   `json:"id"`
+	Nama string `json:"nama"`
+	NIM  string `json:"nim"`
+}
+
+type KelulusanAngkatanResponse struct {
+	DryRun         bool                      `json:"dry_run"`
+	Angkatan       int                       `json:"angkatan"`
+	TanggalEfektif string                    `json:"tanggal_efektif"`
+	Total          int                       `json:"total"`
+	Mahasantri     []KelulusanMahasantriItem `json:"mahasantri"`
+}
+
+type ArsipMahasantriResponse struct {
+	ID             uint      `json:"id"`
+	Nama           string    `json:"nama"`
+	NIM            string    `json:"nim"`
+	Jurusan        string    `json:"jurusan"`
+	Gender         string    `json:"gender"`
+	MentorID       uint      `json:"mentor_id"`
+	DiarsipkanPada time.Time `json:"diarsipkan_pada"`
+}
+
+type ImportMahasantriRow struct {
+	Row            int      `json:"row"`
+	Nama           string   `json:"nama"`
+	NIM            string   `json:"nim"`
+	Jurusan        string   `json:"jurusan"`
+	Gender         string   `json:"gender"`
+	MentorID       uint     `json:"mentor_id"`
+	MentorOtomatis bool     `json:"mentor_otomatis,omitempty"`
+	MahasantriID   uint     `json:"mahasantri_id,omitempty"`
+	Errors         []string `json:"errors,omitempty"`
+}
+
+type ImportMahasantriResponse struct {
+	DryRun      bool                  `json:"dry_run"`
+	TotalRows   int                   `json:"total_rows"`
+	ValidRows   int                   `json:"valid_rows"`
+	InvalidRows int                   `json:"invalid_rows"`
+	Imported    int                   `json:"imported"`
+	Rows        []ImportMahasantriRow `json:"rows"`
+}
+
+type MahasantriSearchResult struct {
+	ID                  uint     `json:"id"`
+	Nama                string   `json:"nama"`
+	NIM                 string   `json:"nim"`
+	Jurusan             string   `json:"jurusan"`
+	Gender              string   `json:"gender"`
+	MentorID            uint     `json:"mentor_id"`
+	Angkatan            int      `json:"angkatan,omitempty"`
+	Status              string   `json:"status"`
+	TotalJuz            int      `json:"total_juz"`
+	TotalAbsensi        int      `json:"total_absensi"`
+	PersentaseKehadiran *float64 `json:"persentase_kehadiran"`
+	AktivitasTerakhir   string   `json:"aktivitas_terakhir,omitempty"` // Format: dd-mm-yyyy
+	Relevansi           *float64 `json:"relevansi,omitempty"`
+}
+
+type KandidatMentorResponse struct {
+	MentorID     uint   `json:"mentor_id"`
+	Nama         string `json:"nama"`
+	Gender       string `json:"gender"`
+	Beban        int    `json:"beban"`
+	TotalHafalan *int   `json:"total_hafalan,omitempty"`
+}
+
+type PenempatanMentorRequest struct {
+	MahasantriIDs        []uint `json:"mahasantri_ids" validate:"required"`
+	PertimbangkanHafalan bool   `json:"pertimbangkan_hafalan,omitempty"`
+	Alasan               string `json:"alasan,omitempty"`
+}
+
+type PenempatanMentorItem struct {
+	MahasantriID   uint   `json:"mahasantri_id"`
+	Nama           string `json:"nama"`
+	MentorLamaID   uint   `json:"mentor_lama_id"`
+	MentorBaruID   uint   `json:"mentor_baru_id,omitempty"`
+	NamaMentorBaru string `json:"nama_mentor_baru,omitempty"`
+	Berubah        bool   `json:"berubah"`
+	Error          string `json:"error,omitempty"`
+}
+
+type PenempatanMentorResponse struct {
+	DryRun      bool                     `json:"dry_run"`
+	Dipindahkan int                      `json:"dipindahkan"`
+	Penempatan  []PenempatanMentorItem   `json:"penempatan"`
+	BebanMentor []KandidatMentorResponse `json:"beban_mentor"`
 }
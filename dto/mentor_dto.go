@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type MentorResponse struct {
 	ID              uint                    `json:"id"`
 	Nama            string                  `json:"nama"`
@@ -15,3 +17,11 @@ type UpdateMentorRequest struct {
 	Email  *string `json:"email,omitempty"`
 	Gender *string `json:"gender,omitempty"`
 }
+
+type ArsipMentorResponse struct {
+	ID             uint      `json:"id"`
+	Nama           string    `json:"nama"`
+	Email          string    `json:"email"`
+	Gender         string    `json:"gender"`
+	DiarsipkanPada time.Time `json:"diarsipkan_pada"`
+}
@@ -0,0 +1,14 @@
+package dto
+
+import "time"
+
+type ModelRekomendasiResponse struct {
+	Versi       int        `json:"versi"`
+	Checksum    string     `json:"checksum"`
+	JumlahState int        `json:"jumlah_state"`
+	JumlahAksi  int        `json:"jumlah_aksi"`
+	Keterangan  string     `json:"keterangan,omitempty"`
+	IsActive    bool       `json:"is_active"`
+	AktifSejak  *time.Time `json:"aktif_sejak,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
@@ -0,0 +1,31 @@
+package dto
+
+import "time"
+
+type JadwalkanQadhaRequest struct {
+	Tanggal string `json:"tanggal" validate:"required"` // Format: dd-mm-yyyy
+	Waktu   string `json:"waktu" validate:"required,oneof=shubuh isya"`
+	Catatan string `json:"catatan,omitempty"`
+}
+
+type SelesaikanQadhaRequest struct {
+	HafalanID uint   `json:"hafalan_id" validate:"required"`
+	Catatan   string `json:"catatan,omitempty"`
+}
+
+type QadhaResponse struct {
+	ID             uint                  `json:"id"`
+	AbsensiID      uint                  `json:"absensi_id"`
+	Alasan         string                `json:"alasan"` // alpa / izin
+	TanggalAbsensi string                `json:"tanggal_absensi"`
+	WaktuAbsensi   string                `json:"waktu_absensi"`
+	Status         string                `json:"status"` // pending / terjadwal / selesai
+	JadwalTanggal  string                `json:"jadwal_tanggal,omitempty"`
+	JadwalWaktu    string                `json:"jadwal_waktu,omitempty"`
+	HafalanID      *uint                 `json:"hafalan_id,omitempty"`
+	SelesaiPada    *time.Time            `json:"selesai_pada,omitempty"`
+	Catatan        string                `json:"catatan,omitempty"`
+	MentorID       uint                  `json:"mentor_id"`
+	Mahasantri     MahasantriResponseDTO `json:"mahasantri"`
+	CreatedAt      time.Time             `json:"created_at"`
+}
@@ -1,17 +1,83 @@
 package dto
 
+import "time"
+
+// RecommendationRequest - Kesibukan dan kategori hafalan hanya boleh dikirim oleh mentor. Jika keduanya kosong,
+// atau pengguna adalah mahasantri, state diambil dari jadwal personal pengguna.
 type RecommendationRequest struct {
-	Kesibukan       string `json:"kesibukan"`
-	KategoriHafalan string `json:"kategori_hafalan"`
+	Kesibukan       string `json:"kesibukan,omitempty"`
+	KategoriHafalan string `json:"kategori_hafalan,omitempty"`
+	Top             int    `json:"top,omitempty"` // Jumlah alternatif jadwal yang dikembalikan, default 3
 }
 
 type RecommendationResponse struct {
 	ID                        uint     `json:"id"`
 	State                     string   `json:"state"`
+	SumberState               string   `json:"sumber_state,omitempty"` // "jadwal_personal" atau "request"
 	MahasantriID              *uint    `json:"mahasantri_id,omitempty"`
 	MentorID                  *uint    `json:"mentor_id,omitempty"`
 	RekomendasiJadwal         string   `json:"rekomendasi_jadwal"`
 	TipeRekomendasi           string   `json:"tipe_rekomendasi"`
 	EstimasiQValue            *float64 `json:"estimasi_q_value,omitempty"`
 	PersentaseEfektifHistoris *float64 `json:"persentase_efektif_historis,omitempty"`
+	Engine                    string   `json:"engine,omitempty"`
+	EngineVersi               string   `json:"engine_versi,omitempty"`
+	EksperimenID              *uint    `json:"eksperimen_id,omitempty"`
+	VarianEksperimen          string   `json:"varian_eksperimen,omitempty"`
+
+	Peringkat     []PeringkatRekomendasi `json:"peringkat,omitempty"`
+	StateTetangga []StateTetangga        `json:"state_tetangga,omitempty"` // Terisi jika rekomendasi diestimasi dari state serupa
+}
+
+type StateTetangga struct {
+	State string  `json:"state"`
+	Bobot float64 `json:"bobot"`
+}
+
+type PeringkatRekomendasi struct {
+	Peringkat                 int      `json:"peringkat"`
+	Jadwal                    string   `json:"jadwal"`
+	EstimasiQValue            *float64 `json:"estimasi_q_value,omitempty"`
+	PersentaseEfektifHistoris *float64 `json:"persentase_efektif_historis,omitempty"`
+	JumlahSampelHistoris      int      `json:"jumlah_sampel_historis"`
+	JumlahUmpanBalik          int      `json:"jumlah_umpan_balik"`
+	Penjelasan                string   `json:"penjelasan"`
+}
+
+type UmpanBalikRekomendasiRequest struct {
+	Diterapkan  *bool  `json:"diterapkan"`            // Wajib: apakah jadwal rekomendasi benar-benar dipakai
+	Efektivitas *int   `json:"efektivitas,omitempty"` // 1-5, wajib jika diterapkan
+	Komentar    string `json:"komentar"`
+}
+
+type UmpanBalikRekomendasiResponse struct {
+	ID                  uint      `json:"id"`
+	JadwalRekomendasiID uint      `json:"jadwal_rekomendasi_id"`
+	State               string    `json:"state"`
+	RekomendasiJadwal   string    `json:"rekomendasi_jadwal"`
+	Diterapkan          bool      `json:"diterapkan"`
+	Efektivitas         *int      `json:"efektivitas,omitempty"`
+	Komentar            string    `json:"komentar"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+type StatistikRekomendasiState struct {
+	State                    string   `json:"state"`
+	TotalRekomendasi         int64    `json:"total_rekomendasi"`
+	TotalUmpanBalik          int64    `json:"total_umpan_balik"`
+	TotalDiadopsi            int64    `json:"total_diadopsi"`
+	TotalDiterapkanKeLog     int64    `json:"total_diterapkan_ke_log"`
+	TingkatPenerimaan        float64  `json:"tingkat_penerimaan"` // Persentase rekomendasi yang diadopsi
+	TotalSesi                int64    `json:"total_sesi"`
+	TotalSesiSelesai         int64    `json:"total_sesi_selesai"`
+	TingkatKeberhasilan      float64  `json:"tingkat_keberhasilan"` // Persentase sesi murojaah dari rekomendasi yang selesai
+	TotalDinilai             int64    `json:"total_dinilai"`
+	RataRataEfektivitas      *float64 `json:"rata_rata_efektivitas,omitempty"`
+	PersentaseDinilaiEfektif float64  `json:"persentase_dinilai_efektif"` // Persentase penilaian dengan skor >= 4
+}
+
+type OpsiStateRekomendasiResponse struct {
+	Kesibukan       []string `json:"kesibukan"`
+	KategoriHafalan []string `json:"kategori_hafalan"`
+	States          []string `json:"states"`
 }
@@ -0,0 +1,23 @@
+package dto
+
+import "time"
+
+type CabutSuratPeringatanRequest struct {
+	Alasan string `json:"alasan" validate:"required"`
+}
+
+type SuratPeringatanResponse struct {
+	ID               uint                  `json:"id"`
+	Kode             string                `json:"kode"` // SP1 / SP2 / SP3
+	Level            int                   `json:"level"`
+	Semester         string                `json:"semester"`
+	TahunAjaran      string                `json:"tahun_ajaran"`
+	JumlahAlpa       int                   `json:"jumlah_alpa"`
+	Status           string                `json:"status"` // aktif / diakui / dicabut
+	DiakuiPada       *time.Time            `json:"diakui_pada,omitempty"`
+	DicabutPada      *time.Time            `json:"dicabut_pada,omitempty"`
+	AlasanPencabutan string                `json:"alasan_pencabutan,omitempty"`
+	CreatedAt        time.Time             `json:"created_at"`
+	Mahasantri       MahasantriResponseDTO `json:"mahasantri"`
+	MentorID         uint                  `json:"mentor_id"`
+}
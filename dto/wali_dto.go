@@ -0,0 +1,34 @@
+package dto
+
+import "time"
+
+type CreateWaliRequest struct {
+	Nama          string `json:"nama" validate:"required"`
+	NoHP          string `json:"no_hp" validate:"required"`
+	Hubungan      string `json:"hubungan,omitempty" validate:"omitempty,oneof=ayah ibu wali"`
+	MahasantriIDs []uint `json:"mahasantri_ids" validate:"required,min=1"`
+}
+
+type HubungkanWaliRequest struct {
+	MahasantriID uint   `json:"mahasantri_id" validate:"required"`
+	Hubungan     string `json:"hubungan,omitempty" validate:"omitempty,oneof=ayah ibu wali"`
+}
+
+type AnakWaliResponse struct {
+	ID       uint   `json:"id"`
+	Nama     string `json:"nama"`
+	NIM      string `json:"nim"`
+	Jurusan  string `json:"jurusan"`
+	Gender   string `json:"gender"`
+	Status   string `json:"status"`
+	MentorID uint   `json:"mentor_id"`
+	Hubungan string `json:"hubungan,omitempty"`
+}
+
+type WaliResponse struct {
+	ID         uint               `json:"id"`
+	Nama       string             `json:"nama"`
+	NoHP       string             `json:"no_hp"`
+	Mahasantri []AnakWaliResponse `json:"mahasantri"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
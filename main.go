@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -12,6 +16,8 @@ import (
 	_ "github.com/habbazettt/mahad-service-go/docs"
 	"github.com/habbazettt/mahad-service-go/middleware"
 	"github.com/habbazettt/mahad-service-go/routes"
+	"github.com/habbazettt/mahad-service-go/services"
+	"github.com/habbazettt/mahad-service-go/utils"
 	"github.com/sirupsen/logrus"
 	fiberSwagger "github.com/swaggo/fiber-swagger"
 )
@@ -33,12 +39,14 @@ func main() {
 	db := config.ConnectDB()
 	config.MigrateDB()
 
-	if err := config.LoadQlearningModels(); err != nil {
+	if err := config.LoadQlearningModels(db); err != nil {
 		log.Fatalf("Gagal memuat model Q-Learning: %v", err)
 	}
 
 	app := fiber.New()
 
+	routes.SetupHealthRoutes(app)
+
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "https://mtadigital.netlify.app,http://localhost:5173",
 		AllowMethods: "GET,POST,PUT,DELETE",
@@ -46,6 +54,7 @@ func main() {
 	}))
 
 	app.Use(middleware.Logger())
+	app.Use(middleware.Metrics())
 
 	app.Use(limiter.New(limiter.Config{
 		Max:        100,
@@ -60,6 +69,8 @@ func main() {
 		},
 	}))
 
+	routes.SetupMetricsRoutes(app, db)
+
 	app.Get("/", func(c *fiber.Ctx) error {
 		logrus.Info("🚀 Mahad Service API is running!")
 		return c.SendString("🚀 Mahad Service API is running!")
@@ -72,8 +83,14 @@ func main() {
 	routes.SetupAbsensiRoutes(app, db)
 	routes.SetupTargetSemesterRoutes(app, db)
 	routes.SetupRekomendasiRoutes(app, db)
+	routes.SetupModelRekomendasiRoutes(app, db)
+	routes.SetupEksperimenRekomendasiRoutes(app, db)
 	routes.SetupJadwalPersonalRoutes(app, db)
 	routes.SetupLogMurojaahRoutes(app, db)
+	routes.SetupSuratPeringatanRoutes(app, db)
+	routes.SetupQadhaRoutes(app, db)
+	routes.SetupHalaqahRoutes(app, db)
+	routes.SetupWaliRoutes(app, db)
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -82,6 +99,42 @@ func main() {
 
 	app.Get("/swagger/*", fiberSwagger.WrapHandler)
 
-	logrus.Infof("Server berjalan di http://localhost:%s", port)
-	log.Fatal(app.Listen(":" + port))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	services.JalankanRewardMurojaah(ctx, db)
+	config.PantauModelAktif(ctx, db)
+
+	listenErr := make(chan error, 1)
+	go func() {
+		logrus.Infof("Server berjalan di http://localhost:%s", port)
+		listenErr <- app.Listen(":" + port)
+	}()
+
+	select {
+	case err := <-listenErr:
+		config.CloseDB()
+		log.Fatal(err)
+	case <-ctx.Done():
+	}
+
+	timeout := shutdownTimeout()
+	logrus.Infof("Sinyal berhenti diterima, menunggu request selesai (maks %s)", timeout)
+	if err := app.ShutdownWithTimeout(timeout); err != nil {
+		logrus.WithError(err).Error("Gagal menghentikan server dengan bersih")
+	}
+	if !utils.WaitNotifications(timeout) {
+		logrus.Warn("Sebagian notifikasi belum terkirim saat server berhenti")
+	}
+	config.CloseDB()
+	logrus.Info("Server berhenti")
+}
+
+// shutdownTimeout membaca SHUTDOWN_TIMEOUT (detik) untuk batas waktu menunggu request yang sedang berjalan
+func shutdownTimeout() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv("SHUTDOWN_TIMEOUT"))
+	if err != nil || seconds <= 0 {
+		return 15 * time.Second
+	}
+	return time.Duration(seconds) * time.Second
 }
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"os"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/mahad-service-go/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// AdminKeyMiddleware memeriksa header X-Admin-Key untuk operasi administratif.
+// Jika ADMIN_API_KEY tidak diatur, seluruh operasi administratif dinonaktifkan.
+func AdminKeyMiddleware(c *fiber.Ctx) error {
+	adminKey := os.Getenv("ADMIN_API_KEY")
+	if adminKey == "" {
+		logrus.Warn("Admin operation attempted but ADMIN_API_KEY is not configured")
+		return utils.ResponseError(c, fiber.StatusForbidden, "Admin operations are disabled", nil)
+	}
+
+	providedKey := c.Get("X-Admin-Key")
+	if subtle.ConstantTimeCompare([]byte(providedKey), []byte(adminKey)) != 1 {
+		logrus.WithField("path", c.Path()).Warn("Unauthorized admin access attempt")
+		return utils.ResponseError(c, fiber.StatusForbidden, "Invalid admin key", nil)
+	}
+
+	return c.Next()
+}
@@ -6,11 +6,11 @@ import (
 
 type Absensi struct {
 	ID           uint      `gorm:"primaryKey" json:"id"`
-	MahasantriID uint      `gorm:"not null" json:"mahasantri_id"`
+	MahasantriID uint      `gorm:"not null;uniqueIndex:idx_absensi_mahasantri_tanggal_waktu" json:"mahasantri_id"`
 	MentorID     uint      `gorm:"not null" json:"mentor_id"`
-	Waktu        string    `gorm:"type:varchar(10);not null" json:"waktu"`
+	Waktu        string    `gorm:"type:varchar(10);not null;uniqueIndex:idx_absensi_mahasantri_tanggal_waktu" json:"waktu"`
 	Status       string    `gorm:"type:varchar(10);not null" json:"status"`
-	Tanggal      time.Time `gorm:"type:date;not null" json:"tanggal"`
+	Tanggal      time.Time `gorm:"type:date;not null;uniqueIndex:idx_absensi_mahasantri_tanggal_waktu" json:"tanggal"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 
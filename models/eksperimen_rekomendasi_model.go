@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// EksperimenRekomendasi membandingkan beberapa engine rekomendasi pada pengguna nyata.
+// Pengguna dibagi ke varian secara deterministik berdasarkan ID, sesuai bobot trafik tiap varian.
+type EksperimenRekomendasi struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	Nama           string     `gorm:"type:varchar(100);not null;uniqueIndex" json:"nama"`
+	Deskripsi      string     `gorm:"type:text" json:"deskripsi"`
+	TanggalMulai   time.Time  `gorm:"type:date;not null" json:"tanggal_mulai"`
+	TanggalSelesai *time.Time `gorm:"type:date" json:"tanggal_selesai,omitempty"` // Inklusif, kosong berarti berjalan sampai dihentikan
+	DihentikanPada *time.Time `json:"dihentikan_pada,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	Varian []VarianEksperimen `gorm:"foreignKey:EksperimenID;constraint:OnDelete:CASCADE;" json:"varian"`
+}
+
+type VarianEksperimen struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	EksperimenID uint   `gorm:"not null;uniqueIndex:idx_varian_eksperimen_nama" json:"eksperimen_id"`
+	Nama         string `gorm:"type:varchar(50);not null;uniqueIndex:idx_varian_eksperimen_nama" json:"nama"`
+	Engine       string `gorm:"type:varchar(50);not null" json:"engine"`
+	Bobot        int    `gorm:"not null" json:"bobot"` // Persentase trafik, total seluruh varian 100
+}
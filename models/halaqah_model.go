@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+const (
+	PeranHalaqahUtama      = "utama"
+	PeranHalaqahPendamping = "pendamping"
+	PeranHalaqahPengganti  = "pengganti"
+)
+
+// Halaqah adalah kelompok setoran yang dapat diampu oleh lebih dari satu mentor
+type Halaqah struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Nama      string    `gorm:"type:varchar(100);not null" json:"nama"`
+	Jadwal    string    `gorm:"type:varchar(100)" json:"jadwal"`
+	Gender    string    `gorm:"type:varchar(10);not null" json:"gender"`
+	Ruangan   string    `gorm:"type:varchar(100)" json:"ruangan"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Mentors []HalaqahMentor  `gorm:"foreignKey:HalaqahID;constraint:OnDelete:CASCADE;" json:"mentors,omitempty"`
+	Anggota []HalaqahAnggota `gorm:"foreignKey:HalaqahID;constraint:OnDelete:CASCADE;" json:"anggota,omitempty"`
+}
+
+// HalaqahMentor adalah mentor pengajar sebuah halaqah (utama, pendamping, atau pengganti)
+type HalaqahMentor struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	HalaqahID uint      `gorm:"not null;uniqueIndex:idx_halaqah_mentor" json:"halaqah_id"`
+	MentorID  uint      `gorm:"not null;uniqueIndex:idx_halaqah_mentor;index" json:"mentor_id"`
+	Peran     string    `gorm:"type:varchar(20);not null;default:'utama'" json:"peran"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Mentor Mentor `gorm:"foreignKey:MentorID;constraint:OnDelete:CASCADE;" json:"-"`
+}
+
+// HalaqahAnggota mencatat riwayat keanggotaan mahasantri, anggota aktif memiliki TanggalKeluar kosong
+type HalaqahAnggota struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	HalaqahID     uint       `gorm:"not null;index" json:"halaqah_id"`
+	MahasantriID  uint       `gorm:"not null;index" json:"mahasantri_id"`
+	TanggalMasuk  time.Time  `gorm:"type:date;not null" json:"tanggal_masuk"`
+	TanggalKeluar *time.Time `gorm:"type:date" json:"tanggal_keluar,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+
+	Mahasantri Mahasantri `gorm:"foreignKey:MahasantriID;constraint:OnDelete:CASCADE;" json:"-"`
+}
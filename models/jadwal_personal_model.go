@@ -12,6 +12,10 @@ type JadwalPersonal struct {
 	Kesibukan         string      `gorm:"type:varchar(255);not null" json:"kesibukan"`
 	Jadwal            string      `gorm:"type:varchar(255)" json:"jadwal"`
 	EfektifitasJadwal int         `gorm:"not null" json:"efektifitas_jadwal"`
+	RewardState       string      `gorm:"type:varchar(255)" json:"-"`  // State yang terakhir menerima reward dari rating
+	RewardJadwal      string      `gorm:"type:varchar(255)" json:"-"`  // Jadwal yang terakhir menerima reward dari rating
+	RewardEfektifitas int         `gorm:"not null;default:0" json:"-"` // Rating yang sudah diterapkan sebagai reward, 0 jika belum
+	RewardModelVersi  int         `gorm:"not null;default:0" json:"-"` // Versi model yang menerima reward tersebut
 	CreatedAt         time.Time   `json:"created_at"`
 	UpdatedAt         time.Time   `json:"updated_at"`
 	Mahasantri        *Mahasantri `gorm:"foreignKey:MahasantriID;constraint:OnDelete:CASCADE;" json:"-"`
@@ -15,6 +15,9 @@ type LogHarian struct {
 	Tanggal             time.Time `gorm:"type:date;not null;uniqueIndex:idx_mahasantri_tanggal" json:"tanggal"`
 	TotalTargetHalaman  int       `gorm:"default:0" json:"total_target_halaman"`
 	TotalSelesaiHalaman int       `gorm:"default:0" json:"total_selesai_halaman"`
+	RewardDiterapkan    bool      `gorm:"default:false" json:"-"` // Sudah dipakai sebagai reward Q-learning
+	StateJadwal         string    `json:"-"`                      // State jadwal personal yang berlaku saat sesi pertama hari itu dicatat
+	Jadwal              string    `json:"-"`                      // Jadwal personal yang berlaku saat sesi pertama hari itu dicatat
 
 	Mahasantri Mahasantri  `gorm:"foreignKey:MahasantriID;constraint:OnDelete:CASCADE;" json:"-"`
 	DetailLogs []DetailLog `gorm:"foreignKey:LogHarianID;constraint:OnDelete:CASCADE;" json:"detail_logs"`
@@ -34,6 +37,9 @@ type DetailLog struct {
 	TotalSelesaiHalaman int             `gorm:"default:0"`
 	Status              StatusDetailLog `gorm:"type:varchar(50);default:'Belum Selesai'"`
 	Catatan             string          `gorm:"type:text"`
+	JadwalRekomendasiID *uint           `gorm:"index"` // Terisi jika sesi dibuat dari rekomendasi jadwal
 	CreatedAt           time.Time
 	UpdatedAt           time.Time
+
+	JadwalRekomendasi *JadwalRekomendasi `gorm:"foreignKey:JadwalRekomendasiID;constraint:OnDelete:SET NULL;"`
 }
@@ -1,6 +1,18 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Status keanggotaan mahasantri di program
+const (
+	StatusMahasantriAktif  = "aktif"
+	StatusMahasantriCuti   = "cuti"
+	StatusMahasantriLulus  = "lulus"
+	StatusMahasantriKeluar = "keluar"
+)
 
 type Mahasantri struct {
 	ID                   uint                `gorm:"primaryKey" json:"id"`
@@ -9,6 +21,17 @@ type Mahasantri struct {
 	Jurusan              string              `gorm:"type:varchar(100);not null" json:"jurusan"`
 	Password             string              `gorm:"not null" json:"-"`
 	Gender               string              `gorm:"type:varchar(10);not null" json:"gender"`
+	Angkatan             int                 `gorm:"index" json:"angkatan,omitempty"`
+	Asrama               string              `gorm:"type:varchar(100)" json:"asrama,omitempty"`
+	Kamar                string              `gorm:"type:varchar(20)" json:"kamar,omitempty"`
+	NoHP                 string              `gorm:"type:varchar(20)" json:"no_hp,omitempty"`
+	NamaWali             string              `gorm:"type:varchar(255)" json:"nama_wali,omitempty"`
+	NoHPWali             string              `gorm:"type:varchar(20)" json:"no_hp_wali,omitempty"`
+	TanggalLahir         *time.Time          `gorm:"type:date" json:"tanggal_lahir,omitempty"`
+	Foto                 string              `gorm:"type:varchar(255)" json:"-"`
+	FotoThumbnail        string              `gorm:"type:varchar(255)" json:"-"`
+	Status               string              `gorm:"type:varchar(20);not null;default:aktif;index" json:"status"`
+	StatusSejak          *time.Time          `gorm:"type:date" json:"status_sejak,omitempty"`
 	IsDataMurojaahFilled bool                `gorm:"default:false" json:"is_data_murojaah_filled"`
 	MentorID             uint                `gorm:"not null" json:"mentor_id"`
 	Mentor               *Mentor             `gorm:"foreignKey:MentorID;constraint:OnDelete:CASCADE;" json:"mentor,omitempty"`
@@ -20,4 +43,5 @@ type Mahasantri struct {
 	TargetSemester       []TargetSemester    `gorm:"foreignKey:MahasantriID;constraint:OnDelete:CASCADE;" json:"target_semester,omitempty"`
 	CreatedAt            time.Time           `json:"created_at"`
 	UpdatedAt            time.Time           `json:"updated_at"`
+	DeletedAt            gorm.DeletedAt      `gorm:"index" json:"-"`
 }
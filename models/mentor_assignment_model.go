@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// MentorAssignment mencatat riwayat mentor pembimbing mahasantri.
+// Periode berlaku mulai MulaiTanggal hingga sebelum SelesaiTanggal, SelesaiTanggal kosong berarti masih berlaku.
+type MentorAssignment struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	MahasantriID    uint       `gorm:"not null;index" json:"mahasantri_id"`
+	MentorID        uint       `gorm:"not null;index" json:"mentor_id"`
+	MulaiTanggal    time.Time  `gorm:"type:date;not null" json:"mulai_tanggal"`
+	SelesaiTanggal  *time.Time `gorm:"type:date" json:"selesai_tanggal,omitempty"`
+	Alasan          string     `gorm:"type:varchar(255)" json:"alasan,omitempty"`
+	DipindahkanOleh *uint      `json:"dipindahkan_oleh,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	Mahasantri Mahasantri `gorm:"foreignKey:MahasantriID;constraint:OnDelete:CASCADE;" json:"-"`
+	Mentor     Mentor     `gorm:"foreignKey:MentorID;constraint:OnDelete:CASCADE;" json:"-"`
+}
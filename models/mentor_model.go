@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Mentor struct {
 	ID                   uint                `gorm:"primaryKey" json:"id"`
@@ -16,4 +20,5 @@ type Mentor struct {
 	Hafalan              []Hafalan           `gorm:"foreignKey:MentorID;constraint:OnDelete:CASCADE;" json:"hafalan,omitempty"`
 	CreatedAt            time.Time           `json:"created_at"`
 	UpdatedAt            time.Time           `json:"updated_at"`
+	DeletedAt            gorm.DeletedAt      `gorm:"index" json:"-"`
 }
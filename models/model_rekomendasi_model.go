@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// ModelRekomendasi menyimpan satu versi model Q-learning (Q-Table dan data historis) beserta metadatanya.
+// Hanya satu versi yang aktif pada satu waktu.
+type ModelRekomendasi struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	Versi          int        `gorm:"not null;uniqueIndex" json:"versi"`
+	QTable         string     `gorm:"type:jsonb;not null" json:"-"`
+	HistoricalBest *string    `gorm:"type:jsonb" json:"-"`
+	Checksum       string     `gorm:"type:varchar(64);not null" json:"checksum"`
+	JumlahState    int        `gorm:"not null" json:"jumlah_state"`
+	JumlahAksi     int        `gorm:"not null" json:"jumlah_aksi"`
+	Keterangan     string     `gorm:"type:varchar(255)" json:"keterangan,omitempty"`
+	IsActive       bool       `gorm:"not null;default:false;index" json:"is_active"`
+	AktifSejak     *time.Time `json:"aktif_sejak,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// QValue menyimpan nilai Q hasil pembelajaran online untuk pasangan state dan aksi (jadwal) pada satu versi model.
+// Nilai di tabel ini menimpa nilai awal Q-Table versi tersebut saat model dimuat.
+type QValue struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	ModelVersi   int       `gorm:"not null;default:1;uniqueIndex:idx_q_value_versi_state_action" json:"model_versi"`
+	State        string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_q_value_versi_state_action" json:"state"`
+	Action       string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_q_value_versi_state_action" json:"action"`
+	Value        float64   `gorm:"not null" json:"value"`
+	JumlahUpdate int       `gorm:"not null;default:0" json:"jumlah_update"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+type StatusQadha string
+
+const (
+	StatusQadhaPending   StatusQadha = "pending"
+	StatusQadhaTerjadwal StatusQadha = "terjadwal"
+	StatusQadhaSelesai   StatusQadha = "selesai"
+)
+
+// Qadha adalah kewajiban setoran pengganti untuk sesi yang terlewat karena alpa atau izin
+type Qadha struct {
+	ID             uint        `gorm:"primaryKey" json:"id"`
+	AbsensiID      uint        `gorm:"not null;uniqueIndex" json:"absensi_id"`
+	MahasantriID   uint        `gorm:"not null;index" json:"mahasantri_id"`
+	MentorID       uint        `gorm:"not null;index" json:"mentor_id"`
+	Alasan         string      `gorm:"type:varchar(10);not null" json:"alasan"` // alpa / izin
+	TanggalAbsensi time.Time   `gorm:"type:date;not null" json:"tanggal_absensi"`
+	WaktuAbsensi   string      `gorm:"type:varchar(10);not null" json:"waktu_absensi"`
+	Status         StatusQadha `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	JadwalTanggal  *time.Time  `gorm:"type:date" json:"jadwal_tanggal,omitempty"`
+	JadwalWaktu    string      `gorm:"type:varchar(10)" json:"jadwal_waktu,omitempty"`
+	HafalanID      *uint       `gorm:"uniqueIndex:idx_qadha_hafalan,where:hafalan_id IS NOT NULL" json:"hafalan_id,omitempty"` // Satu setoran hafalan hanya menutup satu qadha
+	SelesaiPada    *time.Time  `json:"selesai_pada,omitempty"`
+	Catatan        string      `gorm:"type:varchar(255)" json:"catatan,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+
+	Absensi    Absensi    `gorm:"foreignKey:AbsensiID;constraint:OnDelete:CASCADE;" json:"-"`
+	Mahasantri Mahasantri `gorm:"foreignKey:MahasantriID;constraint:OnDelete:CASCADE;" json:"-"`
+	Mentor     Mentor     `gorm:"foreignKey:MentorID;constraint:OnDelete:CASCADE;" json:"-"`
+	Hafalan    *Hafalan   `gorm:"foreignKey:HafalanID;constraint:OnDelete:SET NULL;" json:"-"`
+}
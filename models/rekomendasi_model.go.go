@@ -11,9 +11,16 @@ type JadwalRekomendasi struct {
 	TipeRekomendasi           string    `gorm:"not null" json:"tipe_rekomendasi"`
 	EstimasiQValue            *float64  `gorm:"null" json:"estimasi_q_value"`
 	PersentaseEfektifHistoris *float64  `gorm:"null" json:"persentase_efektif_historis"`
+	Engine                    string    `gorm:"type:varchar(50);not null;default:'qlearning';index" json:"engine"` // Engine yang menghasilkan rekomendasi
+	EngineVersi               string    `gorm:"type:varchar(50)" json:"engine_versi"`                              // Versi model/aturan engine
+	EksperimenID              *uint     `gorm:"index" json:"eksperimen_id,omitempty"`                              // Terisi jika rekomendasi merupakan paparan eksperimen
+	VarianID                  *uint     `gorm:"index" json:"varian_id,omitempty"`
 	CreatedAt                 time.Time `json:"created_at"`
 	UpdatedAt                 time.Time `json:"updated_at"`
 
 	Mahasantri Mahasantri `gorm:"foreignKey:MahasantriID;constraint:OnDelete:SET NULL;" json:"-"`
 	Mentor     Mentor     `gorm:"foreignKey:MentorID;constraint:OnDelete:SET NULL;" json:"-"`
+
+	Eksperimen *EksperimenRekomendasi `gorm:"foreignKey:EksperimenID;constraint:OnDelete:SET NULL;" json:"-"`
+	Varian     *VarianEksperimen      `gorm:"foreignKey:VarianID;constraint:OnDelete:SET NULL;" json:"-"`
 }
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// RiwayatStatusMahasantri mencatat setiap perubahan status mahasantri beserta tanggal efektifnya
+type RiwayatStatusMahasantri struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	MahasantriID   uint      `gorm:"not null;index" json:"mahasantri_id"`
+	StatusLama     string    `gorm:"type:varchar(20);not null" json:"status_lama"`
+	StatusBaru     string    `gorm:"type:varchar(20);not null" json:"status_baru"`
+	TanggalEfektif time.Time `gorm:"type:date;not null" json:"tanggal_efektif"`
+	Keterangan     string    `gorm:"type:text" json:"keterangan,omitempty"`
+	DiubahOleh     *uint     `json:"diubah_oleh,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+
+	Mahasantri Mahasantri `gorm:"foreignKey:MahasantriID;constraint:OnDelete:CASCADE;" json:"-"`
+}
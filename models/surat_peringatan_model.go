@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+type StatusSuratPeringatan string
+
+const (
+	StatusSPAktif   StatusSuratPeringatan = "aktif"
+	StatusSPDiakui  StatusSuratPeringatan = "diakui"
+	StatusSPDicabut StatusSuratPeringatan = "dicabut"
+)
+
+// SuratPeringatan hanya boleh ada satu yang belum dicabut untuk setiap level dalam satu semester.
+// SP yang dicabut tidak ikut index agar level yang sama dapat diterbitkan ulang.
+type SuratPeringatan struct {
+	ID               uint                  `gorm:"primaryKey" json:"id"`
+	MahasantriID     uint                  `gorm:"not null;index;uniqueIndex:idx_sp_mahasantri_semester_level,where:status <> 'dicabut'" json:"mahasantri_id"`
+	MentorID         uint                  `gorm:"not null;index" json:"mentor_id"`
+	Level            int                   `gorm:"not null;uniqueIndex:idx_sp_mahasantri_semester_level" json:"level"` // 1 = SP1, 2 = SP2, 3 = SP3
+	Semester         string                `gorm:"type:varchar(10);not null;uniqueIndex:idx_sp_mahasantri_semester_level" json:"semester"`
+	TahunAjaran      string                `gorm:"type:varchar(10);not null;uniqueIndex:idx_sp_mahasantri_semester_level" json:"tahun_ajaran"`
+	JumlahAlpa       int                   `gorm:"not null" json:"jumlah_alpa"`
+	Status           StatusSuratPeringatan `gorm:"type:varchar(20);not null;default:'aktif'" json:"status"`
+	DiakuiPada       *time.Time            `json:"diakui_pada,omitempty"`
+	DicabutPada      *time.Time            `json:"dicabut_pada,omitempty"`
+	AlasanPencabutan string                `gorm:"type:varchar(255)" json:"alasan_pencabutan,omitempty"`
+	CreatedAt        time.Time             `json:"created_at"`
+	UpdatedAt        time.Time             `json:"updated_at"`
+
+	Mahasantri Mahasantri `gorm:"foreignKey:MahasantriID;constraint:OnDelete:CASCADE;" json:"-"`
+	Mentor     Mentor     `gorm:"foreignKey:MentorID;constraint:OnDelete:CASCADE;" json:"-"`
+}
+
+func (sp *SuratPeringatan) GetKodeLevel() string {
+	switch sp.Level {
+	case 1:
+		return "SP1"
+	case 2:
+		return "SP2"
+	case 3:
+		return "SP3"
+	default:
+		return ""
+	}
+}
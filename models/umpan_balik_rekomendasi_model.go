@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// UmpanBalikRekomendasi menyimpan penilaian pengguna terhadap satu rekomendasi jadwal yang pernah diberikan.
+// Setiap rekomendasi hanya memiliki satu umpan balik; pengiriman ulang memperbarui data yang sama.
+type UmpanBalikRekomendasi struct {
+	ID                  uint      `gorm:"primaryKey" json:"id"`
+	JadwalRekomendasiID uint      `gorm:"not null;uniqueIndex" json:"jadwal_rekomendasi_id"`
+	Diterapkan          bool      `gorm:"not null;default:false" json:"diterapkan"`
+	Efektivitas         *int      `gorm:"null" json:"efektivitas,omitempty"` // Skor 1-5, hanya diisi jika jadwal diterapkan
+	Komentar            string    `gorm:"type:text" json:"komentar"`
+	RewardEfektivitas   int       `gorm:"not null;default:0" json:"-"` // Skor yang sudah diterapkan sebagai reward, 0 jika belum
+	RewardModelVersi    int       `gorm:"not null;default:0" json:"-"` // Versi model yang menerima reward tersebut
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+
+	JadwalRekomendasi JadwalRekomendasi `gorm:"foreignKey:JadwalRekomendasiID;constraint:OnDelete:CASCADE;" json:"-"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Wali adalah orang tua/wali mahasantri yang hanya dapat melihat perkembangan anak yang terhubung dengannya
+type Wali struct {
+	ID         uint             `gorm:"primaryKey" json:"id"`
+	Nama       string           `gorm:"type:varchar(255);not null" json:"nama"`
+	NoHP       string           `gorm:"type:varchar(20);not null;unique" json:"no_hp"`
+	Password   string           `gorm:"not null" json:"-"`
+	Mahasantri []WaliMahasantri `gorm:"foreignKey:WaliID;constraint:OnDelete:CASCADE;" json:"mahasantri,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt   `gorm:"index" json:"-"`
+}
+
+// WaliMahasantri menghubungkan wali dengan mahasantri. Satu wali bisa memiliki beberapa anak dan sebaliknya.
+type WaliMahasantri struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	WaliID       uint      `gorm:"not null;uniqueIndex:idx_wali_mahasantri" json:"wali_id"`
+	MahasantriID uint      `gorm:"not null;uniqueIndex:idx_wali_mahasantri;index" json:"mahasantri_id"`
+	Hubungan     string    `gorm:"type:varchar(20)" json:"hubungan,omitempty"` // ayah, ibu, atau wali
+	CreatedAt    time.Time `json:"created_at"`
+
+	Mahasantri Mahasantri `gorm:"foreignKey:MahasantriID;constraint:OnDelete:CASCADE;" json:"-"`
+}
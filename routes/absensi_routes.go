@@ -7,11 +7,12 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"github.com/habbazettt/mahad-service-go/middleware"
 	"github.com/habbazettt/mahad-service-go/services"
+	"github.com/habbazettt/mahad-service-go/utils"
 	"gorm.io/gorm"
 )
 
 func SetupAbsensiRoutes(app *fiber.App, db *gorm.DB) {
-	absensiService := services.AbsensiService{DB: db}
+	absensiService := services.AbsensiService{DB: db, Notifier: utils.NewNotifier()}
 
 	absensiLimiter := limiter.New(limiter.Config{
 		Max:        5,
@@ -40,6 +41,7 @@ func SetupAbsensiRoutes(app *fiber.App, db *gorm.DB) {
 	{
 		absensiRoutes.Post("/", middleware.RoleMiddleware("mentor"), absensiService.CreateAbsensi)
 		absensiRoutes.Get("/", middleware.RoleMiddleware("mentor"), absensiService.GetAbsensi)
+		absensiRoutes.Get("/export", middleware.RoleMiddleware("mentor"), absensiService.ExportAbsensi)
 		absensiRoutes.Get("/:id", middleware.RoleMiddleware("mentor"), absensiService.GetAbsensiByID)
 		absensiRoutes.Put("/:id", middleware.RoleMiddleware("mentor"), absensiService.UpdateAbsensi)
 		absensiRoutes.Get("/mahasantri/:mahasantri_id/daily-summary", middleware.RoleMiddleware("mentor", "mahasantri"), absensiService.GetAbsensiDailySummary)
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/mahad-service-go/middleware"
+	"github.com/habbazettt/mahad-service-go/services"
+	"gorm.io/gorm"
+)
+
+func SetupEksperimenRekomendasiRoutes(app *fiber.App, db *gorm.DB) {
+	service := services.NewEksperimenRekomendasiService(db)
+
+	eksperimenRoutes := app.Group("/api/v1/eksperimen-rekomendasi", middleware.AdminKeyMiddleware)
+	{
+		eksperimenRoutes.Get("/", service.GetAllEksperimen)
+		eksperimenRoutes.Post("/", service.CreateEksperimen)
+		eksperimenRoutes.Put("/:id/hentikan", service.HentikanEksperimen)
+		eksperimenRoutes.Get("/:id/hasil", service.GetHasilEksperimen)
+	}
+}
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/mahad-service-go/middleware"
+	"github.com/habbazettt/mahad-service-go/services"
+	"gorm.io/gorm"
+)
+
+func SetupHalaqahRoutes(app *fiber.App, db *gorm.DB) {
+	service := services.NewHalaqahService(db)
+
+	halaqahRoutes := app.Group("/api/v1/halaqah", middleware.JWTMiddleware)
+	{
+		halaqahRoutes.Get("/", middleware.RoleMiddleware("mentor", "mahasantri"), service.GetAllHalaqah)
+		halaqahRoutes.Post("/", middleware.RoleMiddleware("mentor"), service.CreateHalaqah)
+		halaqahRoutes.Get("/:id", middleware.RoleMiddleware("mentor"), service.GetHalaqahByID)
+		halaqahRoutes.Put("/:id", middleware.RoleMiddleware("mentor"), service.UpdateHalaqah)
+		halaqahRoutes.Delete("/:id", middleware.RoleMiddleware("mentor"), service.DeleteHalaqah)
+		halaqahRoutes.Post("/:id/mentors", middleware.RoleMiddleware("mentor"), service.AddMentorHalaqah)
+		halaqahRoutes.Delete("/:id/mentors/:mentor_id", middleware.RoleMiddleware("mentor"), service.RemoveMentorHalaqah)
+		halaqahRoutes.Post("/:id/anggota", middleware.RoleMiddleware("mentor"), service.AddAnggotaHalaqah)
+		halaqahRoutes.Delete("/:id/anggota/:mahasantri_id", middleware.RoleMiddleware("mentor"), service.RemoveAnggotaHalaqah)
+		halaqahRoutes.Get("/:id/riwayat", middleware.RoleMiddleware("mentor"), service.GetRiwayatAnggotaHalaqah)
+	}
+}
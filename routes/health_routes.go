@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/mahad-service-go/services"
+)
+
+// SetupHealthRoutes harus didaftarkan sebelum middleware logger, metrics, dan limiter
+// agar probe dari container tidak memenuhi log dan tidak terkena rate limit
+func SetupHealthRoutes(app *fiber.App) {
+	service := services.HealthService{}
+
+	app.Get("/healthz", service.Liveness)
+	app.Get("/readyz", service.Readiness)
+}
@@ -7,11 +7,12 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"github.com/habbazettt/mahad-service-go/middleware"
 	"github.com/habbazettt/mahad-service-go/services"
+	"github.com/habbazettt/mahad-service-go/utils"
 	"gorm.io/gorm"
 )
 
 func SetupMahasantriRoutes(app *fiber.App, db *gorm.DB) {
-	service := services.MahasantriService{DB: db}
+	service := services.MahasantriService{DB: db, Notifier: utils.NewNotifier(), Storage: utils.NewStorage()}
 
 	mahasantriLimiter := limiter.New(limiter.Config{
 		Max:        5,
@@ -35,10 +36,26 @@ func SetupMahasantriRoutes(app *fiber.App, db *gorm.DB) {
 
 	mahasantriRoutes := app.Group("/api/v1/mahasantri", methodLimiter)
 	{
-		mahasantriRoutes.Get("/", service.GetAllMahasantri)
+		// Sebelumnya publik tanpa JWT, kini hanya untuk mentor sesuai dokumentasi endpoint (perubahan yang memutus klien lama)
+		mahasantriRoutes.Get("/", middleware.JWTMiddleware, middleware.RoleMiddleware("mentor"), service.GetAllMahasantri)
+		mahasantriRoutes.Get("/search", middleware.JWTMiddleware, middleware.RoleMiddleware("mentor"), service.SearchMahasantri)
+		mahasantriRoutes.Post("/import", middleware.JWTMiddleware, middleware.RoleMiddleware("mentor"), service.ImportMahasantri)
+		mahasantriRoutes.Get("/penempatan/rekomendasi", middleware.JWTMiddleware, middleware.RoleMiddleware("mentor"), service.GetRekomendasiMentor)
+		mahasantriRoutes.Post("/penempatan", middleware.JWTMiddleware, middleware.RoleMiddleware("mentor"), service.PenempatanMentor)
+		mahasantriRoutes.Post("/kelulusan", middleware.JWTMiddleware, middleware.RoleMiddleware("mentor"), service.LuluskanAngkatan)
+		mahasantriRoutes.Get("/arsip", middleware.JWTMiddleware, middleware.RoleMiddleware("mentor"), service.GetArsipMahasantri)
 		mahasantriRoutes.Get("/:id", middleware.JWTMiddleware, middleware.RoleMiddleware("mentor", "mahasantri"), service.GetMahasantriByID)
 		mahasantriRoutes.Get("/mentor/:mentor_id", middleware.JWTMiddleware, middleware.RoleMiddleware("mentor"), service.GetMahasantriByMentorID)
 		mahasantriRoutes.Put("/:id", middleware.JWTMiddleware, middleware.RoleMiddleware("mentor", "mahasantri"), service.UpdateMahasantri)
+		mahasantriRoutes.Post("/:id/transfer", middleware.JWTMiddleware, middleware.RoleMiddleware("mentor"), service.TransferMentor)
+		mahasantriRoutes.Get("/:id/riwayat-mentor", middleware.JWTMiddleware, middleware.RoleMiddleware("mentor", "mahasantri"), service.GetRiwayatMentor)
+		mahasantriRoutes.Put("/:id/status", middleware.JWTMiddleware, middleware.RoleMiddleware("mentor"), service.UpdateStatusMahasantri)
+		mahasantriRoutes.Get("/:id/riwayat-status", middleware.JWTMiddleware, middleware.RoleMiddleware("mentor", "mahasantri"), service.GetRiwayatStatusMahasantri)
+		mahasantriRoutes.Get("/:id/foto", middleware.JWTMiddleware, middleware.RoleMiddleware("mentor", "mahasantri"), service.GetFotoMahasantri)
+		mahasantriRoutes.Put("/:id/foto", middleware.JWTMiddleware, middleware.RoleMiddleware("mentor", "mahasantri"), service.UploadFotoMahasantri)
+		mahasantriRoutes.Delete("/:id/foto", middleware.JWTMiddleware, middleware.RoleMiddleware("mentor", "mahasantri"), service.DeleteFotoMahasantri)
 		mahasantriRoutes.Delete("/:id", middleware.JWTMiddleware, middleware.RoleMiddleware("mentor"), service.DeleteMahasantri)
+		mahasantriRoutes.Put("/:id/restore", middleware.JWTMiddleware, middleware.RoleMiddleware("mentor"), service.RestoreMahasantri)
+		mahasantriRoutes.Delete("/:id/purge", middleware.AdminKeyMiddleware, service.PurgeMahasantri)
 	}
 }
@@ -36,8 +36,11 @@ func SetupMentorRoutes(app *fiber.App, db *gorm.DB) {
 	mentorRoutes := app.Group("/api/v1/mentors", methodLimiter)
 	{
 		mentorRoutes.Get("/", service.GetAllMentors)
+		mentorRoutes.Get("/arsip", middleware.JWTMiddleware, middleware.RoleMiddleware("mentor"), service.GetArsipMentors)
 		mentorRoutes.Get("/:id", service.GetMentorByID)
 		mentorRoutes.Put("/:id", middleware.JWTMiddleware, middleware.RoleMiddleware("mentor"), service.UpdateMentor)
 		mentorRoutes.Delete("/:id", middleware.JWTMiddleware, middleware.RoleMiddleware("mentor"), service.DeleteMentor)
+		mentorRoutes.Put("/:id/restore", middleware.JWTMiddleware, middleware.RoleMiddleware("mentor"), service.RestoreMentor)
+		mentorRoutes.Delete("/:id/purge", middleware.AdminKeyMiddleware, service.PurgeMentor)
 	}
 }
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/habbazettt/mahad-service-go/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// SetupMetricsRoutes mendaftarkan endpoint /metrics untuk Prometheus beserta statistik pool koneksi database.
+// Endpoint dilindungi header X-Admin-Key karena memuat pola route dan kondisi internal service.
+func SetupMetricsRoutes(app *fiber.App, db *gorm.DB) {
+	if sqlDB, err := db.DB(); err != nil {
+		logrus.WithError(err).Warn("Statistik pool database tidak tersedia untuk metrics")
+	} else {
+		prometheus.MustRegister(collectors.NewDBStatsCollector(sqlDB, "mahad"))
+	}
+
+	app.Get("/metrics", middleware.AdminKeyMiddleware, adaptor.HTTPHandler(promhttp.Handler()))
+}
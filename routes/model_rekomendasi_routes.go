@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/mahad-service-go/middleware"
+	"github.com/habbazettt/mahad-service-go/services"
+	"gorm.io/gorm"
+)
+
+func SetupModelRekomendasiRoutes(app *fiber.App, db *gorm.DB) {
+	service := services.NewModelRekomendasiService(db)
+
+	modelRoutes := app.Group("/api/v1/model-rekomendasi", middleware.AdminKeyMiddleware)
+	{
+		modelRoutes.Get("/", service.GetAllModelRekomendasi)
+		modelRoutes.Post("/", service.UploadModelRekomendasi)
+		modelRoutes.Post("/rollback", service.RollbackModelRekomendasi)
+		modelRoutes.Put("/:versi/aktifkan", service.AktifkanModelRekomendasi)
+	}
+}
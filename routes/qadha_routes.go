@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/mahad-service-go/middleware"
+	"github.com/habbazettt/mahad-service-go/services"
+	"github.com/habbazettt/mahad-service-go/utils"
+	"gorm.io/gorm"
+)
+
+func SetupQadhaRoutes(app *fiber.App, db *gorm.DB) {
+	service := services.NewQadhaService(db, utils.NewNotifier())
+
+	qadhaRoutes := app.Group("/api/v1/qadha", middleware.JWTMiddleware)
+	{
+		qadhaRoutes.Get("/", middleware.RoleMiddleware("mentor", "mahasantri"), service.GetAllQadha)
+		qadhaRoutes.Get("/:id", middleware.RoleMiddleware("mentor", "mahasantri"), service.GetQadhaByID)
+		qadhaRoutes.Put("/:id/jadwal", middleware.RoleMiddleware("mentor"), service.JadwalkanQadha)
+		qadhaRoutes.Put("/:id/selesai", middleware.RoleMiddleware("mentor"), service.SelesaikanQadha)
+	}
+}
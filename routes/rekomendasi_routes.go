@@ -39,5 +39,10 @@ func SetupRekomendasiRoutes(app *fiber.App, db *gorm.DB) {
 		rekomendasiRoutes.Post("/", middleware.RoleMiddleware("mentor", "mahasantri"), service.GetRecommendation)
 		rekomendasiRoutes.Get("/", middleware.RoleMiddleware("mentor", "mahasantri"), service.GetAllRekomendasi)
 		rekomendasiRoutes.Get("/kesibukan", middleware.RoleMiddleware("mentor", "mahasantri"), service.GetAllKesibukan)
+		rekomendasiRoutes.Get("/states", middleware.RoleMiddleware("mentor", "mahasantri"), service.GetStatesRekomendasi)
+		rekomendasiRoutes.Get("/statistik", middleware.RoleMiddleware("mentor"), service.GetStatistikRekomendasi)
+		rekomendasiRoutes.Get("/analitik", middleware.RoleMiddleware("mentor"), service.GetAnalitikRekomendasi)
+		rekomendasiRoutes.Get("/analitik/tren", middleware.RoleMiddleware("mentor"), service.GetTrenRekomendasi)
+		rekomendasiRoutes.Post("/:id/umpan-balik", middleware.RoleMiddleware("mentor", "mahasantri"), service.CreateUmpanBalikRekomendasi)
 	}
 }
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/mahad-service-go/middleware"
+	"github.com/habbazettt/mahad-service-go/services"
+	"github.com/habbazettt/mahad-service-go/utils"
+	"gorm.io/gorm"
+)
+
+func SetupSuratPeringatanRoutes(app *fiber.App, db *gorm.DB) {
+	service := services.NewSuratPeringatanService(db, utils.NewNotifier())
+
+	spRoutes := app.Group("/api/v1/surat-peringatan", middleware.JWTMiddleware)
+	{
+		spRoutes.Get("/", middleware.RoleMiddleware("mentor", "mahasantri"), service.GetAllSuratPeringatan)
+		spRoutes.Get("/:id", middleware.RoleMiddleware("mentor", "mahasantri"), service.GetSuratPeringatanByID)
+		spRoutes.Put("/:id/akui", middleware.RoleMiddleware("mentor"), service.AkuiSuratPeringatan)
+		spRoutes.Put("/:id/cabut", middleware.RoleMiddleware("mentor"), service.CabutSuratPeringatan)
+		spRoutes.Post("/evaluasi/:mahasantri_id", middleware.RoleMiddleware("mentor"), service.EvaluasiSuratPeringatan)
+	}
+}
@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/mahad-service-go/middleware"
+	"github.com/habbazettt/mahad-service-go/services"
+	"github.com/habbazettt/mahad-service-go/utils"
+	"gorm.io/gorm"
+)
+
+func SetupWaliRoutes(app *fiber.App, db *gorm.DB) {
+	notifier := utils.NewNotifier()
+	service := services.NewWaliService(db, notifier)
+	absensiService := services.AbsensiService{DB: db, Notifier: notifier}
+	hafalanService := services.HafalanService{DB: db}
+	logService := services.NewLogMurojaahService(db)
+	spService := services.NewSuratPeringatanService(db, notifier)
+
+	waliRoutes := app.Group("/api/v1/wali", middleware.JWTMiddleware)
+	{
+		// Portal wali (read-only), hanya untuk mahasantri yang terhubung dengan wali
+		anakRoutes := waliRoutes.Group("/anak", middleware.RoleMiddleware("wali"))
+		anakRoutes.Get("/", service.GetAnakWali)
+		anakRoutes.Get("/:mahasantri_id/absensi", service.RequireAnakWali, absensiService.GetAbsensiDailySummary)
+		anakRoutes.Get("/:mahasantri_id/hafalan", service.RequireAnakWali, hafalanService.GetHafalanByMahasantriID)
+		anakRoutes.Get("/:mahasantri_id/log-harian", service.RequireAnakWali, logService.GetOrCreateLogHarian)
+		anakRoutes.Get("/:mahasantri_id/log-harian/rekap/mingguan", service.RequireAnakWali, logService.GetRecapMingguan)
+		anakRoutes.Get("/:mahasantri_id/log-harian/statistik", service.RequireAnakWali, logService.GetStatistikMurojaah)
+		anakRoutes.Get("/:mahasantri_id/surat-peringatan", service.RequireAnakWali, spService.GetAllSuratPeringatan)
+		anakRoutes.Get("/:mahasantri_id/surat-peringatan/:id", service.RequireAnakWali, spService.GetSuratPeringatanByID)
+
+		// Pengelolaan akun wali oleh mentor
+		waliRoutes.Post("/", middleware.RoleMiddleware("mentor"), service.CreateWali)
+		waliRoutes.Get("/", middleware.RoleMiddleware("mentor"), service.GetAllWali)
+		waliRoutes.Post("/:id/mahasantri", middleware.RoleMiddleware("mentor"), service.HubungkanMahasantri)
+		waliRoutes.Delete("/:id/mahasantri/:mahasantri_id", middleware.RoleMiddleware("mentor"), service.PutuskanMahasantri)
+	}
+}
@@ -0,0 +1,349 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/mahad-service-go/dto"
+	"github.com/habbazettt/mahad-service-go/models"
+	"github.com/habbazettt/mahad-service-go/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/xuri/excelize/v2"
+)
+
+// urutan status yang ditampilkan pada kolom total
+var statusRekapAbsensi = []string{"hadir", "izin", "alpa", "belum-absen", "libur"}
+
+// rekapAbsensiMahasantri adalah data rekap bulanan satu mahasantri yang siap dicetak
+type rekapAbsensiMahasantri struct {
+	Mahasantri models.Mahasantri
+	Summary    []dto.AbsensiDailySummaryDTO
+	Total      map[string]int
+}
+
+// ExportAbsensi godoc
+// @Summary Export rekap absensi bulanan
+// @Description Menghasilkan rekap absensi bulanan (shubuh dan isya) dalam format XLSX atau PDF untuk seluruh mahasantri bimbingan mentor, atau satu mahasantri jika mahasantri_id diisi. Setiap mahasantri dilengkapi total per status.
+// @Description Mahasantri yang dipindahkan pada bulan tersebut hanya menampilkan absensi selama periode bimbingan mentor.
+// @Tags Absensi
+// @Security BearerAuth
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/pdf
+// @Param format query string true "Format file" Enums(xlsx, pdf)
+// @Param month query string true "Bulan (format: MM, contoh: 04 untuk April)"
+// @Param year query string true "Tahun (format: YYYY, contoh: 2025)"
+// @Param mahasantri_id query int false "ID Mahasantri (opsional)"
+// @Success 200 {file} file "File rekap absensi"
+// @Failure 400 {object} utils.Response "Invalid query parameters"
+// @Failure 404 {object} utils.Response "Mahasantri not found"
+// @Failure 500 {object} utils.Response "Failed to export absensi"
+// @Router /api/v1/absensi/export [get]
+func (s *AbsensiService) ExportAbsensi(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+
+	format := c.Query("format")
+	if format != "xlsx" && format != "pdf" {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Invalid format value. Allowed values are 'xlsx' or 'pdf'", nil)
+	}
+
+	month := c.Query("month")
+	year := c.Query("year")
+	if month == "" || year == "" {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Missing query parameters", "month and year are required")
+	}
+
+	startDate, endDate, err := parseBulanAbsensi(month, year)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Invalid date format", err.Error())
+	}
+
+	var mentor models.Mentor
+	if err := s.DB.First(&mentor, claims.ID).Error; err != nil {
+		return utils.ResponseError(c, fiber.StatusNotFound, "Mentor not found", err.Error())
+	}
+
+	// Hanya mahasantri yang dibimbing mentor pada bulan tersebut yang bisa diexport
+	assignments, err := mentorAssignmentsInRange(s.DB, mentor.ID, startDate, endDate)
+	if err != nil {
+		logrus.WithError(err).Error("Gagal mengambil riwayat mentor untuk export absensi")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to fetch mahasantri", err.Error())
+	}
+	assignmentPerMahasantri := make(map[uint][]models.MentorAssignment)
+	assignedIDs := []uint{0}
+	for _, a := range assignments {
+		assignmentPerMahasantri[a.MahasantriID] = append(assignmentPerMahasantri[a.MahasantriID], a)
+		assignedIDs = append(assignedIDs, a.MahasantriID)
+	}
+
+	query := s.DB.Where("id IN ?", assignedIDs).Order("nama ASC")
+	if mahasantriIDStr := c.Query("mahasantri_id"); mahasantriIDStr != "" {
+		mahasantriID, err := strconv.ParseUint(mahasantriIDStr, 10, 64)
+		if err != nil {
+			return utils.ResponseError(c, fiber.StatusBadRequest, "Invalid Mahasantri ID format", err.Error())
+		}
+		query = query.Where("id = ?", mahasantriID)
+	}
+
+	var mahasantris []models.Mahasantri
+	if err := query.Find(&mahasantris).Error; err != nil {
+		logrus.WithError(err).Error("Gagal mengambil data mahasantri untuk export absensi")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to fetch mahasantri", err.Error())
+	}
+	if len(mahasantris) == 0 {
+		return utils.ResponseError(c, fiber.StatusNotFound, "Mahasantri not found", nil)
+	}
+
+	mahasantriIDs := make([]uint, len(mahasantris))
+	for i, m := range mahasantris {
+		mahasantriIDs[i] = m.ID
+	}
+
+	var absensi []models.Absensi
+	if err := s.DB.Where("mahasantri_id IN ?", mahasantriIDs).
+		Where("tanggal BETWEEN ? AND ?", startDate, endDate).
+		Find(&absensi).Error; err != nil {
+		logrus.WithError(err).Error("Gagal mengambil data absensi untuk export")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to fetch absensi", err.Error())
+	}
+
+	absensiPerMahasantri := make(map[uint][]models.Absensi)
+	for _, a := range absensi {
+		// Absensi di luar periode bimbingan mentor ini milik mentor lain
+		if !isDalamPeriodeAssignment(assignmentPerMahasantri[a.MahasantriID], a.Tanggal) {
+			continue
+		}
+		absensiPerMahasantri[a.MahasantriID] = append(absensiPerMahasantri[a.MahasantriID], a)
+	}
+
+	rekap := make([]rekapAbsensiMahasantri, 0, len(mahasantris))
+	for _, m := range mahasantris {
+		summary := buildAbsensiDailySummary(absensiPerMahasantri[m.ID], startDate, endDate)
+		rekap = append(rekap, rekapAbsensiMahasantri{
+			Mahasantri: m,
+			Summary:    summary,
+			Total:      hitungTotalStatusAbsensi(summary),
+		})
+	}
+
+	periode := fmt.Sprintf("%s %d", getNamaBulan(startDate.Month()), startDate.Year())
+	filename := fmt.Sprintf("rekap-absensi-%s-%s", year, month)
+
+	var content []byte
+	var contentType string
+	switch format {
+	case "xlsx":
+		content, err = renderRekapAbsensiXLSX(mentor, periode, rekap)
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case "pdf":
+		content, err = renderRekapAbsensiPDF(mentor, periode, rekap)
+		contentType = "application/pdf"
+	}
+	if err != nil {
+		logrus.WithError(err).WithField("format", format).Error("Gagal membuat file rekap absensi")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to export absensi", err.Error())
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"mentor_id":         mentor.ID,
+		"format":            format,
+		"periode":           periode,
+		"jumlah_mahasantri": len(rekap),
+	}).Info("Rekap absensi berhasil diexport")
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.%s"`, filename, format))
+	return c.Status(fiber.StatusOK).Send(content)
+}
+
+// hitungTotalStatusAbsensi menghitung jumlah tiap status dari sesi shubuh dan isya
+func hitungTotalStatusAbsensi(summary []dto.AbsensiDailySummaryDTO) map[string]int {
+	total := make(map[string]int)
+	for _, s := range summary {
+		total[s.Shubuh]++
+		total[s.Isya]++
+	}
+	return total
+}
+
+func renderRekapAbsensiXLSX(mentor models.Mentor, periode string, rekap []rekapAbsensiMahasantri) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	boldStyle, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return nil, err
+	}
+
+	// Sheet pertama berisi total per status untuk semua mahasantri
+	const rekapSheet = "Rekap"
+	if err := f.SetSheetName("Sheet1", rekapSheet); err != nil {
+		return nil, err
+	}
+	f.SetCellValue(rekapSheet, "A1", "Rekap Absensi "+periode)
+	f.SetCellValue(rekapSheet, "A2", "Mentor: "+mentor.Nama)
+	f.SetCellStyle(rekapSheet, "A1", "A1", boldStyle)
+
+	header := []interface{}{"No", "Nama", "NIM", "Jurusan"}
+	for _, status := range statusRekapAbsensi {
+		header = append(header, status)
+	}
+	if err := f.SetSheetRow(rekapSheet, "A4", &header); err != nil {
+		return nil, err
+	}
+	lastCol, _ := excelize.ColumnNumberToName(len(header))
+	f.SetCellStyle(rekapSheet, "A4", lastCol+"4", boldStyle)
+
+	for i, r := range rekap {
+		row := []interface{}{i + 1, r.Mahasantri.Nama, r.Mahasantri.NIM, r.Mahasantri.Jurusan}
+		for _, status := range statusRekapAbsensi {
+			row = append(row, r.Total[status])
+		}
+		if err := f.SetSheetRow(rekapSheet, fmt.Sprintf("A%d", i+5), &row); err != nil {
+			return nil, err
+		}
+	}
+	f.SetColWidth(rekapSheet, "B", "B", 30)
+	f.SetColWidth(rekapSheet, "C", "D", 18)
+
+	// Satu sheet detail harian untuk setiap mahasantri
+	for _, r := range rekap {
+		sheet := namaSheetMahasantri(r.Mahasantri)
+		if _, err := f.NewSheet(sheet); err != nil {
+			return nil, err
+		}
+
+		f.SetCellValue(sheet, "A1", fmt.Sprintf("%s (%s)", r.Mahasantri.Nama, r.Mahasantri.NIM))
+		f.SetCellValue(sheet, "A2", "Periode: "+periode)
+		f.SetCellStyle(sheet, "A1", "A1", boldStyle)
+
+		if err := f.SetSheetRow(sheet, "A4", &[]interface{}{"Tanggal", "Hari", "Shubuh", "Isya"}); err != nil {
+			return nil, err
+		}
+		f.SetCellStyle(sheet, "A4", "D4", boldStyle)
+
+		rowIdx := 5
+		for _, d := range r.Summary {
+			if err := f.SetSheetRow(sheet, fmt.Sprintf("A%d", rowIdx), &[]interface{}{d.Tanggal, d.Hari, d.Shubuh, d.Isya}); err != nil {
+				return nil, err
+			}
+			rowIdx++
+		}
+
+		rowIdx++
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", rowIdx), "Total")
+		f.SetCellStyle(sheet, fmt.Sprintf("A%d", rowIdx), fmt.Sprintf("A%d", rowIdx), boldStyle)
+		for _, status := range statusRekapAbsensi {
+			rowIdx++
+			if err := f.SetSheetRow(sheet, fmt.Sprintf("A%d", rowIdx), &[]interface{}{status, r.Total[status]}); err != nil {
+				return nil, err
+			}
+		}
+		f.SetColWidth(sheet, "A", "D", 14)
+	}
+
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func renderRekapAbsensiPDF(mentor models.Mentor, periode string, rekap []rekapAbsensiMahasantri) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	// Font inti PDF hanya mengenal cp1252, nama dengan huruf beraksen harus diterjemahkan dari UTF-8
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	// Halaman pertama berisi total per status untuk semua mahasantri
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(0, 8, "Rekap Absensi "+periode, "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 6, "Mentor: "+tr(mentor.Nama), "", 1, "C", false, 0, "")
+	pdf.Ln(4)
+
+	widths := []float64{10, 55, 30, 17, 17, 17, 17, 17}
+	pdf.SetFont("Helvetica", "B", 9)
+	for i, h := range append([]string{"No", "Nama", "NIM"}, statusRekapAbsensi...) {
+		pdf.CellFormat(widths[i], 7, h, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 9)
+	for i, r := range rekap {
+		pdf.CellFormat(widths[0], 6, strconv.Itoa(i+1), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(widths[1], 6, tr(r.Mahasantri.Nama), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[2], 6, tr(r.Mahasantri.NIM), "1", 0, "C", false, 0, "")
+		for j, status := range statusRekapAbsensi {
+			pdf.CellFormat(widths[3+j], 6, strconv.Itoa(r.Total[status]), "1", 0, "C", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	// Satu halaman detail harian untuk setiap mahasantri
+	for _, r := range rekap {
+		pdf.AddPage()
+		pdf.SetFont("Helvetica", "B", 12)
+		pdf.CellFormat(0, 7, "Absensi "+periode, "", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(0, 5, tr(fmt.Sprintf("Nama: %s", r.Mahasantri.Nama)), "", 1, "L", false, 0, "")
+		pdf.CellFormat(0, 5, tr(fmt.Sprintf("NIM: %s", r.Mahasantri.NIM)), "", 1, "L", false, 0, "")
+		pdf.CellFormat(0, 5, tr(fmt.Sprintf("Mentor: %s", mentor.Nama)), "", 1, "L", false, 0, "")
+		pdf.Ln(3)
+
+		pdf.SetFont("Helvetica", "B", 9)
+		for _, h := range []string{"Tanggal", "Hari", "Shubuh", "Isya"} {
+			pdf.CellFormat(40, 6, h, "1", 0, "C", false, 0, "")
+		}
+		pdf.Ln(-1)
+
+		pdf.SetFont("Helvetica", "", 9)
+		for _, d := range r.Summary {
+			for _, v := range []string{d.Tanggal, d.Hari, d.Shubuh, d.Isya} {
+				pdf.CellFormat(40, 6, tr(v), "1", 0, "C", false, 0, "")
+			}
+			pdf.Ln(-1)
+		}
+
+		pdf.Ln(3)
+		pdf.SetFont("Helvetica", "B", 9)
+		for _, status := range statusRekapAbsensi {
+			pdf.CellFormat(32, 6, status, "1", 0, "C", false, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 9)
+		for _, status := range statusRekapAbsensi {
+			pdf.CellFormat(32, 6, strconv.Itoa(r.Total[status]), "1", 0, "C", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// namaSheetMahasantri membuat nama sheet unik dengan batas 31 karakter dari Excel
+func namaSheetMahasantri(m models.Mahasantri) string {
+	name := fmt.Sprintf("%d-%s", m.ID, m.Nama)
+	for _, ch := range []string{":", "\\", "/", "?", "*", "[", "]"} {
+		name = strings.ReplaceAll(name, ch, "")
+	}
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	return name
+}
+
+func getNamaBulan(month time.Month) string {
+	namaBulan := []string{"Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli", "Agustus", "September", "Oktober", "November", "Desember"}
+	return namaBulan[month-1]
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -13,19 +14,33 @@ import (
 	"github.com/habbazettt/mahad-service-go/utils"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AbsensiService struct {
-	DB *gorm.DB
+	DB       *gorm.DB
+	Notifier utils.Notifier
 }
 
+const (
+	AbsensiItemCreated = "created"
+	AbsensiItemUpdated = "updated"
+	AbsensiItemSkipped = "skipped"
+	AbsensiItemError   = "error"
+)
+
 // CreateAbsensi - Membuat absensi baru
 // @Summary Membuat absensi baru untuk Mahasantri
 // @Description Endpoint ini digunakan untuk membuat absensi baru untuk Mahasantri berdasarkan data yang dikirimkan oleh mentor. Dapat menerima satu atau beberapa data absensi dalam satu request.
+// @Description Secara default seluruh batch dibatalkan jika ada satu item yang gagal. Gunakan mode=partial agar setiap item diproses sendiri-sendiri dan mendapatkan hasil masing-masing (created/updated/skipped/error).
+// @Description Gunakan upsert=true untuk memperbarui absensi yang sudah ada pada mahasantri, tanggal, dan waktu yang sama.
 // @Tags Absensi
 // @Accept json
 // @Produce json
 // @Param request body []dto.AbsensiRequestDTO true "Data Absensi dalam bentuk array"
+// @Param mode query string false "Mode pemrosesan batch" Enums(atomic, partial) Default(atomic)
+// @Param upsert query bool false "Perbarui absensi yang sudah ada" Default(false)
+// @Success 200 {object} utils.Response "Batch absensi processed (mode=partial)"
 // @Success 201 {object} utils.Response "Absensi created successfully"
 // @Failure 400 {object} utils.Response "Invalid request body or Absensi already recorded for this date and time"
 // @Failure 401 {object} utils.Response "Unauthorized"
@@ -47,6 +62,13 @@ func (s *AbsensiService) CreateAbsensi(c *fiber.Ctx) error {
 		return utils.ResponseError(c, fiber.StatusUnauthorized, "Unauthorized", "Authorization token is missing")
 	}
 
+	mode := c.Query("mode", "atomic")
+	if mode != "atomic" && mode != "partial" {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Invalid mode value. Allowed values are 'atomic' or 'partial'", nil)
+	}
+	partial := mode == "partial"
+	upsert := c.QueryBool("upsert", false)
+
 	tx := s.DB.Begin()
 	defer func() {
 		if r := recover(); r != nil {
@@ -54,110 +76,298 @@ func (s *AbsensiService) CreateAbsensi(c *fiber.Ctx) error {
 		}
 	}()
 
+	results := make([]dto.AbsensiBatchItemResult, len(req))
+	var summary dto.AbsensiBatchSummary
 	var batchResponse []dto.AbsensiResponseDTO
-	var errors []utils.ErrorResponse
-
-	for _, absensiReq := range req {
-		// Parsing tanggal dari string ke time.Time
-		tanggal, err := time.Parse("02-01-2006", absensiReq.Tanggal)
-		if err != nil {
-			errors = append(errors, utils.ErrorResponse{
-				Message: "Invalid date format",
-				Details: err.Error(),
+	// Item yang gagal beserta indeksnya, dikembalikan utuh jika batch atomic dibatalkan
+	var failed []dto.AbsensiBatchItemResult
+
+	// Tanggal alpa per mahasantri untuk evaluasi surat peringatan
+	alpaToEvaluate := make(map[string]models.Absensi)
+	// Jumlah absensi baru per status untuk metrics, dicatat setelah commit
+	createdPerStatus := make(map[string]int)
+
+	for i, absensiReq := range req {
+		var result dto.AbsensiBatchItemResult
+		var saved *models.Absensi
+
+		if partial {
+			// Setiap item memakai savepoint sendiri agar kegagalan satu item tidak membatalkan item lain
+			err := tx.Transaction(func(itemTx *gorm.DB) error {
+				result, saved = s.saveAbsensiItem(itemTx, absensiReq, upsert)
+				if result.Status == AbsensiItemError {
+					return fmt.Errorf("%s", result.Reason)
+				}
+				return nil
 			})
-			continue
+			if err != nil && result.Status != AbsensiItemError {
+				result.Status = AbsensiItemError
+				result.Reason = "Failed to save absensi"
+				result.Details = err.Error()
+				saved = nil
+			}
+		} else {
+			result, saved = s.saveAbsensiItem(tx, absensiReq, upsert)
+			// Pada mode atomic, absensi duplikat tanpa upsert tetap dianggap gagal
+			if result.Status == AbsensiItemSkipped && !upsert {
+				result.Status = AbsensiItemError
+			}
 		}
-
-		// Memeriksa apakah hari adalah Sabtu atau Minggu
-		if tanggal.Weekday() == time.Saturday || tanggal.Weekday() == time.Sunday {
-			errors = append(errors, utils.ErrorResponse{
-				Message: "Absensi is not allowed on Saturdays or Sundays",
-				Details: "Absensi tidak diperbolehkan pada hari Sabtu atau Minggu",
-			})
-			continue
+		result.Index = i
+
+		switch result.Status {
+		case AbsensiItemCreated:
+			summary.Created++
+		case AbsensiItemUpdated:
+			summary.Updated++
+		case AbsensiItemSkipped:
+			summary.Skipped++
+		case AbsensiItemError:
+			summary.Error++
+			failed = append(failed, result)
 		}
 
-		var mahasantri models.Mahasantri
-		if err := tx.First(&mahasantri, absensiReq.MahasantriID).Error; err != nil {
-			errors = append(errors, utils.ErrorResponse{
-				Message: "Mahasantri not found",
-				Details: err.Error(),
-			})
-			continue
+		if saved != nil && result.Status == AbsensiItemCreated {
+			createdPerStatus[strings.ToLower(saved.Status)]++
 		}
-
-		// Memeriksa apakah absensi sudah tercatat untuk tanggal dan waktu yang diinput
-		var existingAbsensi models.Absensi
-		if err := tx.Where("mahasantri_id = ? AND tanggal = ? AND waktu = ?",
-			absensiReq.MahasantriID, tanggal, absensiReq.Waktu).First(&existingAbsensi).Error; err == nil {
-			errors = append(errors, utils.ErrorResponse{
-				Message: "Absensi already recorded for this date and time",
-				Details: "Absensi sudah tercatat untuk tanggal dan waktu ini",
-			})
-			continue
+		if saved != nil && (result.Status == AbsensiItemCreated || result.Status == AbsensiItemUpdated) {
+			if strings.EqualFold(saved.Status, "alpa") {
+				semester, tahunAjaran, _, _ := getSemesterAkademik(saved.Tanggal)
+				alpaToEvaluate[fmt.Sprintf("%d_%s_%s", saved.MahasantriID, semester, tahunAjaran)] = *saved
+			}
+			if result.Data != nil {
+				batchResponse = append(batchResponse, *result.Data)
+			}
 		}
 
-		absensi := models.Absensi{
-			MahasantriID: absensiReq.MahasantriID,
-			MentorID:     absensiReq.MentorID,
-			Waktu:        absensiReq.Waktu,
-			Status:       absensiReq.Status,
-			Tanggal:      tanggal,
-		}
+		results[i] = result
+	}
 
-		if err := tx.Create(&absensi).Error; err != nil {
-			errors = append(errors, utils.ErrorResponse{
-				Message: "Failed to create absensi",
-				Details: err.Error(),
-			})
-			continue
-		}
+	if !partial && len(failed) > 0 {
+		tx.Rollback()
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Some requests failed", failed)
+	}
 
-		var absensiWithRelations models.Absensi
-		if err := tx.Preload("Mentor").Preload("Mahasantri").First(&absensiWithRelations, absensi.ID).Error; err != nil {
-			errors = append(errors, utils.ErrorResponse{
-				Message: "Failed to preload relations",
-				Details: err.Error(),
-			})
-			continue
+	var issuedSP []models.SuratPeringatan
+	for _, absensi := range alpaToEvaluate {
+		issued, err := evaluasiSuratPeringatan(tx, absensi.MahasantriID, absensi.Tanggal)
+		if err != nil {
+			tx.Rollback()
+			logrus.WithError(err).WithField("mahasantri_id", absensi.MahasantriID).Error("Failed to evaluate surat peringatan")
+			return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to evaluate surat peringatan", err.Error())
 		}
+		issuedSP = append(issuedSP, issued...)
+	}
 
-		response := dto.AbsensiResponseDTO{
-			ID:           absensiWithRelations.ID,
-			MahasantriID: absensiWithRelations.MahasantriID,
-			MentorID:     absensiWithRelations.MentorID,
-			Waktu:        absensiWithRelations.Waktu,
-			Status:       absensiWithRelations.Status,
-			Tanggal:      absensiWithRelations.GetFormattedTanggal(),
-			CreatedAt:    absensiWithRelations.CreatedAt,
-			UpdatedAt:    absensiWithRelations.UpdatedAt,
-			Mentor: dto.MentorResponseDTO{
-				ID:     absensiWithRelations.Mentor.ID,
-				Nama:   absensiWithRelations.Mentor.Nama,
-				Email:  absensiWithRelations.Mentor.Email,
-				Gender: absensiWithRelations.Mentor.Gender,
-			},
-			Mahasantri: dto.MahasantriResponseDTO{
-				ID:      absensiWithRelations.Mahasantri.ID,
-				Nama:    absensiWithRelations.Mahasantri.Nama,
-				NIM:     absensiWithRelations.Mahasantri.NIM,
-				Jurusan: absensiWithRelations.Mahasantri.Jurusan,
-				Gender:  absensiWithRelations.Mahasantri.Gender,
-			},
-		}
-		batchResponse = append(batchResponse, response)
+	if err := tx.Commit().Error; err != nil {
+		logrus.WithError(err).Error("Failed to commit absensi batch")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to create absensi", err.Error())
 	}
 
-	if len(errors) > 0 {
-		tx.Rollback()
-		return utils.ResponseError(c, fiber.StatusBadRequest, "Some requests failed", errors)
+	notifySuratPeringatan(s.Notifier, issuedSP)
+	for status, count := range createdPerStatus {
+		utils.AbsensiRecordedTotal.WithLabelValues(status).Add(float64(count))
 	}
 
-	tx.Commit()
+	logrus.WithFields(logrus.Fields{
+		"mode":    mode,
+		"upsert":  upsert,
+		"created": summary.Created,
+		"updated": summary.Updated,
+		"skipped": summary.Skipped,
+		"error":   summary.Error,
+	}).Info("Absensi batch processed")
+
+	if partial {
+		return utils.SuccessResponse(c, fiber.StatusOK, "Batch absensi processed", fiber.Map{
+			"summary": summary,
+			"results": results,
+		})
+	}
 
 	return utils.SuccessResponse(c, fiber.StatusCreated, "Absensi created successfully", batchResponse)
 }
 
+// saveAbsensiItem memvalidasi dan menyimpan satu item absensi dari batch.
+// Absensi yang sudah ada pada mahasantri, tanggal, dan waktu yang sama akan dilewati, atau diperbarui jika upsert aktif.
+func (s *AbsensiService) saveAbsensiItem(tx *gorm.DB, req dto.AbsensiRequestDTO, upsert bool) (dto.AbsensiBatchItemResult, *models.Absensi) {
+	result := dto.AbsensiBatchItemResult{
+		MahasantriID: req.MahasantriID,
+		Tanggal:      req.Tanggal,
+		Waktu:        req.Waktu,
+	}
+	fail := func(reason string, details interface{}) (dto.AbsensiBatchItemResult, *models.Absensi) {
+		result.Status = AbsensiItemError
+		result.Reason = reason
+		result.Details = details
+		return result, nil
+	}
+
+	// Parsing tanggal dari string ke time.Time
+	tanggal, err := time.Parse("02-01-2006", req.Tanggal)
+	if err != nil {
+		return fail("Invalid date format", err.Error())
+	}
+
+	// Memeriksa apakah hari adalah Sabtu atau Minggu
+	if tanggal.Weekday() == time.Saturday || tanggal.Weekday() == time.Sunday {
+		return fail("Absensi is not allowed on Saturdays or Sundays", "Absensi tidak diperbolehkan pada hari Sabtu atau Minggu")
+	}
+
+	waktu := strings.ToLower(req.Waktu)
+	if waktu != "shubuh" && waktu != "isya" {
+		return fail("Invalid waktu", "Waktu harus shubuh atau isya")
+	}
+
+	status := strings.ToLower(req.Status)
+	if status != "hadir" && status != "alpa" && status != "izin" {
+		return fail("Invalid status", "Status harus hadir, alpa, atau izin")
+	}
+
+	var mahasantri models.Mahasantri
+	if err := tx.First(&mahasantri, req.MahasantriID).Error; err != nil {
+		return fail("Mahasantri not found", err.Error())
+	}
+	if mahasantri.Status == models.StatusMahasantriLulus {
+		return fail("Mahasantri sudah lulus", errMahasantriAlumni.Error())
+	}
+
+	absensi := models.Absensi{
+		MahasantriID: req.MahasantriID,
+		MentorID:     req.MentorID,
+		Waktu:        waktu,
+		Status:       status,
+		Tanggal:      tanggal,
+	}
+
+	// Memeriksa apakah absensi sudah tercatat untuk tanggal dan waktu yang diinput
+	var existing models.Absensi
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("mahasantri_id = ? AND tanggal = ? AND waktu = ?", req.MahasantriID, tanggal, waktu).
+		First(&existing).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fail("Failed to check existing absensi", err.Error())
+	}
+
+	if err == nil {
+		result, saved := s.applyExistingAbsensi(tx, existing, absensi, upsert)
+		result.MahasantriID, result.Tanggal, result.Waktu = req.MahasantriID, req.Tanggal, waktu
+		return result, saved
+	}
+
+	// Unique constraint menjaga agar request bersamaan tidak membuat absensi ganda
+	res := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "mahasantri_id"}, {Name: "tanggal"}, {Name: "waktu"}},
+		DoNothing: true,
+	}).Create(&absensi)
+	if res.Error != nil {
+		return fail("Failed to create absensi", res.Error.Error())
+	}
+
+	if res.RowsAffected == 0 {
+		if err := tx.Where("mahasantri_id = ? AND tanggal = ? AND waktu = ?", req.MahasantriID, tanggal, waktu).First(&existing).Error; err != nil {
+			return fail("Failed to check existing absensi", err.Error())
+		}
+		result, saved := s.applyExistingAbsensi(tx, existing, absensi, upsert)
+		result.MahasantriID, result.Tanggal, result.Waktu = req.MahasantriID, req.Tanggal, waktu
+		return result, saved
+	}
+
+	if err := syncQadhaAbsensi(tx, absensi); err != nil {
+		return fail("Failed to create qadha", err.Error())
+	}
+
+	data, err := s.loadAbsensiResponse(tx, absensi.ID)
+	if err != nil {
+		return fail("Failed to preload relations", err.Error())
+	}
+
+	result.Status = AbsensiItemCreated
+	result.Waktu = waktu
+	result.Data = data
+	return result, &absensi
+}
+
+// applyExistingAbsensi memperbarui absensi yang sudah ada jika upsert aktif, atau melewatinya jika tidak
+func (s *AbsensiService) applyExistingAbsensi(tx *gorm.DB, existing models.Absensi, incoming models.Absensi, upsert bool) (dto.AbsensiBatchItemResult, *models.Absensi) {
+	var result dto.AbsensiBatchItemResult
+
+	if !upsert {
+		result.Status = AbsensiItemSkipped
+		result.Reason = "Absensi already recorded for this date and time"
+		result.Details = "Absensi sudah tercatat untuk tanggal dan waktu ini"
+		return result, nil
+	}
+
+	if existing.Status == incoming.Status && existing.MentorID == incoming.MentorID {
+		result.Status = AbsensiItemSkipped
+		result.Reason = "No changes detected"
+		return result, nil
+	}
+
+	existing.Status = incoming.Status
+	existing.MentorID = incoming.MentorID
+	if err := tx.Model(&existing).Updates(map[string]interface{}{
+		"status":    existing.Status,
+		"mentor_id": existing.MentorID,
+	}).Error; err != nil {
+		result.Status = AbsensiItemError
+		result.Reason = "Failed to update absensi"
+		result.Details = err.Error()
+		return result, nil
+	}
+
+	if err := syncQadhaAbsensi(tx, existing); err != nil {
+		result.Status = AbsensiItemError
+		result.Reason = "Failed to update qadha"
+		result.Details = err.Error()
+		return result, nil
+	}
+
+	data, err := s.loadAbsensiResponse(tx, existing.ID)
+	if err != nil {
+		result.Status = AbsensiItemError
+		result.Reason = "Failed to preload relations"
+		result.Details = err.Error()
+		return result, nil
+	}
+
+	result.Status = AbsensiItemUpdated
+	result.Data = data
+	return result, &existing
+}
+
+func (s *AbsensiService) loadAbsensiResponse(tx *gorm.DB, id uint) (*dto.AbsensiResponseDTO, error) {
+	var absensiWithRelations models.Absensi
+	if err := tx.Preload("Mentor").Preload("Mahasantri").First(&absensiWithRelations, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &dto.AbsensiResponseDTO{
+		ID:           absensiWithRelations.ID,
+		MahasantriID: absensiWithRelations.MahasantriID,
+		MentorID:     absensiWithRelations.MentorID,
+		Waktu:        absensiWithRelations.Waktu,
+		Status:       absensiWithRelations.Status,
+		Tanggal:      absensiWithRelations.GetFormattedTanggal(),
+		CreatedAt:    absensiWithRelations.CreatedAt,
+		UpdatedAt:    absensiWithRelations.UpdatedAt,
+		Mentor: dto.MentorResponseDTO{
+			ID:     absensiWithRelations.Mentor.ID,
+			Nama:   absensiWithRelations.Mentor.Nama,
+			Email:  absensiWithRelations.Mentor.Email,
+			Gender: absensiWithRelations.Mentor.Gender,
+		},
+		Mahasantri: dto.MahasantriResponseDTO{
+			ID:      absensiWithRelations.Mahasantri.ID,
+			Nama:    absensiWithRelations.Mahasantri.Nama,
+			NIM:     absensiWithRelations.Mahasantri.NIM,
+			Jurusan: absensiWithRelations.Mahasantri.Jurusan,
+			Gender:  absensiWithRelations.Mahasantri.Gender,
+		},
+	}, nil
+}
+
 // GetAbsensi - Mengambil data absensi
 // @Summary Mengambil data absensi
 // @Description Endpoint ini digunakan untuk mengambil data absensi dengan pagination, filter, dan sorting.
@@ -379,13 +589,10 @@ func (s *AbsensiService) GetAbsensiDailySummary(c *fiber.Ctx) error {
 		return utils.ResponseError(c, fiber.StatusBadRequest, "Missing query parameters", "month and year are required")
 	}
 
-	layout := "02-01-2006"
-	location := time.Now().Location()
-	startDate, err := time.ParseInLocation("02-01-2006", fmt.Sprintf("01-%s-%s", month, year), location)
+	startDate, endDate, err := parseBulanAbsensi(month, year)
 	if err != nil {
 		return utils.ResponseError(c, fiber.StatusBadRequest, "Invalid date format", err.Error())
 	}
-	endDate := startDate.AddDate(0, 1, -1)
 
 	// Fetch all absensi for that month
 	var absensi []models.Absensi
@@ -407,51 +614,7 @@ func (s *AbsensiService) GetAbsensiDailySummary(c *fiber.Ctx) error {
 		return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to fetch Mentor details", err.Error())
 	}
 
-	// Indexing absensi per tanggal & waktu
-	absensiMap := make(map[string]map[string]string) // tanggal -> waktu -> status
-	for _, a := range absensi {
-		tanggal := a.Tanggal.Format(layout)
-		if _, ok := absensiMap[tanggal]; !ok {
-			absensiMap[tanggal] = make(map[string]string)
-		}
-		absensiMap[tanggal][a.Waktu] = a.Status
-	}
-
-	// Build daily summary
-	var summary []dto.AbsensiDailySummaryDTO
-	for d := startDate; !d.After(endDate); d = d.AddDate(0, 0, 1) {
-		tanggal := d.Format(layout)
-		shubuh := "belum-absen"
-		isya := "belum-absen"
-
-		// Cek hari dalam seminggu
-		switch d.Weekday() {
-		case time.Saturday:
-			shubuh = "libur"
-			isya = "libur"
-		case time.Sunday:
-			shubuh = "libur"
-			// Isya tidak libur, jadi tetap "belum-absen" kecuali ada data
-		default:
-			// Hari biasa, cek absensi
-			if data, ok := absensiMap[tanggal]; ok {
-				if val, exists := data["shubuh"]; exists {
-					shubuh = val
-				}
-				if val, exists := data["isya"]; exists {
-					isya = val
-				}
-			}
-		}
-
-		// Tambahkan detail hari ke dalam ringkasan
-		summary = append(summary, dto.AbsensiDailySummaryDTO{
-			Tanggal: tanggal,
-			Hari:    getNamaHari(d.Weekday()),
-			Shubuh:  shubuh,
-			Isya:    isya,
-		})
-	}
+	summary := buildAbsensiDailySummary(absensi, startDate, endDate)
 
 	info := fiber.Map{
 		"month": month,
@@ -508,6 +671,9 @@ func (s *AbsensiService) UpdateAbsensi(c *fiber.Ctx) error {
 		logrus.WithField("absensi_id", id).Warn("Absensi not found")
 		return utils.ResponseError(c, fiber.StatusNotFound, "Absensi not found", nil)
 	}
+	if err := ensureMahasantriWritable(s.DB, absensi.MahasantriID); err != nil {
+		return responseMahasantriFrozen(c, err)
+	}
 
 	var req dto.UpdateAbsensiRequestDTO
 	if err := c.BodyParser(&req); err != nil {
@@ -547,13 +713,34 @@ func (s *AbsensiService) UpdateAbsensi(c *fiber.Ctx) error {
 		return utils.ResponseError(c, fiber.StatusBadRequest, "No changes detected", nil)
 	}
 
-	// Menyimpan perubahan ke database
-	if err := s.DB.Save(&absensi).Error; err != nil {
+	// Menyimpan perubahan ke database beserta penyesuaian qadha
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&absensi).Error; err != nil {
+			return err
+		}
+		return syncQadhaAbsensi(tx, absensi)
+	})
+	if err != nil {
 		logrus.WithError(err).WithFields(updateFields).Error("Failed to update absensi")
 		return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to update absensi", err.Error())
 	}
 
 	logrus.WithFields(updateFields).Info("Absensi updated successfully")
+
+	if strings.EqualFold(absensi.Status, "alpa") {
+		var issuedSP []models.SuratPeringatan
+		err := s.DB.Transaction(func(tx *gorm.DB) error {
+			var err error
+			issuedSP, err = evaluasiSuratPeringatan(tx, absensi.MahasantriID, absensi.Tanggal)
+			return err
+		})
+		if err != nil {
+			logrus.WithError(err).WithFields(updateFields).Error("Failed to evaluate surat peringatan")
+		} else {
+			notifySuratPeringatan(s.Notifier, issuedSP)
+		}
+	}
+
 	response := dto.AbsensiResponseDTO{
 		ID:           absensi.ID,
 		MahasantriID: absensi.MahasantriID,
@@ -587,6 +774,9 @@ func (s *AbsensiService) DeleteAbsensi(c *fiber.Ctx) error {
 		logrus.WithField("absensi_id", id).Warn("Absensi not found")
 		return utils.ResponseError(c, fiber.StatusNotFound, "Absensi not found", nil)
 	}
+	if err := ensureMahasantriWritable(s.DB, absensi.MahasantriID); err != nil {
+		return responseMahasantriFrozen(c, err)
+	}
 
 	// Menghapus absensi dari database
 	if err := s.DB.Delete(&absensi).Error; err != nil {
@@ -598,6 +788,72 @@ func (s *AbsensiService) DeleteAbsensi(c *fiber.Ctx) error {
 	return utils.SuccessResponse(c, fiber.StatusOK, "Absensi deleted successfully", nil)
 }
 
+// parseBulanAbsensi mengembalikan tanggal awal dan akhir dari bulan (MM) dan tahun (YYYY)
+func parseBulanAbsensi(month, year string) (time.Time, time.Time, error) {
+	startDate, err := time.ParseInLocation("02-01-2006", fmt.Sprintf("01-%s-%s", month, year), time.Now().Location())
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return startDate, startDate.AddDate(0, 1, -1), nil
+}
+
+// buildAbsensiDailySummary menyusun status shubuh dan isya per hari dalam rentang tanggal.
+// Default "belum-absen" jika belum ada data, dan "libur" untuk hari libur.
+func buildAbsensiDailySummary(absensi []models.Absensi, startDate, endDate time.Time) []dto.AbsensiDailySummaryDTO {
+	layout := "02-01-2006"
+
+	// Indexing absensi per tanggal & waktu
+	absensiMap := make(map[string]map[string]string) // tanggal -> waktu -> status
+	for _, a := range absensi {
+		tanggal := a.Tanggal.Format(layout)
+		if _, ok := absensiMap[tanggal]; !ok {
+			absensiMap[tanggal] = make(map[string]string)
+		}
+		absensiMap[tanggal][a.Waktu] = a.Status
+	}
+
+	// Build daily summary
+	var summary []dto.AbsensiDailySummaryDTO
+	for d := startDate; !d.After(endDate); d = d.AddDate(0, 0, 1) {
+		tanggal := d.Format(layout)
+		shubuh := "belum-absen"
+		isya := "belum-absen"
+
+		// Cek hari dalam seminggu
+		switch d.Weekday() {
+		case time.Saturday:
+			shubuh = "libur"
+			isya = "libur"
+		case time.Sunday:
+			shubuh = "libur"
+			// Isya tidak libur, jadi tetap "belum-absen" kecuali ada data
+			if val, exists := absensiMap[tanggal]["isya"]; exists {
+				isya = val
+			}
+		default:
+			// Hari biasa, cek absensi
+			if data, ok := absensiMap[tanggal]; ok {
+				if val, exists := data["shubuh"]; exists {
+					shubuh = val
+				}
+				if val, exists := data["isya"]; exists {
+					isya = val
+				}
+			}
+		}
+
+		// Tambahkan detail hari ke dalam ringkasan
+		summary = append(summary, dto.AbsensiDailySummaryDTO{
+			Tanggal: tanggal,
+			Hari:    getNamaHari(d.Weekday()),
+			Shubuh:  shubuh,
+			Isya:    isya,
+		})
+	}
+
+	return summary
+}
+
 // Fungsi untuk mengonversi nama hari ke dalam bahasa Indonesia
 func getNamaHari(weekday time.Weekday) string {
 	switch weekday {
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/habbazettt/mahad-service-go/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildAbsensiDailySummary(t *testing.T) {
+	tanggal := func(hari int) time.Time {
+		return time.Date(2025, time.June, hari, 0, 0, 0, 0, time.Local)
+	}
+	absensi := []models.Absensi{
+		{Tanggal: tanggal(6), Waktu: "shubuh", Status: "hadir"},
+		{Tanggal: tanggal(7), Waktu: "shubuh", Status: "hadir"},
+		{Tanggal: tanggal(8), Waktu: "shubuh", Status: "hadir"},
+		{Tanggal: tanggal(8), Waktu: "isya", Status: "izin"},
+	}
+
+	summary := buildAbsensiDailySummary(absensi, tanggal(6), tanggal(9))
+
+	if assert.Len(t, summary, 4) {
+		// Jumat: shubuh tercatat, isya belum
+		assert.Equal(t, "06-06-2025", summary[0].Tanggal)
+		assert.Equal(t, "Jumat", summary[0].Hari)
+		assert.Equal(t, "hadir", summary[0].Shubuh)
+		assert.Equal(t, "belum-absen", summary[0].Isya)
+
+		// Sabtu: libur meskipun ada data
+		assert.Equal(t, "libur", summary[1].Shubuh)
+		assert.Equal(t, "libur", summary[1].Isya)
+
+		// Minggu: hanya shubuh yang libur
+		assert.Equal(t, "Minggu", summary[2].Hari)
+		assert.Equal(t, "libur", summary[2].Shubuh)
+		assert.Equal(t, "izin", summary[2].Isya)
+
+		// Senin tanpa data
+		assert.Equal(t, "belum-absen", summary[3].Shubuh)
+		assert.Equal(t, "belum-absen", summary[3].Isya)
+	}
+}
+
+func TestBuildAbsensiDailySummaryRentangKosong(t *testing.T) {
+	mulai := time.Date(2025, time.June, 9, 0, 0, 0, 0, time.Local)
+	assert.Empty(t, buildAbsensiDailySummary(nil, mulai, mulai.AddDate(0, 0, -1)))
+}
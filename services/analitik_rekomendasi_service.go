@@ -0,0 +1,251 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/mahad-service-go/dto"
+	"github.com/habbazettt/mahad-service-go/models"
+	"github.com/habbazettt/mahad-service-go/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	// sqlTipeDasar menghapus daftar state tetangga dari tipe fallback kemiripan
+	sqlTipeDasar = "CASE WHEN jr.tipe_rekomendasi LIKE 'Kemiripan State%' THEN 'Kemiripan State' ELSE jr.tipe_rekomendasi END"
+
+	// sqlFallback menandai rekomendasi engine Q-learning yang tidak berasal dari state yang dikenal Q-Table.
+	// Engine lain memang tidak memakai Q-Table sehingga tidak dihitung sebagai fallback.
+	sqlFallback = "(jr.engine = 'qlearning' AND jr.tipe_rekomendasi <> 'Spesifik')"
+
+	sqlDiadopsi = "(ub.diterapkan OR COALESCE(dl.jumlah_sesi, 0) > 0)"
+
+	// sqlSesiAI mengenali sesi dari rekomendasi. Sesi lama sebelum ada relasi jadwal_rekomendasi_id dikenali dari prefix "AI:".
+	sqlSesiAI = "(dl.jadwal_rekomendasi_id IS NOT NULL OR dl.waktu_murojaah LIKE 'AI:%')"
+)
+
+var intervalTrenRekomendasi = map[string]string{"hari": "day", "minggu": "week", "bulan": "month"}
+
+func persentase(bagian, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(bagian) / float64(total) * 100
+}
+
+type barisSesiMurojaah struct {
+	TotalSesi           int64
+	SesiSelesai         int64
+	TotalTargetHalaman  int64
+	TotalSelesaiHalaman int64
+}
+
+func (b barisSesiMurojaah) ringkasan() dto.RingkasanSesiMurojaah {
+	return dto.RingkasanSesiMurojaah{
+		TotalSesi:           b.TotalSesi,
+		SesiSelesai:         b.SesiSelesai,
+		TingkatPenyelesaian: persentase(b.SesiSelesai, b.TotalSesi),
+		TotalTargetHalaman:  b.TotalTargetHalaman,
+		TotalSelesaiHalaman: b.TotalSelesaiHalaman,
+		PersentaseHalaman:   persentase(b.TotalSelesaiHalaman, b.TotalTargetHalaman),
+	}
+}
+
+const sqlKolomSesiMurojaah = `COUNT(*) AS total_sesi,
+	COUNT(*) FILTER (WHERE dl.status = ?) AS sesi_selesai,
+	COALESCE(SUM(dl.total_target_halaman), 0) AS total_target_halaman,
+	COALESCE(SUM(dl.total_selesai_halaman), 0) AS total_selesai_halaman`
+
+// rekomendasiDenganAdopsi menggabungkan riwayat rekomendasi dengan umpan balik dan sesi murojaah yang dibuat darinya
+func (s *rekomendasiService) rekomendasiDenganAdopsi(dari, sampai *time.Time) *gorm.DB {
+	query := s.DB.Table("jadwal_rekomendasis AS jr").
+		Joins("LEFT JOIN umpan_balik_rekomendasis ub ON ub.jadwal_rekomendasi_id = jr.id").
+		Joins("LEFT JOIN (?) AS dl ON dl.jadwal_rekomendasi_id = jr.id", sesiPerRekomendasiQuery(s.DB))
+	return filterRentangTanggal(query, "jr.created_at", dari, sampai)
+}
+
+func (s *rekomendasiService) sesiMurojaah(dari, sampai *time.Time) *gorm.DB {
+	query := s.DB.Table("detail_logs AS dl").Joins("JOIN log_harians lh ON lh.id = dl.log_harian_id")
+	return filterRentangTanggal(query, "lh.tanggal", dari, sampai)
+}
+
+// GetAnalitikRekomendasi - Ringkasan pemakaian rekomendasi
+// @Summary Analitik pemakaian rekomendasi
+// @Description Ringkasan jumlah permintaan rekomendasi per state, tipe, dan engine, tingkat fallback, tingkat adopsi, serta perbandingan penyelesaian sesi murojaah dari rekomendasi (AI) dengan sesi manual. Rekomendasi dianggap diadopsi jika pengguna menyatakan menerapkannya atau jika dipakai membuat sesi murojaah.
+// @Tags Rekomendasi
+// @Produce json
+// @Param dari query string false "Tanggal awal (DD-MM-YYYY)"
+// @Param sampai query string false "Tanggal akhir (DD-MM-YYYY)"
+// @Success 200 {object} utils.Response{data=dto.AnalitikRekomendasiResponse} "Analitik rekomendasi berhasil diambil"
+// @Failure 400 {object} utils.Response "Format tanggal tidak valid"
+// @Failure 500 {object} utils.Response "Gagal mengambil analitik rekomendasi"
+// @Security BearerAuth
+// @Router /api/v1/rekomendasi/analitik [get]
+func (s *rekomendasiService) GetAnalitikRekomendasi(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	log := logrus.WithFields(logrus.Fields{"handler": "GetAnalitikRekomendasi", "userID": claims.ID})
+
+	dari, sampai, err := parseRentangTanggal(c)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, err.Error(), nil)
+	}
+
+	var perState []dto.AnalitikStateRekomendasi
+	if err := s.rekomendasiDenganAdopsi(dari, sampai).
+		Select(`jr.state,
+			COUNT(*) AS total_permintaan,
+			COUNT(*) FILTER (WHERE ` + sqlFallback + `) AS total_fallback,
+			COUNT(*) FILTER (WHERE ` + sqlDiadopsi + `) AS total_diadopsi`).
+		Group("jr.state").
+		Order("total_permintaan DESC, jr.state ASC").
+		Scan(&perState).Error; err != nil {
+		log.WithError(err).Error("Gagal menghitung analitik rekomendasi per state")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil analitik rekomendasi", err.Error())
+	}
+
+	var perTipe, perEngine []dto.JumlahPerKategori
+	if err := filterRentangTanggal(s.DB.Table("jadwal_rekomendasis AS jr"), "jr.created_at", dari, sampai).
+		Select(sqlTipeDasar + " AS nama, COUNT(*) AS jumlah").
+		Group("nama").
+		Order("jumlah DESC, nama ASC").
+		Scan(&perTipe).Error; err != nil {
+		log.WithError(err).Error("Gagal menghitung analitik rekomendasi per tipe")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil analitik rekomendasi", err.Error())
+	}
+	if err := filterRentangTanggal(s.DB.Table("jadwal_rekomendasis AS jr"), "jr.created_at", dari, sampai).
+		Select("jr.engine AS nama, COUNT(*) AS jumlah").
+		Group("jr.engine").
+		Order("jumlah DESC, nama ASC").
+		Scan(&perEngine).Error; err != nil {
+		log.WithError(err).Error("Gagal menghitung analitik rekomendasi per engine")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil analitik rekomendasi", err.Error())
+	}
+
+	var sesi []struct {
+		Sesi   barisSesiMurojaah `gorm:"embedded"`
+		DariAI bool
+	}
+	if err := s.sesiMurojaah(dari, sampai).
+		Select(sqlSesiAI+" AS dari_ai, "+sqlKolomSesiMurojaah, models.StatusSesiSelesai).
+		Group("dari_ai").
+		Scan(&sesi).Error; err != nil {
+		log.WithError(err).Error("Gagal menghitung penyelesaian sesi murojaah")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil analitik rekomendasi", err.Error())
+	}
+
+	res := dto.AnalitikRekomendasiResponse{PerTipe: perTipe, PerEngine: perEngine, PerState: perState}
+	for i := range res.PerState {
+		st := &res.PerState[i]
+		st.TingkatFallback = persentase(st.TotalFallback, st.TotalPermintaan)
+		st.TingkatAdopsi = persentase(st.TotalDiadopsi, st.TotalPermintaan)
+		res.TotalPermintaan += st.TotalPermintaan
+		res.TotalFallback += st.TotalFallback
+		res.TotalDiadopsi += st.TotalDiadopsi
+	}
+	res.TingkatFallback = persentase(res.TotalFallback, res.TotalPermintaan)
+	res.TingkatAdopsi = persentase(res.TotalDiadopsi, res.TotalPermintaan)
+	for _, row := range sesi {
+		if row.DariAI {
+			res.SesiAI = row.Sesi.ringkasan()
+		} else {
+			res.SesiManual = row.Sesi.ringkasan()
+		}
+	}
+
+	log.WithField("total_permintaan", res.TotalPermintaan).Info("Berhasil mengambil analitik rekomendasi")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Analitik rekomendasi berhasil diambil", res)
+}
+
+// GetTrenRekomendasi - Tren pemakaian rekomendasi per periode
+// @Summary Tren pemakaian rekomendasi
+// @Description Jumlah permintaan, fallback, dan adopsi rekomendasi serta penyelesaian sesi murojaah AI dan manual per hari, minggu, atau bulan. Periode tanpa data tidak ditampilkan.
+// @Tags Rekomendasi
+// @Produce json
+// @Param interval query string false "hari, minggu, atau bulan" default(minggu)
+// @Param dari query string false "Tanggal awal (DD-MM-YYYY)"
+// @Param sampai query string false "Tanggal akhir (DD-MM-YYYY)"
+// @Success 200 {object} utils.Response{data=[]dto.TrenRekomendasiItem} "Tren rekomendasi berhasil diambil"
+// @Failure 400 {object} utils.Response "Interval atau format tanggal tidak valid"
+// @Failure 500 {object} utils.Response "Gagal mengambil tren rekomendasi"
+// @Security BearerAuth
+// @Router /api/v1/rekomendasi/analitik/tren [get]
+func (s *rekomendasiService) GetTrenRekomendasi(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	log := logrus.WithFields(logrus.Fields{"handler": "GetTrenRekomendasi", "userID": claims.ID})
+
+	interval, ok := intervalTrenRekomendasi[c.Query("interval", "minggu")]
+	if !ok {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Interval harus hari, minggu, atau bulan", nil)
+	}
+	dari, sampai, err := parseRentangTanggal(c)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, err.Error(), nil)
+	}
+
+	var permintaan []struct {
+		Periode         time.Time
+		TotalPermintaan int64
+		TotalFallback   int64
+		TotalDiadopsi   int64
+	}
+	if err := s.rekomendasiDenganAdopsi(dari, sampai).
+		Select(`DATE_TRUNC(?, jr.created_at) AS periode,
+			COUNT(*) AS total_permintaan,
+			COUNT(*) FILTER (WHERE `+sqlFallback+`) AS total_fallback,
+			COUNT(*) FILTER (WHERE `+sqlDiadopsi+`) AS total_diadopsi`, interval).
+		Group("periode").
+		Scan(&permintaan).Error; err != nil {
+		log.WithError(err).Error("Gagal menghitung tren permintaan rekomendasi")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil tren rekomendasi", err.Error())
+	}
+
+	var sesi []struct {
+		Sesi    barisSesiMurojaah `gorm:"embedded"`
+		Periode time.Time
+		DariAI  bool
+	}
+	if err := s.sesiMurojaah(dari, sampai).
+		Select("DATE_TRUNC(?, lh.tanggal::timestamp) AS periode, "+sqlSesiAI+" AS dari_ai, "+sqlKolomSesiMurojaah, interval, models.StatusSesiSelesai).
+		Group("periode, dari_ai").
+		Scan(&sesi).Error; err != nil {
+		log.WithError(err).Error("Gagal menghitung tren sesi murojaah")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil tren rekomendasi", err.Error())
+	}
+
+	perPeriode := make(map[string]*dto.TrenRekomendasiItem)
+	item := func(periode time.Time) *dto.TrenRekomendasiItem {
+		key := periode.Format("2006-01-02")
+		if perPeriode[key] == nil {
+			perPeriode[key] = &dto.TrenRekomendasiItem{Periode: periode.Format("02-01-2006")}
+		}
+		return perPeriode[key]
+	}
+	for _, row := range permintaan {
+		it := item(row.Periode)
+		it.TotalPermintaan = row.TotalPermintaan
+		it.TotalFallback = row.TotalFallback
+		it.TotalDiadopsi = row.TotalDiadopsi
+	}
+	for _, row := range sesi {
+		if row.DariAI {
+			item(row.Periode).SesiAI = row.Sesi.ringkasan()
+		} else {
+			item(row.Periode).SesiManual = row.Sesi.ringkasan()
+		}
+	}
+
+	keys := make([]string, 0, len(perPeriode))
+	for k := range perPeriode {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	tren := make([]dto.TrenRekomendasiItem, len(keys))
+	for i, k := range keys {
+		tren[i] = *perPeriode[k]
+	}
+
+	log.WithFields(logrus.Fields{"interval": interval, "jumlah_periode": len(tren)}).Info("Berhasil mengambil tren rekomendasi")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Tren rekomendasi berhasil diambil", tren)
+}
@@ -0,0 +1,320 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/mahad-service-go/dto"
+	"github.com/habbazettt/mahad-service-go/models"
+	"github.com/habbazettt/mahad-service-go/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// paginationParams membaca page dan limit dari query string
+func paginationParams(c *fiber.Ctx) (page, limit, offset int) {
+	page, _ = strconv.Atoi(c.Query("page", "1"))
+	limit, _ = strconv.Atoi(c.Query("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	return page, limit, (page - 1) * limit
+}
+
+// GetArsipMahasantri - Mengambil daftar mahasantri yang diarsipkan
+// @Summary Daftar arsip mahasantri
+// @Description Mengambil mahasantri yang sudah dihapus (diarsipkan). Data hafalan, absensi, dan log tetap tersimpan dan dapat dipulihkan.
+// @Tags Mahasantri
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Limit per page" default(10)
+// @Success 200 {object} utils.Response "Archived mahasantri retrieved successfully"
+// @Failure 500 {object} utils.Response "Failed to fetch archived mahasantri"
+// @Security BearerAuth
+// @Router /api/v1/mahasantri/arsip [get]
+func (s *MahasantriService) GetArsipMahasantri(c *fiber.Ctx) error {
+	page, limit, offset := paginationParams(c)
+
+	query := s.DB.Unscoped().Model(&models.Mahasantri{}).Where("deleted_at IS NOT NULL")
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logrus.WithError(err).Error("Failed to count archived mahasantri")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to fetch archived mahasantri", err.Error())
+	}
+
+	var mahasantri []models.Mahasantri
+	if err := query.Order("deleted_at DESC").Limit(limit).Offset(offset).Find(&mahasantri).Error; err != nil {
+		logrus.WithError(err).Error("Failed to fetch archived mahasantri")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to fetch archived mahasantri", err.Error())
+	}
+
+	response := make([]dto.ArsipMahasantriResponse, len(mahasantri))
+	for i, m := range mahasantri {
+		response[i] = dto.ArsipMahasantriResponse{
+			ID:             m.ID,
+			Nama:           m.Nama,
+			NIM:            m.NIM,
+			Jurusan:        m.Jurusan,
+			Gender:         m.Gender,
+			MentorID:       m.MentorID,
+			DiarsipkanPada: m.DeletedAt.Time,
+		}
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Archived mahasantri retrieved successfully", fiber.Map{
+		"pagination": fiber.Map{
+			"current_page": page,
+			"total_data":   total,
+			"total_pages":  int(math.Ceil(float64(total) / float64(limit))),
+		},
+		"mahasantri": response,
+	})
+}
+
+// RestoreMahasantri - Memulihkan mahasantri dari arsip
+// @Summary Memulihkan mahasantri dari arsip
+// @Description Memulihkan mahasantri yang diarsipkan beserta seluruh datanya. Mentor pembimbing harus masih aktif.
+// @Tags Mahasantri
+// @Produce json
+// @Param id path int true "ID Mahasantri"
+// @Success 200 {object} utils.Response "Mahasantri restored successfully"
+// @Failure 404 {object} utils.Response "Archived mahasantri not found"
+// @Failure 409 {object} utils.Response "Mentor of this mahasantri is archived or at capacity"
+// @Security BearerAuth
+// @Router /api/v1/mahasantri/{id}/restore [put]
+func (s *MahasantriService) RestoreMahasantri(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var mahasantri models.Mahasantri
+	if err := s.DB.Unscoped().Where("deleted_at IS NOT NULL").First(&mahasantri, id).Error; err != nil {
+		return utils.ResponseError(c, fiber.StatusNotFound, "Archived mahasantri not found", nil)
+	}
+
+	var mentor models.Mentor
+	if err := s.DB.First(&mentor, mahasantri.MentorID).Error; err != nil {
+		return utils.ResponseError(c, fiber.StatusConflict, "Mentor of this mahasantri is archived, restore the mentor first", fiber.Map{"mentor_id": mahasantri.MentorID})
+	}
+
+	// Mahasantri yang dipulihkan kembali dihitung sebagai beban mentor
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := kunciMentor(tx, mahasantri.MentorID); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&mahasantri).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return cekKapasitasMentor(tx, mahasantri.MentorID)
+	})
+	if errors.Is(err, errKapasitasMentorPenuh) {
+		return utils.ResponseError(c, fiber.StatusConflict, "Mentor of this mahasantri has reached MENTOR_MAX_BIMBINGAN, transfer another mahasantri first", fiber.Map{"mentor_id": mahasantri.MentorID})
+	}
+	if err != nil {
+		logrus.WithError(err).Error("Failed to restore mahasantri")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to restore mahasantri", err.Error())
+	}
+
+	logrus.WithField("mahasantri_id", mahasantri.ID).Info("Mahasantri restored successfully")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Mahasantri restored successfully", dto.MahasantriResponse{
+		ID:                   mahasantri.ID,
+		Nama:                 mahasantri.Nama,
+		NIM:                  mahasantri.NIM,
+		Jurusan:              mahasantri.Jurusan,
+		Gender:               mahasantri.Gender,
+		MentorID:             mahasantri.MentorID,
+		IsDataMurojaahFilled: mahasantri.IsDataMurojaahFilled,
+	})
+}
+
+// PurgeMahasantri - Menghapus permanen mahasantri yang diarsipkan
+// @Summary Menghapus permanen mahasantri
+// @Description Menghapus permanen mahasantri yang sudah diarsipkan beserta seluruh hafalan, absensi, target, dan log. Membutuhkan header X-Admin-Key dan parameter confirm berisi NIM mahasantri.
+// @Tags Mahasantri
+// @Produce json
+// @Param id path int true "ID Mahasantri"
+// @Param confirm query string true "NIM mahasantri sebagai konfirmasi"
+// @Param X-Admin-Key header string true "Admin API key"
+// @Success 200 {object} utils.Response "Mahasantri purged successfully"
+// @Failure 400 {object} utils.Response "Confirmation does not match"
+// @Failure 403 {object} utils.Response "Invalid admin key"
+// @Failure 404 {object} utils.Response "Archived mahasantri not found"
+// @Router /api/v1/mahasantri/{id}/purge [delete]
+func (s *MahasantriService) PurgeMahasantri(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	// Hanya data yang sudah diarsipkan yang dapat dihapus permanen
+	var mahasantri models.Mahasantri
+	if err := s.DB.Unscoped().Where("deleted_at IS NOT NULL").First(&mahasantri, id).Error; err != nil {
+		return utils.ResponseError(c, fiber.StatusNotFound, "Archived mahasantri not found", nil)
+	}
+
+	if c.Query("confirm") != mahasantri.NIM {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Confirmation does not match, send the mahasantri NIM in the confirm parameter", nil)
+	}
+
+	if err := s.DB.Unscoped().Delete(&mahasantri).Error; err != nil {
+		logrus.WithError(err).Error("Failed to purge mahasantri")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to purge mahasantri", err.Error())
+	}
+	s.deleteFotoFiles(mahasantri.Foto, mahasantri.FotoThumbnail)
+
+	logrus.WithFields(logrus.Fields{
+		"mahasantri_id": mahasantri.ID,
+		"nim":           mahasantri.NIM,
+	}).Warn("Mahasantri purged permanently")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Mahasantri purged successfully", nil)
+}
+
+// GetArsipMentors - Mengambil daftar mentor yang diarsipkan
+// @Summary Daftar arsip mentor
+// @Description Mengambil mentor yang sudah dihapus (diarsipkan).
+// @Tags Mentor
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Limit per page" default(10)
+// @Success 200 {object} utils.Response "Archived mentors retrieved successfully"
+// @Failure 500 {object} utils.Response "Failed to fetch archived mentors"
+// @Security BearerAuth
+// @Router /api/v1/mentors/arsip [get]
+func (s *MentorService) GetArsipMentors(c *fiber.Ctx) error {
+	page, limit, offset := paginationParams(c)
+
+	query := s.DB.Unscoped().Model(&models.Mentor{}).Where("deleted_at IS NOT NULL")
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logrus.WithError(err).Error("Failed to count archived mentors")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to fetch archived mentors", err.Error())
+	}
+
+	var mentors []models.Mentor
+	if err := query.Order("deleted_at DESC").Limit(limit).Offset(offset).Find(&mentors).Error; err != nil {
+		logrus.WithError(err).Error("Failed to fetch archived mentors")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to fetch archived mentors", err.Error())
+	}
+
+	response := make([]dto.ArsipMentorResponse, len(mentors))
+	for i, m := range mentors {
+		response[i] = dto.ArsipMentorResponse{
+			ID:             m.ID,
+			Nama:           m.Nama,
+			Email:          m.Email,
+			Gender:         m.Gender,
+			DiarsipkanPada: m.DeletedAt.Time,
+		}
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Archived mentors retrieved successfully", fiber.Map{
+		"pagination": fiber.Map{
+			"current_page": page,
+			"total_data":   total,
+			"total_pages":  int(math.Ceil(float64(total) / float64(limit))),
+		},
+		"mentors": response,
+	})
+}
+
+// RestoreMentor - Memulihkan mentor dari arsip
+// @Summary Memulihkan mentor dari arsip
+// @Description Memulihkan mentor yang diarsipkan sehingga dapat login dan membimbing kembali.
+// @Tags Mentor
+// @Produce json
+// @Param id path int true "ID Mentor"
+// @Success 200 {object} utils.Response "Mentor restored successfully"
+// @Failure 404 {object} utils.Response "Archived mentor not found"
+// @Security BearerAuth
+// @Router /api/v1/mentors/{id}/restore [put]
+func (s *MentorService) RestoreMentor(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var mentor models.Mentor
+	if err := s.DB.Unscoped().Where("deleted_at IS NOT NULL").First(&mentor, id).Error; err != nil {
+		return utils.ResponseError(c, fiber.StatusNotFound, "Archived mentor not found", nil)
+	}
+
+	if err := s.DB.Unscoped().Model(&mentor).Update("deleted_at", nil).Error; err != nil {
+		logrus.WithError(err).Error("Failed to restore mentor")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to restore mentor", err.Error())
+	}
+
+	logrus.WithField("mentor_id", mentor.ID).Info("Mentor restored successfully")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Mentor restored successfully", dto.MentorResponse{
+		ID:     mentor.ID,
+		Nama:   mentor.Nama,
+		Email:  mentor.Email,
+		Gender: mentor.Gender,
+	})
+}
+
+// PurgeMentor - Menghapus permanen mentor yang diarsipkan
+// @Summary Menghapus permanen mentor
+// @Description Menghapus permanen mentor yang sudah diarsipkan. Mentor yang masih dirujuk oleh mahasantri (termasuk yang diarsipkan), absensi, hafalan, surat peringatan, qadha, halaqah, jadwal personal, atau riwayat bimbingan tidak dapat dihapus permanen. Membutuhkan header X-Admin-Key dan parameter confirm berisi email mentor.
+// @Tags Mentor
+// @Produce json
+// @Param id path int true "ID Mentor"
+// @Param confirm query string true "Email mentor sebagai konfirmasi"
+// @Param X-Admin-Key header string true "Admin API key"
+// @Success 200 {object} utils.Response "Mentor purged successfully"
+// @Failure 400 {object} utils.Response "Confirmation does not match"
+// @Failure 403 {object} utils.Response "Invalid admin key"
+// @Failure 404 {object} utils.Response "Archived mentor not found"
+// @Failure 409 {object} utils.Response "Mentor still has related data"
+// @Router /api/v1/mentors/{id}/purge [delete]
+func (s *MentorService) PurgeMentor(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var mentor models.Mentor
+	if err := s.DB.Unscoped().Where("deleted_at IS NOT NULL").First(&mentor, id).Error; err != nil {
+		return utils.ResponseError(c, fiber.StatusNotFound, "Archived mentor not found", nil)
+	}
+
+	if c.Query("confirm") != mentor.Email {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Confirmation does not match, send the mentor email in the confirm parameter", nil)
+	}
+
+	// Foreign key mentor_id memakai ON DELETE CASCADE, sehingga purge ditolak selama masih ada data yang merujuk mentor
+	// agar mahasantri beserta riwayat absensi, hafalan, dan bimbingannya tidak ikut terhapus
+	referensi := []struct {
+		nama  string
+		model interface{}
+	}{
+		{"mahasantri", &models.Mahasantri{}},
+		{"absensi", &models.Absensi{}},
+		{"hafalan", &models.Hafalan{}},
+		{"surat_peringatan", &models.SuratPeringatan{}},
+		{"qadha", &models.Qadha{}},
+		{"halaqah", &models.HalaqahMentor{}},
+		{"jadwal_personal", &models.JadwalPersonal{}},
+		{"riwayat_mentor", &models.MentorAssignment{}},
+	}
+	masihDirujuk := fiber.Map{}
+	for _, r := range referensi {
+		var count int64
+		if err := s.DB.Unscoped().Model(r.model).Where("mentor_id = ?", mentor.ID).Count(&count).Error; err != nil {
+			logrus.WithError(err).WithField("tabel", r.nama).Error("Failed to count mentor references")
+			return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to purge mentor", err.Error())
+		}
+		if count > 0 {
+			masihDirujuk[r.nama] = count
+		}
+	}
+	if len(masihDirujuk) > 0 {
+		return utils.ResponseError(c, fiber.StatusConflict, "Mentor still has related data, transfer or purge it first", masihDirujuk)
+	}
+
+	if err := s.DB.Unscoped().Delete(&mentor).Error; err != nil {
+		logrus.WithError(err).Error("Failed to purge mentor")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to purge mentor", err.Error())
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"mentor_id": mentor.ID,
+		"email":     mentor.Email,
+	}).Warn("Mentor purged permanently")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Mentor purged successfully", nil)
+}
@@ -1,6 +1,9 @@
 package services
 
 import (
+	"errors"
+	"strings"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/habbazettt/mahad-service-go/dto"
 	"github.com/habbazettt/mahad-service-go/models"
@@ -12,6 +15,7 @@ import (
 const (
 	RoleMentor     = "mentor"
 	RoleMahasantri = "mahasantri"
+	RoleWali       = "wali"
 )
 
 // AuthService menangani logika autentikasi pengguna
@@ -21,7 +25,7 @@ type AuthService struct {
 
 // RegisterMahasantri godoc
 // @Summary Register Mahasantri
-// @Description Mendaftarkan akun Mahasantri baru
+// @Description Mendaftarkan akun Mahasantri baru. Gender mentor harus sama dengan gender mahasantri; jika mentor_id dikosongkan, mahasantri ditempatkan otomatis ke mentor dengan beban paling ringan. Batas MENTOR_MAX_BIMBINGAN berlaku untuk keduanya.
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -29,6 +33,7 @@ type AuthService struct {
 // @Success 201 {object} utils.SuccessResponseSwagger
 // @Failure 400 {object} utils.ErrorResponseSwagger
 // @Failure 409 {object} utils.ErrorResponseSwagger
+// @Failure 422 {object} utils.ErrorResponseSwagger
 // @Router /api/v1/auth/register/mahasantri [post]
 func (s *AuthService) RegisterMahasantri(c *fiber.Ctx) error {
 	var req dto.RegisterMahasantriRequest
@@ -38,16 +43,42 @@ func (s *AuthService) RegisterMahasantri(c *fiber.Ctx) error {
 		return utils.ResponseError(c, fiber.StatusBadRequest, "Invalid request body", err.Error())
 	}
 
+	req.Gender = strings.ToUpper(req.Gender)
+	if req.Gender != "L" && req.Gender != "P" {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Gender must be L or P", nil)
+	}
+
+	if req.MentorID == 0 {
+		// Tanpa mentor_id, mahasantri ditempatkan ke mentor dengan beban paling ringan
+		planner, err := loadPenempatanPlanner(s.DB, req.PertimbangkanHafalan)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to load mentor load")
+			return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to register mahasantri", err.Error())
+		}
+		mentor, err := planner.tempatkan(req.Gender, req.TotalHafalan, 0)
+		if err != nil {
+			return utils.ResponseError(c, fiber.StatusUnprocessableEntity, err.Error(), nil)
+		}
+		req.MentorID = mentor.ID
+	}
+
 	// Cek apakah mentor ID valid
 	var mentor models.Mentor
 	if err := s.DB.First(&mentor, req.MentorID).Error; err != nil {
 		logrus.Warn("Invalid mentor ID: ", req.MentorID)
 		return utils.ResponseError(c, fiber.StatusBadRequest, "Invalid mentor ID", nil)
 	}
+	if mentor.Gender != req.Gender {
+		return utils.ResponseError(c, fiber.StatusBadRequest, errGenderMentorTidakSesuai.Error(), nil)
+	}
 
 	// Cek apakah NIM sudah terdaftar
 	var existingMahasantri models.Mahasantri
-	if err := s.DB.Where("nim = ?", req.NIM).First(&existingMahasantri).Error; err == nil {
+	if err := s.DB.Unscoped().Where("nim = ?", req.NIM).First(&existingMahasantri).Error; err == nil {
+		if existingMahasantri.DeletedAt.Valid {
+			logrus.Warn("NIM registered on archived mahasantri: ", req.NIM)
+			return utils.ResponseError(c, fiber.StatusConflict, "NIM belongs to an archived mahasantri, restore it instead", fiber.Map{"mahasantri_id": existingMahasantri.ID})
+		}
 		logrus.Warn("NIM already registered: ", req.NIM)
 		return utils.ResponseError(c, fiber.StatusConflict, "NIM already registered", nil)
 	}
@@ -69,7 +100,23 @@ func (s *AuthService) RegisterMahasantri(c *fiber.Ctx) error {
 		MentorID: req.MentorID,
 	}
 
-	if err := s.DB.Create(&mahasantri).Error; err != nil {
+	// Batas bimbingan berlaku juga untuk mentor_id yang dipilih langsung, dihitung dengan baris mentor terkunci
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := kunciMentor(tx, mahasantri.MentorID); err != nil {
+			return err
+		}
+		if err := tx.Create(&mahasantri).Error; err != nil {
+			return err
+		}
+		if err := createInitialMentorAssignment(tx, mahasantri); err != nil {
+			return err
+		}
+		return cekKapasitasMentor(tx, mahasantri.MentorID)
+	})
+	if errors.Is(err, errKapasitasMentorPenuh) {
+		return utils.ResponseError(c, fiber.StatusUnprocessableEntity, errKapasitasMentorPenuh.Error(), nil)
+	}
+	if err != nil {
 		logrus.WithError(err).Error("Failed to register mahasantri")
 		return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to register mahasantri", err.Error())
 	}
@@ -113,7 +160,11 @@ func (s *AuthService) RegisterMentor(c *fiber.Ctx) error {
 	}
 
 	var existingMentor models.Mentor
-	if err := s.DB.Where("email = ?", req.Email).First(&existingMentor).Error; err == nil {
+	if err := s.DB.Unscoped().Where("email = ?", req.Email).First(&existingMentor).Error; err == nil {
+		if existingMentor.DeletedAt.Valid {
+			logrus.Warn("Email registered on archived mentor: ", req.Email)
+			return utils.ResponseError(c, fiber.StatusConflict, "Email belongs to an archived mentor, restore it instead", fiber.Map{"mentor_id": existingMentor.ID})
+		}
 		logrus.Warn("Email already registered: ", req.Email)
 		return utils.ResponseError(c, fiber.StatusConflict, "Email already registered", nil)
 	}
@@ -263,6 +314,63 @@ func (s *AuthService) LoginMahasantri(c *fiber.Ctx) error {
 	})
 }
 
+// LoginWali godoc
+// @Summary Login Wali
+// @Description Melakukan login untuk wali (orang tua) dengan nomor HP dan password
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body dto.LoginWaliRequest true "Data login Wali"
+// @Success 200 {object} utils.SuccessResponseSwagger
+// @Failure 400 {object} utils.ErrorResponseSwagger
+// @Failure 401 {object} utils.ErrorResponseSwagger
+// @Router /api/v1/auth/login/wali [post]
+func (s *AuthService) LoginWali(c *fiber.Ctx) error {
+	var req dto.LoginWaliRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		logrus.WithError(err).Error("Failed to parse request body")
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+	req.NoHP = strings.TrimSpace(req.NoHP)
+
+	var wali models.Wali
+	if err := s.DB.Where("no_hp = ?", req.NoHP).First(&wali).Error; err != nil {
+		logrus.Warn("Invalid phone number or password: ", req.NoHP)
+		return utils.ResponseError(c, fiber.StatusUnauthorized, "Invalid phone number or password", nil)
+	}
+
+	if !utils.ComparePassword(wali.Password, req.Password) {
+		logrus.Warn("Invalid password for phone number: ", req.NoHP)
+		return utils.ResponseError(c, fiber.StatusUnauthorized, "Invalid phone number or password", nil)
+	}
+
+	anak, err := findAnakWali(s.DB, wali.ID)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to fetch linked mahasantri")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to fetch linked mahasantri", err.Error())
+	}
+
+	token, err := utils.GenerateToken(wali.ID, RoleWali)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to generate token")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to generate token", err.Error())
+	}
+
+	logrus.WithField("user_id", wali.ID).Info("Wali logged in successfully")
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Login successful", dto.AuthResponse{
+		Token: token,
+		User: dto.UserWaliResponse{
+			ID:         wali.ID,
+			Nama:       wali.Nama,
+			NoHP:       wali.NoHP,
+			UserType:   RoleWali,
+			Mahasantri: anak,
+		},
+	})
+}
+
 // ForgotPassword godoc
 // @Summary Forgot Password
 // @Description Endpoint untuk mengupdate password untuk Mahasantri atau Mentor berdasarkan NIM atau Email
@@ -414,6 +522,27 @@ func (s *AuthService) GetCurrentUser(c *fiber.Ctx) error {
 			IsDataMurojaahFilled: mahasantri.IsDataMurojaahFilled,
 		}
 
+	case RoleWali:
+		var wali models.Wali
+		if err := s.DB.First(&wali, userClaims.ID).Error; err != nil {
+			logrus.Warn("Wali not found: ", userClaims.ID)
+			return utils.ResponseError(c, fiber.StatusNotFound, "User not found", nil)
+		}
+
+		anak, err := findAnakWali(s.DB, wali.ID)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to fetch linked mahasantri")
+			return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to fetch linked mahasantri", err.Error())
+		}
+
+		response = dto.UserWaliResponse{
+			ID:         wali.ID,
+			Nama:       wali.Nama,
+			NoHP:       wali.NoHP,
+			UserType:   RoleWali,
+			Mahasantri: anak,
+		}
+
 	default:
 		logrus.Warn("Unauthorized access: Invalid role")
 		return utils.ResponseError(c, fiber.StatusUnauthorized, "Invalid user role", nil)
@@ -0,0 +1,450 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/mahad-service-go/dto"
+	"github.com/habbazettt/mahad-service-go/models"
+	"github.com/habbazettt/mahad-service-go/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// zSkor95 adalah nilai z untuk interval kepercayaan 95%
+const zSkor95 = 1.96
+
+var (
+	errEksperimenBertabrakan = errors.New("periode bertabrakan dengan eksperimen lain yang belum dihentikan")
+	errNamaEksperimenDipakai = errors.New("nama eksperimen sudah dipakai")
+)
+
+var daftarEngineRekomendasi = []string{EngineQLearning, EngineHistoris, EngineRuleBased, EngineThompson}
+
+type EksperimenRekomendasiService interface {
+	CreateEksperimen(c *fiber.Ctx) error
+	GetAllEksperimen(c *fiber.Ctx) error
+	HentikanEksperimen(c *fiber.Ctx) error
+	GetHasilEksperimen(c *fiber.Ctx) error
+}
+
+type eksperimenRekomendasiService struct {
+	DB *gorm.DB
+}
+
+func NewEksperimenRekomendasiService(db *gorm.DB) EksperimenRekomendasiService {
+	return &eksperimenRekomendasiService{DB: db}
+}
+
+func hariIni() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func eksperimenAktif(e models.EksperimenRekomendasi, tanggal time.Time) bool {
+	if e.DihentikanPada != nil || tanggal.Before(e.TanggalMulai) {
+		return false
+	}
+	return e.TanggalSelesai == nil || !tanggal.After(*e.TanggalSelesai)
+}
+
+func toEksperimenRekomendasiResponse(e models.EksperimenRekomendasi) dto.EksperimenRekomendasiResponse {
+	res := dto.EksperimenRekomendasiResponse{
+		ID:             e.ID,
+		Nama:           e.Nama,
+		Deskripsi:      e.Deskripsi,
+		TanggalMulai:   e.TanggalMulai.Format("02-01-2006"),
+		DihentikanPada: e.DihentikanPada,
+		Aktif:          eksperimenAktif(e, hariIni()),
+		Varian:         make([]dto.VarianEksperimenResponse, len(e.Varian)),
+		CreatedAt:      e.CreatedAt,
+	}
+	if e.TanggalSelesai != nil {
+		res.TanggalSelesai = e.TanggalSelesai.Format("02-01-2006")
+	}
+	for i, v := range e.Varian {
+		res.Varian[i] = dto.VarianEksperimenResponse{ID: v.ID, Nama: v.Nama, Engine: v.Engine, Bobot: v.Bobot}
+	}
+	return res
+}
+
+// bucketPengguna memetakan pengguna ke angka 0-99 secara deterministik. ID eksperimen ikut di-hash agar pembagian
+// antar eksperimen tidak selalu sama, dan role ikut di-hash karena ID mentor dan mahasantri bisa bertabrakan.
+func bucketPengguna(eksperimenID uint, role string, userID uint) int {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%d:%s:%d", eksperimenID, role, userID)
+	return int(h.Sum32() % 100)
+}
+
+// pilihVarian memilih varian berdasarkan bucket pengguna dan bobot kumulatif varian (diurutkan berdasarkan ID)
+func pilihVarian(e models.EksperimenRekomendasi, role string, userID uint) *models.VarianEksperimen {
+	bucket := bucketPengguna(e.ID, role, userID)
+	kumulatif := 0
+	for i := range e.Varian {
+		kumulatif += e.Varian[i].Bobot
+		if bucket < kumulatif {
+			return &e.Varian[i]
+		}
+	}
+	return nil
+}
+
+// varianUntukPengguna mengembalikan eksperimen yang sedang berjalan beserta varian pengguna. Nil jika tidak ada eksperimen aktif.
+func varianUntukPengguna(db *gorm.DB, role string, userID uint) (*models.EksperimenRekomendasi, *models.VarianEksperimen, error) {
+	today := hariIni()
+
+	var eksperimen models.EksperimenRekomendasi
+	err := db.Preload("Varian", func(tx *gorm.DB) *gorm.DB { return tx.Order("id ASC") }).
+		Where("dihentikan_pada IS NULL AND tanggal_mulai <= ?", today).
+		Where("tanggal_selesai IS NULL OR tanggal_selesai >= ?", today).
+		Order("tanggal_mulai DESC, id DESC").
+		First(&eksperimen).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+
+	varian := pilihVarian(eksperimen, role, userID)
+	if varian == nil {
+		return nil, nil, nil
+	}
+	return &eksperimen, varian, nil
+}
+
+func validasiVarianEksperimen(varian []dto.VarianEksperimenRequest) error {
+	if len(varian) < 2 {
+		return errors.New("eksperimen membutuhkan minimal 2 varian")
+	}
+
+	namaDipakai := make(map[string]bool)
+	totalBobot := 0
+	for _, v := range varian {
+		if strings.TrimSpace(v.Nama) == "" {
+			return errors.New("nama varian wajib diisi")
+		}
+		if namaDipakai[v.Nama] {
+			return fmt.Errorf("nama varian %q duplikat", v.Nama)
+		}
+		namaDipakai[v.Nama] = true
+
+		engineValid := false
+		for _, e := range daftarEngineRekomendasi {
+			if v.Engine == e {
+				engineValid = true
+				break
+			}
+		}
+		if !engineValid {
+			return fmt.Errorf("engine %q tidak dikenal, gunakan salah satu dari: %s", v.Engine, strings.Join(daftarEngineRekomendasi, ", "))
+		}
+		if v.Bobot <= 0 {
+			return fmt.Errorf("bobot varian %q harus lebih dari 0", v.Nama)
+		}
+		totalBobot += v.Bobot
+	}
+	if totalBobot != 100 {
+		return fmt.Errorf("total bobot varian harus 100, saat ini %d", totalBobot)
+	}
+	return nil
+}
+
+// CreateEksperimen - Membuat eksperimen A/B rekomendasi
+// @Summary Membuat eksperimen rekomendasi
+// @Description Membuat eksperimen A/B yang membandingkan beberapa engine rekomendasi. Total bobot varian harus 100, dan periode eksperimen tidak boleh bertabrakan dengan eksperimen lain yang belum dihentikan.
+// @Tags Eksperimen Rekomendasi
+// @Accept json
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Param body body dto.CreateEksperimenRekomendasiRequest true "Definisi eksperimen"
+// @Success 201 {object} utils.Response{data=dto.EksperimenRekomendasiResponse} "Eksperimen berhasil dibuat"
+// @Failure 400 {object} utils.Response "Request tidak valid"
+// @Failure 409 {object} utils.Response "Nama eksperimen sudah dipakai atau periode bertabrakan"
+// @Failure 500 {object} utils.Response "Gagal membuat eksperimen"
+// @Router /api/v1/eksperimen-rekomendasi [post]
+func (s *eksperimenRekomendasiService) CreateEksperimen(c *fiber.Ctx) error {
+	log := logrus.WithField("handler", "CreateEksperimen")
+
+	var req dto.CreateEksperimenRekomendasiRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Request body tidak valid", err.Error())
+	}
+	req.Nama = strings.TrimSpace(req.Nama)
+	if req.Nama == "" {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Nama eksperimen wajib diisi", nil)
+	}
+	if err := validasiVarianEksperimen(req.Varian); err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, err.Error(), nil)
+	}
+
+	mulai, err := time.Parse("02-01-2006", req.TanggalMulai)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Format tanggal_mulai tidak valid, gunakan DD-MM-YYYY", nil)
+	}
+	var selesai *time.Time
+	if req.TanggalSelesai != "" {
+		t, err := time.Parse("02-01-2006", req.TanggalSelesai)
+		if err != nil {
+			return utils.ResponseError(c, fiber.StatusBadRequest, "Format tanggal_selesai tidak valid, gunakan DD-MM-YYYY", nil)
+		}
+		if t.Before(mulai) {
+			return utils.ResponseError(c, fiber.StatusBadRequest, "tanggal_selesai tidak boleh sebelum tanggal_mulai", nil)
+		}
+		selesai = &t
+	}
+
+	eksperimen := models.EksperimenRekomendasi{
+		Nama:           req.Nama,
+		Deskripsi:      req.Deskripsi,
+		TanggalMulai:   mulai,
+		TanggalSelesai: selesai,
+		Varian:         make([]models.VarianEksperimen, len(req.Varian)),
+	}
+	for i, v := range req.Varian {
+		eksperimen.Varian[i] = models.VarianEksperimen{Nama: strings.TrimSpace(v.Nama), Engine: v.Engine, Bobot: v.Bobot}
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		// Kunci tabel agar dua permintaan bersamaan tidak sama-sama lolos pemeriksaan tumpang tindih dan nama
+		if err := tx.Exec("LOCK TABLE eksperimen_rekomendasis IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+			return err
+		}
+
+		// Hanya satu eksperimen yang boleh berjalan pada satu waktu agar setiap paparan jelas milik eksperimen mana
+		overlap := tx.Model(&models.EksperimenRekomendasi{}).
+			Where("dihentikan_pada IS NULL").
+			Where("tanggal_selesai IS NULL OR tanggal_selesai >= ?", mulai)
+		if selesai != nil {
+			overlap = overlap.Where("tanggal_mulai <= ?", *selesai)
+		}
+		var jumlah int64
+		if err := overlap.Count(&jumlah).Error; err != nil {
+			return err
+		}
+		if jumlah > 0 {
+			return errEksperimenBertabrakan
+		}
+
+		if err := tx.Model(&models.EksperimenRekomendasi{}).Where("nama = ?", eksperimen.Nama).Count(&jumlah).Error; err != nil {
+			return err
+		}
+		if jumlah > 0 {
+			return errNamaEksperimenDipakai
+		}
+		if err := tx.Create(&eksperimen).Error; err != nil {
+			if utils.IsUniqueViolation(err) {
+				return errNamaEksperimenDipakai
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errEksperimenBertabrakan) || errors.Is(err, errNamaEksperimenDipakai) {
+			return utils.ResponseError(c, fiber.StatusConflict, err.Error(), nil)
+		}
+		log.WithError(err).Error("Gagal membuat eksperimen rekomendasi")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal membuat eksperimen", err.Error())
+	}
+
+	log.WithFields(logrus.Fields{"eksperimenID": eksperimen.ID, "nama": eksperimen.Nama}).Info("Eksperimen rekomendasi berhasil dibuat")
+	return utils.SuccessResponse(c, fiber.StatusCreated, "Eksperimen berhasil dibuat", toEksperimenRekomendasiResponse(eksperimen))
+}
+
+// GetAllEksperimen - Daftar eksperimen rekomendasi
+// @Summary Daftar eksperimen rekomendasi
+// @Tags Eksperimen Rekomendasi
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Success 200 {object} utils.Response{data=[]dto.EksperimenRekomendasiResponse} "Daftar eksperimen berhasil diambil"
+// @Failure 500 {object} utils.Response "Gagal mengambil daftar eksperimen"
+// @Router /api/v1/eksperimen-rekomendasi [get]
+func (s *eksperimenRekomendasiService) GetAllEksperimen(c *fiber.Ctx) error {
+	var daftar []models.EksperimenRekomendasi
+	if err := s.DB.Preload("Varian", func(tx *gorm.DB) *gorm.DB { return tx.Order("id ASC") }).
+		Order("tanggal_mulai DESC, id DESC").
+		Find(&daftar).Error; err != nil {
+		logrus.WithError(err).Error("Gagal mengambil daftar eksperimen rekomendasi")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil daftar eksperimen", err.Error())
+	}
+
+	res := make([]dto.EksperimenRekomendasiResponse, len(daftar))
+	for i, e := range daftar {
+		res[i] = toEksperimenRekomendasiResponse(e)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, "Daftar eksperimen berhasil diambil", res)
+}
+
+// HentikanEksperimen - Menghentikan eksperimen lebih awal
+// @Summary Menghentikan eksperimen rekomendasi
+// @Description Setelah dihentikan, pengguna kembali dilayani engine default. Data paparan tetap tersimpan untuk hasil eksperimen.
+// @Tags Eksperimen Rekomendasi
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Param id path int true "ID eksperimen"
+// @Success 200 {object} utils.Response{data=dto.EksperimenRekomendasiResponse} "Eksperimen berhasil dihentikan"
+// @Failure 404 {object} utils.Response "Eksperimen tidak ditemukan"
+// @Failure 409 {object} utils.Response "Eksperimen sudah dihentikan"
+// @Router /api/v1/eksperimen-rekomendasi/{id}/hentikan [put]
+func (s *eksperimenRekomendasiService) HentikanEksperimen(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "ID eksperimen tidak valid", nil)
+	}
+
+	var eksperimen models.EksperimenRekomendasi
+	if err := s.DB.Preload("Varian").First(&eksperimen, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.ResponseError(c, fiber.StatusNotFound, "Eksperimen tidak ditemukan", nil)
+		}
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil eksperimen", err.Error())
+	}
+	if eksperimen.DihentikanPada != nil {
+		return utils.ResponseError(c, fiber.StatusConflict, "Eksperimen sudah dihentikan", nil)
+	}
+
+	now := time.Now()
+	if err := s.DB.Model(&eksperimen).Update("dihentikan_pada", now).Error; err != nil {
+		logrus.WithError(err).WithField("eksperimenID", id).Error("Gagal menghentikan eksperimen")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal menghentikan eksperimen", err.Error())
+	}
+	eksperimen.DihentikanPada = &now
+
+	logrus.WithField("eksperimenID", id).Info("Eksperimen rekomendasi dihentikan")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Eksperimen berhasil dihentikan", toEksperimenRekomendasiResponse(eksperimen))
+}
+
+// GetHasilEksperimen - Membandingkan hasil tiap varian eksperimen
+// @Summary Hasil eksperimen rekomendasi
+// @Description Membandingkan tingkat penerimaan, penyelesaian sesi murojaah, dan efektivitas tiap varian. Proporsi memakai interval kepercayaan Wilson 95%, rata-rata efektivitas memakai interval normal 95%.
+// @Description Setiap metrik dihitung per pengguna: paparan adalah jumlah pengguna yang pernah dilayani varian (termasuk yang tidak mendapat jadwal), penerimaan adalah pengguna yang pernah mengadopsi rekomendasi, penyelesaian adalah pengguna dengan minimal satu sesi selesai dari pengguna yang membuat sesi, dan efektivitas memakai rata-rata skor tiap pengguna.
+// @Tags Eksperimen Rekomendasi
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Param id path int true "ID eksperimen"
+// @Success 200 {object} utils.Response{data=dto.HasilEksperimenRekomendasiResponse} "Hasil eksperimen berhasil diambil"
+// @Failure 404 {object} utils.Response "Eksperimen tidak ditemukan"
+// @Failure 500 {object} utils.Response "Gagal menghitung hasil eksperimen"
+// @Router /api/v1/eksperimen-rekomendasi/{id}/hasil [get]
+func (s *eksperimenRekomendasiService) GetHasilEksperimen(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "ID eksperimen tidak valid", nil)
+	}
+	log := logrus.WithFields(logrus.Fields{"handler": "GetHasilEksperimen", "eksperimenID": id})
+
+	var eksperimen models.EksperimenRekomendasi
+	if err := s.DB.Preload("Varian", func(tx *gorm.DB) *gorm.DB { return tx.Order("id ASC") }).First(&eksperimen, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.ResponseError(c, fiber.StatusNotFound, "Eksperimen tidak ditemukan", nil)
+		}
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil eksperimen", err.Error())
+	}
+
+	// Satu pengguna bisa meminta rekomendasi berkali-kali, sehingga setiap metrik dihitung per pengguna agar paparan
+	// berulang tidak mempersempit interval kepercayaan. Riwayat yang penggunanya sudah terhapus dihitung sendiri-sendiri.
+	perPengguna := s.DB.Table("jadwal_rekomendasis AS jr").
+		Select(`jr.varian_id,
+			COALESCE(BOOL_OR(ub.diterapkan OR COALESCE(dl.jumlah_sesi, 0) > 0), false) AS diadopsi,
+			COALESCE(SUM(dl.jumlah_sesi), 0) > 0 AS punya_sesi,
+			COALESCE(SUM(dl.sesi_selesai), 0) > 0 AS punya_sesi_selesai,
+			AVG(ub.efektivitas) AS efektivitas`).
+		Joins("LEFT JOIN umpan_balik_rekomendasis ub ON ub.jadwal_rekomendasi_id = jr.id").
+		Joins("LEFT JOIN (?) AS dl ON dl.jadwal_rekomendasi_id = jr.id", sesiPerRekomendasiQuery(s.DB)).
+		Where("jr.eksperimen_id = ?", eksperimen.ID).
+		Group(`jr.varian_id, CASE
+			WHEN jr.mahasantri_id IS NOT NULL THEN 'mahasantri:' || jr.mahasantri_id
+			WHEN jr.mentor_id IS NOT NULL THEN 'mentor:' || jr.mentor_id
+			ELSE 'rekomendasi:' || jr.id END`)
+
+	var rows []struct {
+		VarianID            uint
+		TotalPaparan        int64
+		TotalDiadopsi       int64
+		TotalSesi           int64
+		TotalSesiSelesai    int64
+		TotalDinilai        int64
+		TotalDinilaiEfektif int64
+		RataRataEfektivitas *float64
+		StddevEfektivitas   *float64
+	}
+	if err := s.DB.Table("(?) AS pp", perPengguna).
+		Select(`pp.varian_id,
+			COUNT(*) AS total_paparan,
+			COUNT(*) FILTER (WHERE pp.diadopsi) AS total_diadopsi,
+			COUNT(*) FILTER (WHERE pp.punya_sesi) AS total_sesi,
+			COUNT(*) FILTER (WHERE pp.punya_sesi_selesai) AS total_sesi_selesai,
+			COUNT(pp.efektivitas) AS total_dinilai,
+			COUNT(*) FILTER (WHERE pp.efektivitas >= 4) AS total_dinilai_efektif,
+			AVG(pp.efektivitas) AS rata_rata_efektivitas,
+			STDDEV_SAMP(pp.efektivitas) AS stddev_efektivitas`).
+		Group("pp.varian_id").
+		Scan(&rows).Error; err != nil {
+		log.WithError(err).Error("Gagal menghitung hasil eksperimen")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal menghitung hasil eksperimen", err.Error())
+	}
+
+	perVarian := make(map[uint]int, len(rows))
+	for i, row := range rows {
+		perVarian[row.VarianID] = i
+	}
+
+	hasil := dto.HasilEksperimenRekomendasiResponse{
+		Eksperimen: toEksperimenRekomendasiResponse(eksperimen),
+		Varian:     make([]dto.HasilVarianEksperimen, len(eksperimen.Varian)),
+	}
+	for i, v := range eksperimen.Varian {
+		item := dto.HasilVarianEksperimen{
+			VarianEksperimenResponse: dto.VarianEksperimenResponse{ID: v.ID, Nama: v.Nama, Engine: v.Engine, Bobot: v.Bobot},
+		}
+		if idx, ok := perVarian[v.ID]; ok {
+			row := rows[idx]
+			item.TotalPaparan = row.TotalPaparan
+			item.Penerimaan = metrikProporsi(row.TotalDiadopsi, row.TotalPaparan)
+			item.Penyelesaian = metrikProporsi(row.TotalSesiSelesai, row.TotalSesi)
+			item.DinilaiEfektif = metrikProporsi(row.TotalDinilaiEfektif, row.TotalDinilai)
+			item.Efektivitas = metrikRataRata(row.TotalDinilai, row.RataRataEfektivitas, row.StddevEfektivitas)
+		}
+		hasil.Varian[i] = item
+	}
+
+	log.WithField("jumlah_varian", len(hasil.Varian)).Info("Berhasil menghitung hasil eksperimen")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Hasil eksperimen berhasil diambil", hasil)
+}
+
+// metrikProporsi menghitung persentase dengan interval Wilson yang tetap masuk akal untuk sampel kecil
+func metrikProporsi(berhasil, total int64) dto.MetrikProporsi {
+	m := dto.MetrikProporsi{Berhasil: berhasil, Total: total}
+	if total == 0 {
+		return m
+	}
+
+	n := float64(total)
+	p := float64(berhasil) / n
+	z2 := zSkor95 * zSkor95
+	tengah := (p + z2/(2*n)) / (1 + z2/n)
+	margin := zSkor95 * math.Sqrt(p*(1-p)/n+z2/(4*n*n)) / (1 + z2/n)
+
+	m.Persentase = p * 100
+	m.BatasBawah = math.Max(0, tengah-margin) * 100
+	m.BatasAtas = math.Min(1, tengah+margin) * 100
+	return m
+}
+
+func metrikRataRata(jumlah int64, rataRata, stddev *float64) dto.MetrikRataRata {
+	m := dto.MetrikRataRata{Jumlah: jumlah, RataRata: rataRata}
+	if rataRata == nil || stddev == nil || jumlah < 2 {
+		return m
+	}
+
+	margin := zSkor95 * *stddev / math.Sqrt(float64(jumlah))
+	bawah, atas := *rataRata-margin, *rataRata+margin
+	m.BatasBawah, m.BatasAtas = &bawah, &atas
+	return m
+}
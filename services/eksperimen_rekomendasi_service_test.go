@@ -0,0 +1,86 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/habbazettt/mahad-service-go/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucketPenggunaDeterministik(t *testing.T) {
+	for userID := uint(1); userID <= 500; userID++ {
+		bucket := bucketPengguna(7, RoleMahasantri, userID)
+		assert.GreaterOrEqual(t, bucket, 0)
+		assert.Less(t, bucket, 100)
+		assert.Equal(t, bucket, bucketPengguna(7, RoleMahasantri, userID))
+	}
+}
+
+func TestBucketPenggunaBergantungEksperimenDanRole(t *testing.T) {
+	bedaEksperimen, bedaRole := 0, 0
+	for userID := uint(1); userID <= 200; userID++ {
+		if bucketPengguna(1, RoleMahasantri, userID) != bucketPengguna(2, RoleMahasantri, userID) {
+			bedaEksperimen++
+		}
+		if bucketPengguna(1, RoleMahasantri, userID) != bucketPengguna(1, RoleMentor, userID) {
+			bedaRole++
+		}
+	}
+	assert.Greater(t, bedaEksperimen, 100)
+	assert.Greater(t, bedaRole, 100)
+}
+
+func TestPilihVarian(t *testing.T) {
+	eksperimen := models.EksperimenRekomendasi{
+		ID: 3,
+		Varian: []models.VarianEksperimen{
+			{ID: 1, Nama: "kontrol", Bobot: 50},
+			{ID: 2, Nama: "thompson", Bobot: 50},
+		},
+	}
+
+	jumlah := map[string]int{}
+	for userID := uint(1); userID <= 2000; userID++ {
+		varian := pilihVarian(eksperimen, RoleMahasantri, userID)
+		if assert.NotNil(t, varian) {
+			jumlah[varian.Nama]++
+			assert.Equal(t, varian.Nama, pilihVarian(eksperimen, RoleMahasantri, userID).Nama)
+		}
+	}
+	assert.InDelta(t, 1000, jumlah["kontrol"], 150)
+	assert.InDelta(t, 1000, jumlah["thompson"], 150)
+}
+
+func TestPilihVarianBobotPenuhDanKosong(t *testing.T) {
+	penuh := models.EksperimenRekomendasi{ID: 4, Varian: []models.VarianEksperimen{
+		{ID: 1, Nama: "kosong", Bobot: 0},
+		{ID: 2, Nama: "semua", Bobot: 100},
+	}}
+	for userID := uint(1); userID <= 100; userID++ {
+		assert.Equal(t, "semua", pilihVarian(penuh, RoleMentor, userID).Nama)
+	}
+
+	assert.Nil(t, pilihVarian(models.EksperimenRekomendasi{ID: 5}, RoleMentor, 1))
+}
+
+func TestMetrikProporsi(t *testing.T) {
+	kosong := metrikProporsi(0, 0)
+	assert.Equal(t, int64(0), kosong.Total)
+	assert.Zero(t, kosong.Persentase)
+	assert.Zero(t, kosong.BatasBawah)
+	assert.Zero(t, kosong.BatasAtas)
+
+	separuh := metrikProporsi(5, 10)
+	assert.InDelta(t, 50, separuh.Persentase, 1e-9)
+	assert.InDelta(t, 23.66, separuh.BatasBawah, 0.01)
+	assert.InDelta(t, 76.34, separuh.BatasAtas, 0.01)
+
+	// Interval Wilson tetap berada di dalam 0-100 walaupun proporsi ekstrem
+	nol := metrikProporsi(0, 10)
+	assert.Zero(t, nol.BatasBawah)
+	assert.Greater(t, nol.BatasAtas, 0.0)
+
+	semua := metrikProporsi(10, 10)
+	assert.InDelta(t, 100, semua.BatasAtas, 1e-9)
+	assert.Less(t, semua.BatasBawah, 100.0)
+}
@@ -50,6 +50,9 @@ func (s *HafalanService) CreateHafalan(c *fiber.Ctx) error {
 		}).Warn("Mahasantri not found")
 		return utils.ResponseError(c, fiber.StatusNotFound, "Mahasantri not found", nil)
 	}
+	if mahasantri.Status == models.StatusMahasantriLulus {
+		return responseMahasantriFrozen(c, errMahasantriAlumni)
+	}
 
 	// Simpan Hafalan
 	hafalan := models.Hafalan{
@@ -71,6 +74,7 @@ func (s *HafalanService) CreateHafalan(c *fiber.Ctx) error {
 		}).Error("Failed to create hafalan")
 		return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to create hafalan", err.Error())
 	}
+	utils.SetoranCreatedTotal.Inc()
 
 	logrus.WithFields(logrus.Fields{
 		"hafalan_id": hafalan.ID,
@@ -610,6 +614,9 @@ func (s *HafalanService) UpdateHafalan(c *fiber.Ctx) error {
 		logrus.WithField("hafalan_id", id).Warn("Hafalan not found")
 		return utils.ResponseError(c, fiber.StatusNotFound, "Hafalan not found", nil)
 	}
+	if err := ensureMahasantriWritable(s.DB, hafalan.MahasantriID); err != nil {
+		return responseMahasantriFrozen(c, err)
+	}
 
 	var req dto.UpdateHafalanRequest
 	if err := c.BodyParser(&req); err != nil {
@@ -683,6 +690,9 @@ func (s *HafalanService) DeleteHafalan(c *fiber.Ctx) error {
 		logrus.WithField("hafalan_id", id).Warn("Hafalan not found")
 		return utils.ResponseError(c, fiber.StatusNotFound, "Hafalan not found", nil)
 	}
+	if err := ensureMahasantriWritable(s.DB, hafalan.MahasantriID); err != nil {
+		return responseMahasantriFrozen(c, err)
+	}
 
 	if err := s.DB.Delete(&hafalan).Error; err != nil {
 		logrus.WithError(err).WithField("hafalan_id", id).Error("Failed to delete hafalan")
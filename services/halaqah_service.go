@@ -0,0 +1,678 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/mahad-service-go/dto"
+	"github.com/habbazettt/mahad-service-go/models"
+	"github.com/habbazettt/mahad-service-go/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type HalaqahService interface {
+	CreateHalaqah(c *fiber.Ctx) error
+	GetAllHalaqah(c *fiber.Ctx) error
+	GetHalaqahByID(c *fiber.Ctx) error
+	UpdateHalaqah(c *fiber.Ctx) error
+	DeleteHalaqah(c *fiber.Ctx) error
+	AddMentorHalaqah(c *fiber.Ctx) error
+	RemoveMentorHalaqah(c *fiber.Ctx) error
+	AddAnggotaHalaqah(c *fiber.Ctx) error
+	RemoveAnggotaHalaqah(c *fiber.Ctx) error
+	GetRiwayatAnggotaHalaqah(c *fiber.Ctx) error
+}
+
+type halaqahService struct {
+	DB *gorm.DB
+}
+
+func NewHalaqahService(db *gorm.DB) HalaqahService {
+	return &halaqahService{DB: db}
+}
+
+// isMentorHalaqah memeriksa apakah mentor termasuk pengajar halaqah
+func isMentorHalaqah(db *gorm.DB, halaqahID, mentorID uint) (bool, error) {
+	var count int64
+	err := db.Model(&models.HalaqahMentor{}).
+		Where("halaqah_id = ? AND mentor_id = ?", halaqahID, mentorID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// peranMentorHalaqah mengembalikan peran mentor pada halaqah, string kosong jika bukan pengajar
+func peranMentorHalaqah(halaqah models.Halaqah, mentorID uint) string {
+	for _, m := range halaqah.Mentors {
+		if m.MentorID == mentorID {
+			return m.Peran
+		}
+	}
+	return ""
+}
+
+// activeAnggotaHalaqahIDs mengambil ID mahasantri yang masih aktif di halaqah
+func activeAnggotaHalaqahIDs(db *gorm.DB, halaqahID uint) ([]uint, error) {
+	var ids []uint
+	err := db.Model(&models.HalaqahAnggota{}).
+		Where("halaqah_id = ? AND tanggal_keluar IS NULL", halaqahID).
+		Pluck("mahasantri_id", &ids).Error
+	return ids, err
+}
+
+// scopeHalaqah membaca query halaqah_id dan mengembalikan anggota aktif halaqah tersebut.
+// scoped bernilai false jika halaqah_id tidak dikirim, sehingga pemanggil tetap memakai scope mentor_id.
+func scopeHalaqah(c *fiber.Ctx, db *gorm.DB, mentorID uint) (ids []uint, scoped bool, err error) {
+	raw := c.Query("halaqah_id")
+	if raw == "" {
+		return nil, false, nil
+	}
+
+	halaqahID, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return nil, true, fiber.NewError(fiber.StatusBadRequest, "halaqah_id tidak valid")
+	}
+
+	ok, err := isMentorHalaqah(db, uint(halaqahID), mentorID)
+	if err != nil {
+		return nil, true, err
+	}
+	if !ok {
+		return nil, true, fiber.NewError(fiber.StatusNotFound, "Halaqah tidak ditemukan atau Anda bukan pengajar halaqah ini")
+	}
+
+	ids, err = activeAnggotaHalaqahIDs(db, uint(halaqahID))
+	return ids, true, err
+}
+
+// responseScopeHalaqahError mengubah error dari scopeHalaqah menjadi response
+func responseScopeHalaqahError(c *fiber.Ctx, err error) error {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return utils.ResponseError(c, fiberErr.Code, fiberErr.Message, nil)
+	}
+	logrus.WithError(err).Error("Gagal memproses scope halaqah")
+	return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal memproses scope halaqah", err.Error())
+}
+
+func toHalaqahResponse(h models.Halaqah, withAnggota bool) dto.HalaqahResponse {
+	response := dto.HalaqahResponse{
+		ID:      h.ID,
+		Nama:    h.Nama,
+		Jadwal:  h.Jadwal,
+		Gender:  h.Gender,
+		Ruangan: h.Ruangan,
+		Mentors: make([]dto.HalaqahMentorResponse, len(h.Mentors)),
+	}
+
+	for i, m := range h.Mentors {
+		response.Mentors[i] = dto.HalaqahMentorResponse{
+			MentorID: m.MentorID,
+			Nama:     m.Mentor.Nama,
+			Peran:    m.Peran,
+		}
+	}
+
+	for _, a := range h.Anggota {
+		if a.TanggalKeluar != nil {
+			continue
+		}
+		response.JumlahAnggota++
+		if withAnggota {
+			response.Anggota = append(response.Anggota, toHalaqahAnggotaResponse(a))
+		}
+	}
+
+	return response
+}
+
+func toHalaqahAnggotaResponse(a models.HalaqahAnggota) dto.HalaqahAnggotaResponse {
+	response := dto.HalaqahAnggotaResponse{
+		MahasantriID: a.MahasantriID,
+		Nama:         a.Mahasantri.Nama,
+		NIM:          a.Mahasantri.NIM,
+		TanggalMasuk: a.TanggalMasuk.Format("02-01-2006"),
+	}
+	if a.TanggalKeluar != nil {
+		response.TanggalKeluar = a.TanggalKeluar.Format("02-01-2006")
+	}
+	return response
+}
+
+// findHalaqahForMentor mengambil halaqah beserta pengajar dan anggota jika mentor termasuk pengajarnya
+func (s *halaqahService) findHalaqahForMentor(id int, mentorID uint) (models.Halaqah, error) {
+	var halaqah models.Halaqah
+	err := s.DB.Preload("Mentors.Mentor").Preload("Anggota.Mahasantri").
+		Joins("JOIN halaqah_mentors hm ON hm.halaqah_id = halaqahs.id").
+		Where("halaqahs.id = ? AND hm.mentor_id = ?", id, mentorID).
+		First(&halaqah).Error
+	return halaqah, err
+}
+
+// CreateHalaqah - Membuat halaqah baru
+// @Summary Membuat halaqah baru
+// @Description Mentor membuat halaqah baru dan otomatis menjadi pengajar utama halaqah tersebut.
+// @Tags Halaqah
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateHalaqahRequest true "Data halaqah"
+// @Success 201 {object} utils.Response "Halaqah berhasil dibuat"
+// @Failure 400 {object} utils.Response "Request tidak valid"
+// @Failure 500 {object} utils.Response "Gagal membuat halaqah"
+// @Security BearerAuth
+// @Router /api/v1/halaqah [post]
+func (s *halaqahService) CreateHalaqah(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	log := logrus.WithFields(logrus.Fields{"handler": "CreateHalaqah", "mentorID": claims.ID})
+
+	var req dto.CreateHalaqahRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Request body tidak valid", err.Error())
+	}
+	if strings.TrimSpace(req.Nama) == "" {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Nama halaqah wajib diisi", nil)
+	}
+	if req.Gender != "L" && req.Gender != "P" {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Gender harus L atau P", nil)
+	}
+
+	halaqah := models.Halaqah{
+		Nama:    req.Nama,
+		Jadwal:  req.Jadwal,
+		Gender:  req.Gender,
+		Ruangan: req.Ruangan,
+		Mentors: []models.HalaqahMentor{{MentorID: claims.ID, Peran: models.PeranHalaqahUtama}},
+	}
+	if err := s.DB.Create(&halaqah).Error; err != nil {
+		log.WithError(err).Error("Gagal membuat halaqah")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal membuat halaqah", err.Error())
+	}
+
+	halaqah, err := s.findHalaqahForMentor(int(halaqah.ID), claims.ID)
+	if err != nil {
+		log.WithError(err).Error("Gagal memuat halaqah")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal memuat halaqah", err.Error())
+	}
+
+	log.WithField("halaqah_id", halaqah.ID).Info("Halaqah berhasil dibuat")
+	return utils.SuccessResponse(c, fiber.StatusCreated, "Halaqah berhasil dibuat", toHalaqahResponse(halaqah, true))
+}
+
+// GetAllHalaqah - Mengambil daftar halaqah
+// @Summary Mengambil daftar halaqah
+// @Description Mentor melihat halaqah yang diampunya, mahasantri melihat halaqah tempat ia menjadi anggota aktif.
+// @Tags Halaqah
+// @Accept json
+// @Produce json
+// @Success 200 {object} utils.Response "Daftar halaqah berhasil diambil"
+// @Failure 500 {object} utils.Response "Gagal mengambil halaqah"
+// @Security BearerAuth
+// @Router /api/v1/halaqah [get]
+func (s *halaqahService) GetAllHalaqah(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+
+	query := s.DB.Preload("Mentors.Mentor").Preload("Anggota")
+	switch claims.Role {
+	case RoleMentor:
+		query = query.Where("id IN (?)", s.DB.Model(&models.HalaqahMentor{}).Select("halaqah_id").Where("mentor_id = ?", claims.ID))
+	case RoleMahasantri:
+		query = query.Where("id IN (?)", s.DB.Model(&models.HalaqahAnggota{}).Select("halaqah_id").Where("mahasantri_id = ? AND tanggal_keluar IS NULL", claims.ID))
+	}
+
+	var halaqahs []models.Halaqah
+	if err := query.Order("nama ASC").Find(&halaqahs).Error; err != nil {
+		logrus.WithError(err).Error("Gagal mengambil daftar halaqah")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil halaqah", err.Error())
+	}
+
+	response := make([]dto.HalaqahResponse, len(halaqahs))
+	for i, h := range halaqahs {
+		response[i] = toHalaqahResponse(h, false)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Daftar halaqah berhasil diambil", response)
+}
+
+// GetHalaqahByID - Mengambil detail halaqah
+// @Summary Mengambil detail halaqah
+// @Description Mengambil detail halaqah beserta pengajar dan anggota aktif. Hanya untuk mentor pengajar halaqah.
+// @Tags Halaqah
+// @Accept json
+// @Produce json
+// @Param id path int true "ID Halaqah"
+// @Success 200 {object} utils.Response "Halaqah ditemukan"
+// @Failure 404 {object} utils.Response "Halaqah tidak ditemukan"
+// @Security BearerAuth
+// @Router /api/v1/halaqah/{id} [get]
+func (s *halaqahService) GetHalaqahByID(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "ID halaqah tidak valid", nil)
+	}
+
+	halaqah, err := s.findHalaqahForMentor(id, claims.ID)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusNotFound, "Halaqah tidak ditemukan", nil)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Halaqah ditemukan", toHalaqahResponse(halaqah, true))
+}
+
+// UpdateHalaqah - Memperbarui data halaqah
+// @Summary Memperbarui data halaqah
+// @Description Memperbarui nama, jadwal, gender, atau ruangan halaqah. Hanya untuk mentor pengajar halaqah.
+// @Tags Halaqah
+// @Accept json
+// @Produce json
+// @Param id path int true "ID Halaqah"
+// @Param request body dto.UpdateHalaqahRequest true "Data yang ingin diperbarui"
+// @Success 200 {object} utils.Response "Halaqah berhasil diperbarui"
+// @Failure 400 {object} utils.Response "Request tidak valid"
+// @Failure 404 {object} utils.Response "Halaqah tidak ditemukan"
+// @Security BearerAuth
+// @Router /api/v1/halaqah/{id} [put]
+func (s *halaqahService) UpdateHalaqah(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "ID halaqah tidak valid", nil)
+	}
+
+	var req dto.UpdateHalaqahRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Request body tidak valid", err.Error())
+	}
+
+	halaqah, err := s.findHalaqahForMentor(id, claims.ID)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusNotFound, "Halaqah tidak ditemukan", nil)
+	}
+
+	if req.Nama != nil {
+		if strings.TrimSpace(*req.Nama) == "" {
+			return utils.ResponseError(c, fiber.StatusBadRequest, "Nama halaqah tidak boleh kosong", nil)
+		}
+		halaqah.Nama = *req.Nama
+	}
+	if req.Jadwal != nil {
+		halaqah.Jadwal = *req.Jadwal
+	}
+	if req.Gender != nil {
+		if *req.Gender != "L" && *req.Gender != "P" {
+			return utils.ResponseError(c, fiber.StatusBadRequest, "Gender harus L atau P", nil)
+		}
+		halaqah.Gender = *req.Gender
+	}
+	if req.Ruangan != nil {
+		halaqah.Ruangan = *req.Ruangan
+	}
+
+	if err := s.DB.Omit(clause.Associations).Save(&halaqah).Error; err != nil {
+		logrus.WithError(err).WithField("halaqah_id", id).Error("Gagal memperbarui halaqah")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal memperbarui halaqah", err.Error())
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Halaqah berhasil diperbarui", toHalaqahResponse(halaqah, true))
+}
+
+// DeleteHalaqah - Menghapus halaqah
+// @Summary Menghapus halaqah
+// @Description Menghapus halaqah beserta data pengajar dan riwayat anggotanya. Hanya untuk pengajar utama.
+// @Tags Halaqah
+// @Param id path int true "ID Halaqah"
+// @Success 200 {object} utils.Response "Halaqah berhasil dihapus"
+// @Failure 403 {object} utils.Response "Hanya pengajar utama yang dapat menghapus halaqah"
+// @Failure 404 {object} utils.Response "Halaqah tidak ditemukan"
+// @Security BearerAuth
+// @Router /api/v1/halaqah/{id} [delete]
+func (s *halaqahService) DeleteHalaqah(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "ID halaqah tidak valid", nil)
+	}
+
+	var pengajar models.HalaqahMentor
+	if err := s.DB.Where("halaqah_id = ? AND mentor_id = ?", id, claims.ID).First(&pengajar).Error; err != nil {
+		return utils.ResponseError(c, fiber.StatusNotFound, "Halaqah tidak ditemukan", nil)
+	}
+	if pengajar.Peran != models.PeranHalaqahUtama {
+		return utils.ResponseError(c, fiber.StatusForbidden, "Hanya pengajar utama yang dapat menghapus halaqah", nil)
+	}
+
+	if err := s.DB.Delete(&models.Halaqah{}, id).Error; err != nil {
+		logrus.WithError(err).WithField("halaqah_id", id).Error("Gagal menghapus halaqah")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal menghapus halaqah", err.Error())
+	}
+
+	logrus.WithField("halaqah_id", id).Info("Halaqah berhasil dihapus")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Halaqah berhasil dihapus", nil)
+}
+
+// AddMentorHalaqah - Menambahkan mentor pengajar ke halaqah
+// @Summary Menambahkan mentor ke halaqah
+// @Description Menambahkan mentor sebagai pengajar utama, pendamping, atau pengganti. Hanya pengajar utama yang dapat mengatur mentor. Jika mentor sudah terdaftar, perannya diperbarui.
+// @Tags Halaqah
+// @Accept json
+// @Produce json
+// @Param id path int true "ID Halaqah"
+// @Param request body dto.HalaqahMentorRequest true "Mentor dan peran"
+// @Success 200 {object} utils.Response "Mentor berhasil ditambahkan"
+// @Failure 400 {object} utils.Response "Request tidak valid atau halaqah kehilangan pengajar utama terakhir"
+// @Failure 403 {object} utils.Response "Hanya pengajar utama yang dapat mengatur mentor"
+// @Failure 404 {object} utils.Response "Halaqah atau mentor tidak ditemukan"
+// @Security BearerAuth
+// @Router /api/v1/halaqah/{id}/mentors [post]
+func (s *halaqahService) AddMentorHalaqah(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "ID halaqah tidak valid", nil)
+	}
+
+	var req dto.HalaqahMentorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Request body tidak valid", err.Error())
+	}
+	if req.Peran == "" {
+		req.Peran = models.PeranHalaqahPendamping
+	}
+	if req.Peran != models.PeranHalaqahUtama && req.Peran != models.PeranHalaqahPendamping && req.Peran != models.PeranHalaqahPengganti {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Peran harus utama, pendamping, atau pengganti", nil)
+	}
+
+	current, err := s.findHalaqahForMentor(id, claims.ID)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusNotFound, "Halaqah tidak ditemukan", nil)
+	}
+	if peranMentorHalaqah(current, claims.ID) != models.PeranHalaqahUtama {
+		return utils.ResponseError(c, fiber.StatusForbidden, "Hanya pengajar utama yang dapat mengatur mentor halaqah", nil)
+	}
+	// Peran mentor yang sudah ada ikut diperbarui, halaqah tidak boleh kehilangan pengajar utama terakhirnya
+	if req.Peran != models.PeranHalaqahUtama {
+		utamaTersisa := 0
+		for _, m := range current.Mentors {
+			if m.MentorID != req.MentorID && m.Peran == models.PeranHalaqahUtama {
+				utamaTersisa++
+			}
+		}
+		if utamaTersisa == 0 {
+			return utils.ResponseError(c, fiber.StatusBadRequest, "Halaqah harus memiliki minimal satu pengajar utama", nil)
+		}
+	}
+
+	var mentor models.Mentor
+	if err := s.DB.First(&mentor, req.MentorID).Error; err != nil {
+		return utils.ResponseError(c, fiber.StatusNotFound, "Mentor tidak ditemukan", nil)
+	}
+
+	pengajar := models.HalaqahMentor{HalaqahID: uint(id), MentorID: mentor.ID, Peran: req.Peran}
+	if err := s.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "halaqah_id"}, {Name: "mentor_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"peran"}),
+	}).Create(&pengajar).Error; err != nil {
+		logrus.WithError(err).WithField("halaqah_id", id).Error("Gagal menambahkan mentor halaqah")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal menambahkan mentor", err.Error())
+	}
+
+	halaqah, err := s.findHalaqahForMentor(id, claims.ID)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal memuat halaqah", err.Error())
+	}
+
+	logrus.WithFields(logrus.Fields{"halaqah_id": id, "mentor_id": mentor.ID, "peran": req.Peran}).Info("Mentor halaqah berhasil ditambahkan")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Mentor berhasil ditambahkan", toHalaqahResponse(halaqah, true))
+}
+
+// RemoveMentorHalaqah - Mengeluarkan mentor dari halaqah
+// @Summary Mengeluarkan mentor dari halaqah
+// @Description Mengeluarkan mentor pengajar dari halaqah. Hanya pengajar utama yang dapat mengeluarkan mentor lain, dan halaqah harus tetap memiliki minimal satu pengajar utama.
+// @Tags Halaqah
+// @Param id path int true "ID Halaqah"
+// @Param mentor_id path int true "ID Mentor"
+// @Success 200 {object} utils.Response "Mentor berhasil dikeluarkan"
+// @Failure 400 {object} utils.Response "Halaqah harus memiliki pengajar utama"
+// @Failure 403 {object} utils.Response "Hanya pengajar utama yang dapat mengeluarkan mentor lain"
+// @Failure 404 {object} utils.Response "Halaqah atau mentor tidak ditemukan"
+// @Security BearerAuth
+// @Router /api/v1/halaqah/{id}/mentors/{mentor_id} [delete]
+func (s *halaqahService) RemoveMentorHalaqah(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "ID halaqah tidak valid", nil)
+	}
+	mentorID, err := c.ParamsInt("mentor_id")
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "ID mentor tidak valid", nil)
+	}
+
+	halaqah, err := s.findHalaqahForMentor(id, claims.ID)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusNotFound, "Halaqah tidak ditemukan", nil)
+	}
+
+	var target *models.HalaqahMentor
+	utamaTersisa := 0
+	for i, m := range halaqah.Mentors {
+		if m.MentorID == uint(mentorID) {
+			target = &halaqah.Mentors[i]
+		} else if m.Peran == models.PeranHalaqahUtama {
+			utamaTersisa++
+		}
+	}
+	if target == nil {
+		return utils.ResponseError(c, fiber.StatusNotFound, "Mentor bukan pengajar halaqah ini", nil)
+	}
+	// Pendamping dan pengganti hanya boleh mengeluarkan dirinya sendiri
+	if target.MentorID != claims.ID && peranMentorHalaqah(halaqah, claims.ID) != models.PeranHalaqahUtama {
+		return utils.ResponseError(c, fiber.StatusForbidden, "Hanya pengajar utama yang dapat mengeluarkan mentor lain", nil)
+	}
+	if utamaTersisa == 0 {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Halaqah harus memiliki minimal satu pengajar utama", nil)
+	}
+
+	if err := s.DB.Delete(target).Error; err != nil {
+		logrus.WithError(err).WithField("halaqah_id", id).Error("Gagal mengeluarkan mentor halaqah")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengeluarkan mentor", err.Error())
+	}
+
+	logrus.WithFields(logrus.Fields{"halaqah_id": id, "mentor_id": mentorID}).Info("Mentor halaqah berhasil dikeluarkan")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Mentor berhasil dikeluarkan", nil)
+}
+
+// AddAnggotaHalaqah - Menambahkan mahasantri ke halaqah
+// @Summary Menambahkan anggota halaqah
+// @Description Menambahkan satu atau beberapa mahasantri bimbingan mentor sebagai anggota aktif. Gender mahasantri harus sama dengan gender halaqah, dan mahasantri yang sudah aktif akan dilewati.
+// @Tags Halaqah
+// @Accept json
+// @Produce json
+// @Param id path int true "ID Halaqah"
+// @Param request body dto.HalaqahAnggotaRequest true "Daftar mahasantri"
+// @Success 200 {object} utils.Response "Anggota berhasil ditambahkan"
+// @Failure 400 {object} utils.Response "Request tidak valid atau gender tidak sesuai"
+// @Failure 404 {object} utils.Response "Halaqah atau mahasantri tidak ditemukan"
+// @Security BearerAuth
+// @Router /api/v1/halaqah/{id}/anggota [post]
+func (s *halaqahService) AddAnggotaHalaqah(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "ID halaqah tidak valid", nil)
+	}
+
+	var req dto.HalaqahAnggotaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Request body tidak valid", err.Error())
+	}
+	if len(req.MahasantriIDs) == 0 {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "mahasantri_ids wajib diisi", nil)
+	}
+
+	tanggal, err := parseTanggalOrToday(req.Tanggal)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Format tanggal tidak valid, gunakan DD-MM-YYYY", nil)
+	}
+
+	halaqah, err := s.findHalaqahForMentor(id, claims.ID)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusNotFound, "Halaqah tidak ditemukan", nil)
+	}
+
+	// ID yang dikirim berulang dihitung sekali agar tidak dianggap tidak ditemukan
+	seen := make(map[uint]bool, len(req.MahasantriIDs))
+	mahasantriIDs := make([]uint, 0, len(req.MahasantriIDs))
+	for _, mid := range req.MahasantriIDs {
+		if !seen[mid] {
+			seen[mid] = true
+			mahasantriIDs = append(mahasantriIDs, mid)
+		}
+	}
+
+	// Mentor hanya dapat menambahkan mahasantri bimbingannya sendiri
+	var mahasantris []models.Mahasantri
+	if err := s.DB.Where("id IN ? AND mentor_id = ?", mahasantriIDs, claims.ID).Find(&mahasantris).Error; err != nil {
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil mahasantri", err.Error())
+	}
+	if len(mahasantris) != len(mahasantriIDs) {
+		return utils.ResponseError(c, fiber.StatusNotFound, "Sebagian mahasantri tidak ditemukan atau bukan bimbingan Anda", nil)
+	}
+	for _, m := range mahasantris {
+		if m.Gender != halaqah.Gender {
+			return utils.ResponseError(c, fiber.StatusBadRequest, "Gender mahasantri tidak sesuai dengan halaqah", fiber.Map{"mahasantri_id": m.ID})
+		}
+		if m.Status == models.StatusMahasantriLulus || m.Status == models.StatusMahasantriKeluar {
+			return utils.ResponseError(c, fiber.StatusBadRequest, "Mahasantri yang sudah lulus atau keluar tidak dapat ditambahkan ke halaqah", fiber.Map{"mahasantri_id": m.ID})
+		}
+	}
+
+	activeIDs, err := activeAnggotaHalaqahIDs(s.DB, halaqah.ID)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil anggota halaqah", err.Error())
+	}
+	active := make(map[uint]bool, len(activeIDs))
+	for _, aid := range activeIDs {
+		active[aid] = true
+	}
+
+	var anggotaBaru []models.HalaqahAnggota
+	for _, m := range mahasantris {
+		if active[m.ID] {
+			continue
+		}
+		anggotaBaru = append(anggotaBaru, models.HalaqahAnggota{HalaqahID: halaqah.ID, MahasantriID: m.ID, TanggalMasuk: tanggal})
+	}
+
+	if len(anggotaBaru) > 0 {
+		if err := s.DB.Create(&anggotaBaru).Error; err != nil {
+			logrus.WithError(err).WithField("halaqah_id", id).Error("Gagal menambahkan anggota halaqah")
+			return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal menambahkan anggota", err.Error())
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{"halaqah_id": id, "ditambahkan": len(anggotaBaru)}).Info("Anggota halaqah berhasil ditambahkan")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Anggota berhasil ditambahkan", fiber.Map{
+		"ditambahkan": len(anggotaBaru),
+		"dilewati":    len(mahasantris) - len(anggotaBaru),
+	})
+}
+
+// RemoveAnggotaHalaqah - Mengeluarkan mahasantri dari halaqah
+// @Summary Mengeluarkan anggota halaqah
+// @Description Menutup keanggotaan aktif mahasantri dengan mengisi tanggal keluar. Riwayat keanggotaan tetap tersimpan.
+// @Tags Halaqah
+// @Param id path int true "ID Halaqah"
+// @Param mahasantri_id path int true "ID Mahasantri"
+// @Param tanggal query string false "Tanggal keluar (DD-MM-YYYY), default hari ini"
+// @Success 200 {object} utils.Response "Anggota berhasil dikeluarkan"
+// @Failure 404 {object} utils.Response "Halaqah atau anggota tidak ditemukan"
+// @Security BearerAuth
+// @Router /api/v1/halaqah/{id}/anggota/{mahasantri_id} [delete]
+func (s *halaqahService) RemoveAnggotaHalaqah(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "ID halaqah tidak valid", nil)
+	}
+	mahasantriID, err := c.ParamsInt("mahasantri_id")
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "ID mahasantri tidak valid", nil)
+	}
+
+	tanggal, err := parseTanggalOrToday(c.Query("tanggal"))
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Format tanggal tidak valid, gunakan DD-MM-YYYY", nil)
+	}
+
+	ok, err := isMentorHalaqah(s.DB, uint(id), claims.ID)
+	if err != nil || !ok {
+		return utils.ResponseError(c, fiber.StatusNotFound, "Halaqah tidak ditemukan", nil)
+	}
+
+	result := s.DB.Model(&models.HalaqahAnggota{}).
+		Where("halaqah_id = ? AND mahasantri_id = ? AND tanggal_keluar IS NULL", id, mahasantriID).
+		Update("tanggal_keluar", tanggal)
+	if result.Error != nil {
+		logrus.WithError(result.Error).WithField("halaqah_id", id).Error("Gagal mengeluarkan anggota halaqah")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengeluarkan anggota", result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return utils.ResponseError(c, fiber.StatusNotFound, "Mahasantri bukan anggota aktif halaqah ini", nil)
+	}
+
+	logrus.WithFields(logrus.Fields{"halaqah_id": id, "mahasantri_id": mahasantriID}).Info("Anggota halaqah berhasil dikeluarkan")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Anggota berhasil dikeluarkan", nil)
+}
+
+// GetRiwayatAnggotaHalaqah - Mengambil riwayat keanggotaan halaqah
+// @Summary Riwayat anggota halaqah
+// @Description Mengambil seluruh riwayat keanggotaan halaqah, termasuk anggota yang sudah keluar.
+// @Tags Halaqah
+// @Produce json
+// @Param id path int true "ID Halaqah"
+// @Success 200 {object} utils.Response "Riwayat anggota berhasil diambil"
+// @Failure 404 {object} utils.Response "Halaqah tidak ditemukan"
+// @Security BearerAuth
+// @Router /api/v1/halaqah/{id}/riwayat [get]
+func (s *halaqahService) GetRiwayatAnggotaHalaqah(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "ID halaqah tidak valid", nil)
+	}
+
+	ok, err := isMentorHalaqah(s.DB, uint(id), claims.ID)
+	if err != nil || !ok {
+		return utils.ResponseError(c, fiber.StatusNotFound, "Halaqah tidak ditemukan", nil)
+	}
+
+	var riwayat []models.HalaqahAnggota
+	if err := s.DB.Preload("Mahasantri").
+		Where("halaqah_id = ?", id).
+		Order("tanggal_masuk DESC, id DESC").
+		Find(&riwayat).Error; err != nil {
+		logrus.WithError(err).WithField("halaqah_id", id).Error("Gagal mengambil riwayat anggota halaqah")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil riwayat anggota", err.Error())
+	}
+
+	response := make([]dto.HalaqahAnggotaResponse, len(riwayat))
+	for i, a := range riwayat {
+		response[i] = toHalaqahAnggotaResponse(a)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Riwayat anggota berhasil diambil", response)
+}
+
+// parseTanggalOrToday mengubah tanggal DD-MM-YYYY ke time.Time, atau hari ini jika kosong
+func parseTanggalOrToday(raw string) (time.Time, error) {
+	if raw == "" {
+		now := time.Now()
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), nil
+	}
+	return time.Parse("02-01-2006", raw)
+}
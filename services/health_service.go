@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/mahad-service-go/config"
+	"github.com/sirupsen/logrus"
+)
+
+const readinessTimeout = 2 * time.Second
+
+// HealthService menyediakan endpoint liveness dan readiness untuk orchestrator/container
+type HealthService struct{}
+
+// Liveness godoc
+// @Summary Liveness check
+// @Description Menandakan proses server masih berjalan. Tidak memeriksa dependensi.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /healthz [get]
+func (s *HealthService) Liveness(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"status": "ok"})
+}
+
+// Readiness godoc
+// @Summary Readiness check
+// @Description Memeriksa koneksi database dan model Q-Learning. Mengembalikan 503 jika salah satu belum siap.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /readyz [get]
+func (s *HealthService) Readiness(c *fiber.Ctx) error {
+	checks := fiber.Map{}
+	ready := true
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), readinessTimeout)
+	defer cancel()
+	if err := config.PingDB(ctx); err != nil {
+		logrus.WithError(err).Warn("Readiness: database tidak dapat dihubungi")
+		checks["database"] = err.Error()
+		ready = false
+	} else {
+		checks["database"] = "ok"
+	}
+
+	if config.Models.IsLoaded() {
+		checks["qlearning_model"] = "ok"
+	} else {
+		checks["qlearning_model"] = "model belum dimuat"
+		ready = false
+	}
+
+	if !ready {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"status": "not ready", "checks": checks})
+	}
+	return c.JSON(fiber.Map{"status": "ready", "checks": checks})
+}
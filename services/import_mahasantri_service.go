@@ -0,0 +1,361 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/mahad-service-go/dto"
+	"github.com/habbazettt/mahad-service-go/models"
+	"github.com/habbazettt/mahad-service-go/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	maxImportMahasantriRows = 1000
+	importPasswordLength    = 10
+)
+
+// kolom wajib pada file import, urutan kolom boleh bebas. Nilai mentor_id boleh dikosongkan untuk penempatan otomatis.
+var importMahasantriColumns = []string{"nama", "nim", "jurusan", "gender", "mentor_id"}
+
+// readImportRows membaca baris dari file CSV atau XLSX (sheet pertama)
+func readImportRows(filename string, r io.Reader) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		reader := csv.NewReader(r)
+		reader.TrimLeadingSpace = true
+		reader.FieldsPerRecord = -1
+		return reader.ReadAll()
+	case ".xlsx":
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, errors.New("file xlsx tidak memiliki sheet")
+		}
+		return f.GetRows(sheets[0])
+	default:
+		return nil, errors.New("format file harus .csv atau .xlsx")
+	}
+}
+
+// parseImportMahasantriRows memetakan baris file ke DTO berdasarkan header kolom
+func parseImportMahasantriRows(records [][]string) ([]dto.ImportMahasantriRow, error) {
+	if len(records) == 0 {
+		return nil, errors.New("file kosong")
+	}
+
+	header := make(map[string]int)
+	for i, col := range records[0] {
+		header[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(col, "\ufeff")))] = i
+	}
+	for _, col := range importMahasantriColumns {
+		if _, ok := header[col]; !ok {
+			return nil, fmt.Errorf("kolom %s tidak ditemukan, kolom wajib: %s", col, strings.Join(importMahasantriColumns, ", "))
+		}
+	}
+
+	cell := func(record []string, col string) string {
+		idx := header[col]
+		if idx >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[idx])
+	}
+
+	var rows []dto.ImportMahasantriRow
+	for i, record := range records[1:] {
+		// Lewati baris kosong
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		row := dto.ImportMahasantriRow{
+			Row:     i + 2,
+			Nama:    cell(record, "nama"),
+			NIM:     cell(record, "nim"),
+			Jurusan: cell(record, "jurusan"),
+			Gender:  strings.ToUpper(cell(record, "gender")),
+		}
+
+		// mentor_id kosong berarti mentor dipilih otomatis saat validasi
+		if mentorID := cell(record, "mentor_id"); mentorID == "" {
+			row.MentorOtomatis = true
+		} else if id, err := strconv.ParseUint(mentorID, 10, 64); err != nil {
+			row.Errors = append(row.Errors, "mentor_id harus berupa angka")
+		} else {
+			row.MentorID = uint(id)
+		}
+
+		rows = append(rows, row)
+	}
+
+	if len(rows) > maxImportMahasantriRows {
+		return nil, fmt.Errorf("maksimal %d baris per import", maxImportMahasantriRows)
+	}
+	return rows, nil
+}
+
+// validateImportMahasantriRows memeriksa isian wajib, gender, NIM ganda, mentor yang tidak dikenal, dan kapasitas mentor,
+// lalu memilih mentor untuk baris yang mentor_id-nya kosong
+func (s *MahasantriService) validateImportMahasantriRows(rows []dto.ImportMahasantriRow) error {
+	nims := make([]string, 0, len(rows))
+	mentorIDs := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		nims = append(nims, row.NIM)
+		mentorIDs = append(mentorIDs, row.MentorID)
+	}
+
+	// NIM pada data arsip juga dianggap terpakai karena kolom nim bersifat unik
+	var existingNIMs []string
+	if err := s.DB.Unscoped().Model(&models.Mahasantri{}).Where("nim IN ?", nims).Pluck("nim", &existingNIMs).Error; err != nil {
+		return err
+	}
+	terdaftar := make(map[string]bool, len(existingNIMs))
+	for _, nim := range existingNIMs {
+		terdaftar[nim] = true
+	}
+
+	var existingMentors []models.Mentor
+	if err := s.DB.Select("id", "gender").Where("id IN ?", mentorIDs).Find(&existingMentors).Error; err != nil {
+		return err
+	}
+	genderMentor := make(map[uint]string, len(existingMentors))
+	for _, m := range existingMentors {
+		genderMentor[m.ID] = m.Gender
+	}
+
+	nimDalamFile := make(map[string]int)
+	for i := range rows {
+		row := &rows[i]
+
+		if row.Nama == "" {
+			row.Errors = append(row.Errors, "nama wajib diisi")
+		}
+		if row.Jurusan == "" {
+			row.Errors = append(row.Errors, "jurusan wajib diisi")
+		}
+		if row.Gender != "L" && row.Gender != "P" {
+			row.Errors = append(row.Errors, "gender harus L atau P")
+		}
+
+		switch {
+		case row.NIM == "":
+			row.Errors = append(row.Errors, "nim wajib diisi")
+		case terdaftar[row.NIM]:
+			row.Errors = append(row.Errors, "nim sudah terdaftar")
+		default:
+			if prev, ok := nimDalamFile[row.NIM]; ok {
+				row.Errors = append(row.Errors, fmt.Sprintf("nim duplikat dengan baris %d", prev))
+			} else {
+				nimDalamFile[row.NIM] = row.Row
+			}
+		}
+
+		if row.MentorID != 0 {
+			if gender, ok := genderMentor[row.MentorID]; !ok {
+				row.Errors = append(row.Errors, "mentor tidak ditemukan")
+			} else if gender != row.Gender {
+				row.Errors = append(row.Errors, errGenderMentorTidakSesuai.Error())
+			}
+		}
+	}
+
+	// Penempatan otomatis dilakukan setelah semua baris valid agar beban mentor dari baris lain ikut dihitung
+	planner, err := loadPenempatanPlanner(s.DB, false)
+	if err != nil {
+		return err
+	}
+	for _, r := range rows {
+		if !r.MentorOtomatis && len(r.Errors) == 0 && planner.byID[r.MentorID] != nil {
+			planner.byID[r.MentorID].Beban++
+		}
+	}
+	// mentor_id yang diisi langsung juga tunduk pada MENTOR_MAX_BIMBINGAN
+	for i := range rows {
+		row := &rows[i]
+		if row.MentorOtomatis || len(row.Errors) > 0 {
+			continue
+		}
+		if m := planner.byID[row.MentorID]; m != nil && planner.maxBeban > 0 && m.Beban > planner.maxBeban {
+			row.Errors = append(row.Errors, errKapasitasMentorPenuh.Error())
+		}
+	}
+	for i := range rows {
+		row := &rows[i]
+		if !row.MentorOtomatis || len(row.Errors) > 0 {
+			continue
+		}
+		mentor, err := planner.tempatkan(row.Gender, nil, 0)
+		if err != nil {
+			row.Errors = append(row.Errors, err.Error())
+			continue
+		}
+		row.MentorID = mentor.ID
+	}
+
+	return nil
+}
+
+// ImportMahasantri - Import data mahasantri dari file CSV/XLSX
+// @Summary Import mahasantri dari CSV/XLSX
+// @Description Mengunggah file CSV atau XLSX berisi kolom nama, nim, jurusan, gender (L/P), dan mentor_id (kosongkan untuk penempatan otomatis ke mentor dengan gender sama dan beban paling ringan). Secara default hanya menampilkan pratinjau (dry run) beserta kesalahan per baris.
+// @Description Dengan dry_run=false seluruh baris disimpan dalam satu transaksi (tidak ada yang disimpan jika satu baris saja tidak valid), dan password awal yang dibuat otomatis dikirim ke masing-masing mahasantri melalui notifikasi. Import hanya dapat disimpan jika notifier webhook dikonfigurasi (NOTIFIER_DRIVER=webhook), karena notifier log tidak meneruskan password.
+// @Tags Mahasantri
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "File CSV atau XLSX"
+// @Param dry_run query bool false "Hanya validasi tanpa menyimpan" default(true)
+// @Success 200 {object} utils.Response{data=dto.ImportMahasantriResponse} "Pratinjau import"
+// @Success 201 {object} utils.Response{data=dto.ImportMahasantriResponse} "Mahasantri berhasil diimport"
+// @Failure 400 {object} utils.Response "File tidak valid atau terdapat baris yang tidak valid"
+// @Failure 500 {object} utils.Response "Gagal mengimport mahasantri"
+// @Failure 503 {object} utils.Response "Notifier webhook belum dikonfigurasi untuk mengirim password awal"
+// @Security BearerAuth
+// @Router /api/v1/mahasantri/import [post]
+func (s *MahasantriService) ImportMahasantri(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	dryRun := c.QueryBool("dry_run", true)
+
+	log := logrus.WithFields(logrus.Fields{
+		"handler":  "ImportMahasantri",
+		"mentorID": claims.ID,
+		"dry_run":  dryRun,
+	})
+
+	// Password awal hanya diberikan lewat notifikasi, tanpa webhook akun yang dibuat tidak dapat dipakai siapa pun
+	if !dryRun && !utils.CanDeliverSecrets(s.Notifier) {
+		log.Warn("Import mahasantri ditolak karena notifier webhook belum dikonfigurasi")
+		return utils.ResponseError(c, fiber.StatusServiceUnavailable, "Notifier webhook belum dikonfigurasi (NOTIFIER_DRIVER=webhook), password awal tidak dapat dikirim", nil)
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "File wajib diunggah pada field 'file'", err.Error())
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Gagal membaca file", err.Error())
+	}
+	defer file.Close()
+
+	records, err := readImportRows(fileHeader.Filename, file)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Gagal membaca file", err.Error())
+	}
+
+	rows, err := parseImportMahasantriRows(records)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Format file tidak valid", err.Error())
+	}
+	if len(rows) == 0 {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "File tidak berisi data mahasantri", nil)
+	}
+
+	if err := s.validateImportMahasantriRows(rows); err != nil {
+		log.WithError(err).Error("Gagal memvalidasi data import")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal memvalidasi data import", err.Error())
+	}
+
+	response := dto.ImportMahasantriResponse{DryRun: dryRun, TotalRows: len(rows), Rows: rows}
+	for _, row := range rows {
+		if len(row.Errors) > 0 {
+			response.InvalidRows++
+		} else {
+			response.ValidRows++
+		}
+	}
+
+	if dryRun {
+		log.WithFields(logrus.Fields{"valid": response.ValidRows, "invalid": response.InvalidRows}).Info("Pratinjau import mahasantri")
+		return utils.SuccessResponse(c, fiber.StatusOK, "Pratinjau import mahasantri", response)
+	}
+
+	if response.InvalidRows > 0 {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Terdapat baris yang tidak valid, tidak ada data yang disimpan", response)
+	}
+
+	// bcrypt lambat, password di-hash sebelum transaksi dibuka agar transaksi tidak menahan koneksi terlalu lama
+	passwords := make([]string, len(rows))
+	hashedPasswords := make([]string, len(rows))
+	for i := range rows {
+		passwords[i], err = utils.GenerateRandomPassword(importPasswordLength)
+		if err == nil {
+			hashedPasswords[i], err = utils.HashPassword(passwords[i])
+		}
+		if err != nil {
+			log.WithError(err).Error("Gagal membuat password mahasantri")
+			return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengimport mahasantri", err.Error())
+		}
+	}
+
+	mentorIDs := make([]uint, len(rows))
+	for i, row := range rows {
+		mentorIDs[i] = row.MentorID
+	}
+
+	var notifications []utils.Notification
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		// Mentor tujuan dikunci agar kapasitas yang dihitung saat validasi tidak terlampaui oleh penempatan lain
+		if err := kunciMentor(tx, mentorIDs...); err != nil {
+			return err
+		}
+		for i := range rows {
+			row := &rows[i]
+
+			mahasantri := models.Mahasantri{
+				Nama:     row.Nama,
+				NIM:      row.NIM,
+				Jurusan:  row.Jurusan,
+				Gender:   row.Gender,
+				Password: hashedPasswords[i],
+				MentorID: row.MentorID,
+			}
+			if err := tx.Create(&mahasantri).Error; err != nil {
+				return fmt.Errorf("baris %d: %w", row.Row, err)
+			}
+			if err := createInitialMentorAssignment(tx, mahasantri); err != nil {
+				return fmt.Errorf("baris %d: %w", row.Row, err)
+			}
+			row.MahasantriID = mahasantri.ID
+
+			notifications = append(notifications, utils.Notification{
+				RecipientRole: RoleMahasantri,
+				RecipientID:   mahasantri.ID,
+				Title:         "Akun mahasantri dibuat",
+				Message:       fmt.Sprintf("Akun Anda telah dibuat. Silakan login menggunakan NIM %s dan segera ganti password awal Anda.", mahasantri.NIM),
+				Data: map[string]interface{}{
+					"nim":      mahasantri.NIM,
+					"password": passwords[i],
+				},
+			})
+		}
+		return cekKapasitasMentor(tx, mentorIDs...)
+	})
+	if errors.Is(err, errKapasitasMentorPenuh) {
+		return utils.ResponseError(c, fiber.StatusUnprocessableEntity, "Beban mentor melebihi batas, tidak ada data yang disimpan", err.Error())
+	}
+	if err != nil {
+		log.WithError(err).Error("Gagal mengimport mahasantri")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengimport mahasantri", err.Error())
+	}
+
+	utils.SendNotifications(s.Notifier, notifications...)
+
+	response.Imported = len(rows)
+	response.Rows = rows
+	log.WithField("imported", response.Imported).Info("Mahasantri berhasil diimport")
+	return utils.SuccessResponse(c, fiber.StatusCreated, "Mahasantri berhasil diimport", response)
+}
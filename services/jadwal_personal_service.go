@@ -124,6 +124,12 @@ func (s *jadwalPersonalService) CreateJadwalPersonal(c *fiber.Ctx) error {
 
 	log := logrus.WithFields(logrus.Fields{"userID": userID, "userRole": userRole})
 
+	if userRole == RoleMahasantri {
+		if err := ensureMahasantriWritable(s.DB, userID); err != nil {
+			return responseMahasantriFrozen(c, err)
+		}
+	}
+
 	var req dto.CreateJadwalPersonalRequest
 	if err := c.BodyParser(&req); err != nil {
 		log.WithError(err).Warn("Gagal mem-parsing request body untuk jadwal personal")
@@ -182,6 +188,7 @@ func (s *jadwalPersonalService) CreateJadwalPersonal(c *fiber.Ctx) error {
 	}
 
 	log.Info("Jadwal personal berhasil disimpan/diperbarui dan status pengguna diperbarui")
+	rewardEfektifitasJadwal(s.DB, finalJadwal.ID)
 
 	response := dto.JadwalPersonalResponse{
 		ID:                finalJadwal.ID,
@@ -244,6 +251,12 @@ func (s *jadwalPersonalService) UpdateJadwalPersonal(c *fiber.Ctx) error {
 
 	log := logrus.WithFields(logrus.Fields{"userID": userID, "userRole": userRole})
 
+	if userRole == RoleMahasantri {
+		if err := ensureMahasantriWritable(s.DB, userID); err != nil {
+			return responseMahasantriFrozen(c, err)
+		}
+	}
+
 	var req dto.UpdateJadwalPersonalRequest
 	if err := c.BodyParser(&req); err != nil {
 		log.WithError(err).Warn("Gagal mem-parsing request body untuk update jadwal personal")
@@ -290,12 +303,14 @@ func (s *jadwalPersonalService) UpdateJadwalPersonal(c *fiber.Ctx) error {
 		return utils.ResponseError(c, fiber.StatusBadRequest, "Tidak ada data yang diubah", nil)
 	}
 
-	if err := s.DB.Save(&jadwalPersonal).Error; err != nil {
+	// Kolom reward hanya diubah oleh rewardEfektifitasJadwal
+	if err := s.DB.Model(&jadwalPersonal).Select("total_hafalan", "jadwal", "kesibukan", "efektifitas_jadwal", "updated_at").Updates(&jadwalPersonal).Error; err != nil {
 		log.WithError(err).Error("Gagal memperbarui jadwal personal di database")
 		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal memperbarui jadwal", err.Error())
 	}
 
 	log.Info("Jadwal personal berhasil diperbarui")
+	rewardEfektifitasJadwal(s.DB, jadwalPersonal.ID)
 
 	response := dto.JadwalPersonalResponse{
 		ID:                jadwalPersonal.ID,
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/habbazettt/mahad-service-go/config"
+	"github.com/habbazettt/mahad-service-go/dto"
+)
+
+const (
+	// maksTetanggaState membatasi jumlah state serupa yang nilai Q-nya digabungkan
+	maksTetanggaState = 3
+
+	// bobotKategoriBersebelahan mengurangi bobot state dengan kategori hafalan yang bersebelahan (misalnya 11-20 Juz untuk 21-30 Juz)
+	bobotKategoriBersebelahan = 0.5
+
+	// maksCacheKanonisState membatasi cache kanonisState karena kesibukan pada jadwal personal berupa teks bebas
+	maksCacheKanonisState = 10000
+)
+
+// komponenKesibukan memecah kesibukan gabungan seperti "kuliah + organisasi" menjadi komponennya
+func komponenKesibukan(kesibukan string) []string {
+	var hasil []string
+	for _, k := range strings.Split(kesibukan, "+") {
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+			hasil = append(hasil, k)
+		}
+	}
+	return hasil
+}
+
+// normalizeKesibukan merapikan spasi di sekitar tanda "+" agar sama dengan penulisan di Q-Table
+func normalizeKesibukan(kesibukan string) string {
+	return strings.Join(komponenKesibukan(kesibukan), " + ")
+}
+
+// kanonisState memetakan state dengan urutan komponen kesibukan berbeda (misalnya "organisasi + kuliah")
+// ke state yang dikenal model dengan komponen yang sama. Jika tidak ada, state dikembalikan apa adanya.
+func kanonisState(state string) string {
+	if stateRekomendasiDikenal(state) {
+		return state
+	}
+
+	versi := config.Models.Versi()
+	cacheKanonis.mu.Lock()
+	if cacheKanonis.versi != versi || len(cacheKanonis.state) >= maksCacheKanonisState {
+		cacheKanonis.versi = versi
+		cacheKanonis.state = make(map[string]string)
+	}
+	hasil, ok := cacheKanonis.state[state]
+	cacheKanonis.mu.Unlock()
+	if ok {
+		return hasil
+	}
+
+	hasil = KanonisState(state, config.Models.States())
+	cacheKanonis.mu.Lock()
+	if cacheKanonis.versi == versi {
+		cacheKanonis.state[state] = hasil
+	}
+	cacheKanonis.mu.Unlock()
+	return hasil
+}
+
+// cacheKanonis menyimpan hasil kanonisState untuk versi model aktif. State hanya berubah saat versi model berganti,
+// sehingga cache dikosongkan ketika versi berbeda atau jumlah isinya mencapai maksCacheKanonisState.
+var cacheKanonis struct {
+	mu    sync.Mutex
+	versi int
+	state map[string]string
+}
+
+// KanonisState memetakan state ke salah satu states dengan komponen kesibukan dan kategori hafalan yang sama.
+// Dipakai juga oleh cmd/train agar data training dengan urutan kesibukan berbeda digabung ke state yang sama.
+func KanonisState(state string, states []string) string {
+	for _, t := range tetanggaState(state, states) {
+		if _, kategori := pecahState(t.State); t.Bobot == 1 && strings.HasSuffix(state, "_"+kategori) {
+			return t.State
+		}
+	}
+	return state
+}
+
+func pecahState(state string) (kesibukan, kategori string) {
+	idx := strings.LastIndex(state, "_")
+	if idx < 0 {
+		return state, ""
+	}
+	return state[:idx], state[idx+1:]
+}
+
+func indeksKategoriHafalan(kategori string) int {
+	for i, k := range daftarKategoriHafalan {
+		if k == kategori {
+			return i
+		}
+	}
+	return -1
+}
+
+// tetanggaState mencari state yang dikenal model dan paling mirip dengan state yang tidak dikenal.
+// Kemiripan kesibukan dihitung dengan indeks Jaccard antar komponen kesibukan, dan hanya state dengan
+// kategori hafalan yang sama atau bersebelahan yang dipertimbangkan.
+func tetanggaState(state string, states []string) []dto.StateTetangga {
+	kesibukan, kategori := pecahState(state)
+	komponen := make(map[string]bool)
+	for _, k := range komponenKesibukan(kesibukan) {
+		komponen[k] = true
+	}
+	idxKategori := indeksKategoriHafalan(kategori)
+	if len(komponen) == 0 || idxKategori < 0 {
+		return nil
+	}
+
+	var hasil []dto.StateTetangga
+	for _, kandidat := range states {
+		kesibukanKandidat, kategoriKandidat := pecahState(kandidat)
+
+		jarak := indeksKategoriHafalan(kategoriKandidat) - idxKategori
+		if jarak < -1 || jarak > 1 || indeksKategoriHafalan(kategoriKandidat) < 0 {
+			continue
+		}
+
+		komponenKandidat := komponenKesibukan(kesibukanKandidat)
+		sama := 0
+		gabungan := len(komponen)
+		for _, k := range komponenKandidat {
+			if komponen[k] {
+				sama++
+			} else {
+				gabungan++
+			}
+		}
+		if sama == 0 {
+			continue
+		}
+
+		bobot := float64(sama) / float64(gabungan)
+		if jarak != 0 {
+			bobot *= bobotKategoriBersebelahan
+		}
+		hasil = append(hasil, dto.StateTetangga{State: kandidat, Bobot: bobot})
+	}
+
+	sort.Slice(hasil, func(i, j int) bool {
+		if hasil[i].Bobot != hasil[j].Bobot {
+			return hasil[i].Bobot > hasil[j].Bobot
+		}
+		return hasil[i].State < hasil[j].State
+	})
+	if len(hasil) > maksTetanggaState {
+		hasil = hasil[:maksTetanggaState]
+	}
+	return hasil
+}
+
+// gabungkanQTetangga menghitung rata-rata berbobot nilai Q setiap jadwal dari state-state tetangga
+func gabungkanQTetangga(tetangga []dto.StateTetangga) map[string]float64 {
+	total := make(map[string]float64)
+	bobot := make(map[string]float64)
+	for _, t := range tetangga {
+		actions, _, ok := config.Models.GetQActions(t.State)
+		if !ok {
+			continue
+		}
+		for action, v := range actions {
+			total[action] += t.Bobot * v
+			bobot[action] += t.Bobot
+		}
+	}
+
+	hasil := make(map[string]float64, len(total))
+	for action, v := range total {
+		hasil[action] = v / bobot[action]
+	}
+	return hasil
+}
+
+// tipeRekomendasiKemiripan menuliskan tipe fallback beserta state tetangga yang dipakai
+func tipeRekomendasiKemiripan(tetangga []dto.StateTetangga) string {
+	bagian := make([]string, len(tetangga))
+	for i, t := range tetangga {
+		bagian[i] = fmt.Sprintf("%s (%.2f)", t.State, t.Bobot)
+	}
+	return fmt.Sprintf("%s: %s", TipeRekomendasiKemiripan, strings.Join(bagian, ", "))
+}
+
+// peringkatDariKemiripan menyusun peringkat dari nilai Q gabungan state tetangga
+func peringkatDariKemiripan(state string, tetangga []dto.StateTetangga, actions map[string]float64, historical []config.HistoricalInfo, top int) []dto.PeringkatRekomendasi {
+	nama := make([]string, len(tetangga))
+	for i, t := range tetangga {
+		nama[i] = t.State
+	}
+
+	peringkat := peringkatDariQTable(state, actions, historical, nil, top)
+	for i := range peringkat {
+		peringkat[i].Penjelasan = fmt.Sprintf("Model belum memiliki data untuk %s, nilai Q diestimasi dari kondisi serupa: %s. %s",
+			deskripsiState(state), strings.Join(nama, "; "), peringkat[i].Penjelasan)
+	}
+	return peringkat
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var statesUji = []string{
+	"kuliah_1-10 Juz",
+	"kuliah_11-20 Juz",
+	"kuliah_21-30 Juz",
+	"kuliah + organisasi_11-20 Juz",
+	"kuliah + organisasi + kerja_11-20 Juz",
+	"organisasi_11-20 Juz",
+	"kerja_1-10 Juz",
+}
+
+func TestTetanggaStateUrutanBobot(t *testing.T) {
+	tetangga := tetanggaState("organisasi + kuliah_11-20 Juz", statesUji)
+
+	if assert.Len(t, tetangga, maksTetanggaState) {
+		assert.Equal(t, "kuliah + organisasi_11-20 Juz", tetangga[0].State)
+		assert.InDelta(t, 1, tetangga[0].Bobot, 1e-9)
+
+		// Bobot sama (2/3) diurutkan berdasarkan nama state
+		assert.Equal(t, "kuliah + organisasi + kerja_11-20 Juz", tetangga[1].State)
+		assert.InDelta(t, 2.0/3.0, tetangga[1].Bobot, 1e-9)
+		assert.InDelta(t, 0.5, tetangga[2].Bobot, 1e-9)
+		assert.Equal(t, "kuliah_11-20 Juz", tetangga[2].State)
+	}
+}
+
+func TestTetanggaStateKategoriBersebelahan(t *testing.T) {
+	tetangga := tetanggaState("kerja_21-30 Juz", statesUji)
+
+	// kerja_1-10 Juz berjarak dua kategori sehingga tidak dipertimbangkan
+	if assert.Len(t, tetangga, 1) {
+		assert.Equal(t, "kuliah + organisasi + kerja_11-20 Juz", tetangga[0].State)
+		assert.InDelta(t, bobotKategoriBersebelahan/3, tetangga[0].Bobot, 1e-9)
+	}
+}
+
+func TestTetanggaStateTidakValid(t *testing.T) {
+	assert.Nil(t, tetanggaState("kuliah", statesUji))
+	assert.Nil(t, tetanggaState("kuliah_41-50 Juz", statesUji))
+	assert.Nil(t, tetanggaState(" + _1-10 Juz", statesUji))
+	assert.Empty(t, tetanggaState("mengajar_1-10 Juz", statesUji))
+}
+
+func TestKanonisState(t *testing.T) {
+	// Urutan dan spasi komponen kesibukan tidak mempengaruhi state kanonis
+	assert.Equal(t, "kuliah + organisasi_11-20 Juz", KanonisState("organisasi + kuliah_11-20 Juz", statesUji))
+	assert.Equal(t, "kuliah + organisasi + kerja_11-20 Juz", KanonisState("kerja+kuliah + organisasi_11-20 Juz", statesUji))
+
+	// Kategori berbeda atau komponen tidak sama persis dikembalikan apa adanya
+	assert.Equal(t, "organisasi + kuliah_1-10 Juz", KanonisState("organisasi + kuliah_1-10 Juz", statesUji))
+	assert.Equal(t, "kuliah + kerja_11-20 Juz", KanonisState("kuliah + kerja_11-20 Juz", statesUji))
+	assert.Equal(t, "kuliah_11-20 Juz", KanonisState("kuliah_11-20 Juz", statesUji))
+}
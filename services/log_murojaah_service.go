@@ -98,6 +98,13 @@ func (s *logMurojaahService) GetOrCreateLogHarian(c *fiber.Ctx) error {
 		if err := s.DB.Select("mentor_id").First(&mahasantri, mahasantriID).Error; err != nil || mahasantri.MentorID != userID {
 			return utils.ResponseError(c, fiber.StatusForbidden, "Anda tidak memiliki hak akses untuk melihat log mahasantri ini", nil)
 		}
+	case RoleWali:
+		// Hubungan wali dengan mahasantri sudah diperiksa oleh middleware RequireAnakWali
+		mahasantriID_int, err_parse := strconv.Atoi(c.Params("mahasantri_id"))
+		if err_parse != nil {
+			return utils.ResponseError(c, fiber.StatusBadRequest, "ID Mahasantri tidak valid pada parameter URL", nil)
+		}
+		mahasantriID = uint(mahasantriID_int)
 	}
 
 	log := logrus.WithFields(logrus.Fields{"handler": "GetOrCreateLogHarian", "mahasantriID": mahasantriID})
@@ -116,9 +123,14 @@ func (s *logMurojaahService) GetOrCreateLogHarian(c *fiber.Ctx) error {
 	tanggal = time.Date(tanggal.Year(), tanggal.Month(), tanggal.Day(), 0, 0, 0, 0, time.UTC)
 
 	var logHarian models.LogHarian
-	err = s.DB.Preload("DetailLogs").
-		Where(models.LogHarian{MahasantriID: mahasantriID, Tanggal: tanggal}).
-		FirstOrCreate(&logHarian).Error
+	query := s.DB.Preload("DetailLogs").Where(models.LogHarian{MahasantriID: mahasantriID, Tanggal: tanggal})
+	if userRole == RoleWali {
+		// Wali hanya membaca, jadi log yang belum ada tidak dibuat
+		err = query.Find(&logHarian).Error
+		logHarian.Tanggal = tanggal
+	} else {
+		err = query.FirstOrCreate(&logHarian).Error
+	}
 
 	if err != nil {
 		log.WithError(err).Error("Gagal mengambil atau membuat log harian")
@@ -160,6 +172,10 @@ func (s *logMurojaahService) AddDetailToLog(c *fiber.Ctx) error {
 
 	log := logrus.WithFields(logrus.Fields{"handler": "AddDetailToLog", "mahasantriID": mahasantriID})
 
+	if err := ensureMahasantriWritable(s.DB, mahasantriID); err != nil {
+		return responseMahasantriFrozen(c, err)
+	}
+
 	var req dto.AddDetailLogRequest
 	if err := c.BodyParser(&req); err != nil {
 		log.WithError(err).Error("Gagal parsing body request")
@@ -176,6 +192,9 @@ func (s *logMurojaahService) AddDetailToLog(c *fiber.Ctx) error {
 		if err := tx.Where(models.LogHarian{MahasantriID: mahasantriID, Tanggal: today}).FirstOrCreate(&logHarian).Error; err != nil {
 			return err
 		}
+		if err := catatJadwalLogHarian(tx, &logHarian); err != nil {
+			return err
+		}
 
 		totalTarget, err := calculateTotalPages(req.TargetStartJuz, req.TargetStartHalaman, req.TargetEndJuz, req.TargetEndHalaman)
 		if err != nil {
@@ -241,6 +260,10 @@ func (s *logMurojaahService) UpdateDetailLog(c *fiber.Ctx) error {
 
 	log := logrus.WithFields(logrus.Fields{"handler": "UpdateDetailLog", "mahasantriID": mahasantriID, "detailID": detailID})
 
+	if err := ensureMahasantriWritable(s.DB, mahasantriID); err != nil {
+		return responseMahasantriFrozen(c, err)
+	}
+
 	var req dto.UpdateDetailLogRequest
 	if err := c.BodyParser(&req); err != nil {
 		log.WithError(err).Error("Gagal parsing body request")
@@ -248,6 +271,7 @@ func (s *logMurojaahService) UpdateDetailLog(c *fiber.Ctx) error {
 	}
 
 	var detailLog models.DetailLog
+	var baruSelesai bool
 
 	err = s.DB.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Joins("JOIN log_harians ON log_harians.id = detail_logs.log_harian_id").
@@ -258,6 +282,7 @@ func (s *logMurojaahService) UpdateDetailLog(c *fiber.Ctx) error {
 			}
 			return err
 		}
+		statusLama := detailLog.Status
 
 		totalSelesai, err := calculateTotalPages(detailLog.TargetStartJuz, detailLog.TargetStartHalaman, req.SelesaiEndJuz, req.SelesaiEndHalaman)
 		if err != nil {
@@ -285,6 +310,7 @@ func (s *logMurojaahService) UpdateDetailLog(c *fiber.Ctx) error {
 		detailLog.TotalSelesaiHalaman = totalSelesai
 		detailLog.Catatan = req.Catatan
 		detailLog.Status = newStatus
+		baruSelesai = newStatus == models.StatusSesiSelesai && statusLama != models.StatusSesiSelesai
 
 		if err := tx.Save(&detailLog).Error; err != nil {
 			return err
@@ -303,6 +329,9 @@ func (s *logMurojaahService) UpdateDetailLog(c *fiber.Ctx) error {
 		}
 		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal memperbarui sesi murojaah", err.Error())
 	}
+	if baruSelesai {
+		utils.LogSesiSelesaiTotal.Inc()
+	}
 
 	response := dto.DetailLogResponse{
 		ID:                  detailLog.ID,
@@ -334,6 +363,10 @@ func (s *logMurojaahService) DeleteDetailLog(c *fiber.Ctx) error {
 
 	log := logrus.WithFields(logrus.Fields{"handler": "DeleteDetailLog", "mahasantriID": mahasantriID, "detailID": detailID})
 
+	if err := ensureMahasantriWritable(s.DB, mahasantriID); err != nil {
+		return responseMahasantriFrozen(c, err)
+	}
+
 	var detailLog models.DetailLog
 
 	err = s.DB.Transaction(func(tx *gorm.DB) error {
@@ -365,9 +398,22 @@ func (s *logMurojaahService) DeleteDetailLog(c *fiber.Ctx) error {
 	return utils.SuccessResponse(c, fiber.StatusOK, "Sesi murojaah berhasil dihapus", nil)
 }
 
-func (s *logMurojaahService) GetRecapMingguan(c *fiber.Ctx) error {
+// targetMahasantriID menentukan mahasantri yang datanya dibaca: mahasantri membaca datanya sendiri,
+// sedangkan wali membaca data anak dari parameter URL yang sudah diperiksa oleh RequireAnakWali
+func targetMahasantriID(c *fiber.Ctx) (uint, error) {
 	claims := c.Locals("user").(*utils.Claims)
-	mahasantriID := claims.ID
+	if claims.Role != RoleWali {
+		return claims.ID, nil
+	}
+	id, err := strconv.ParseUint(c.Params("mahasantri_id"), 10, 64)
+	return uint(id), err
+}
+
+func (s *logMurojaahService) GetRecapMingguan(c *fiber.Ctx) error {
+	mahasantriID, err := targetMahasantriID(c)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "ID Mahasantri tidak valid pada parameter URL", nil)
+	}
 
 	log := logrus.WithFields(logrus.Fields{"handler": "GetRecapMingguan", "mahasantriID": mahasantriID})
 	log.Info("Menerima permintaan untuk rekap mingguan")
@@ -382,7 +428,7 @@ func (s *logMurojaahService) GetRecapMingguan(c *fiber.Ctx) error {
 	endDate := time.Now()
 	startDate := endDate.AddDate(0, 0, -6)
 
-	err := s.DB.Model(&models.LogHarian{}).
+	err = s.DB.Model(&models.LogHarian{}).
 		Select("to_char(tanggal, 'DD-MM-YYYY') as tanggal, total_selesai_halaman").
 		Where("mahasantri_id = ? AND tanggal BETWEEN ? AND ?", mahasantriID, startDate, endDate).
 		Order("tanggal ASC").
@@ -420,10 +466,35 @@ func (s *logMurojaahService) GetAllLogsForMentorDashboard(c *fiber.Ctx) error {
 	tanggal = time.Date(tanggal.Year(), tanggal.Month(), tanggal.Day(), 0, 0, 0, 0, time.UTC)
 	log = log.WithField("tanggal", tanggal.Format("2006-01-02"))
 
-	var mahasantriIDs []uint
-	if err := s.DB.Model(&models.Mahasantri{}).Where("mentor_id = ?", mentorID).Pluck("id", &mahasantriIDs).Error; err != nil {
-		log.WithError(err).Error("Gagal mengambil daftar ID mahasantri bimbingan")
-		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal memproses data", err.Error())
+	mahasantriIDs, scoped, err := scopeHalaqah(c, s.DB, mentorID)
+	if err != nil {
+		return responseScopeHalaqahError(c, err)
+	}
+	statuses, err := parseFilterStatusMahasantri(c)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, err.Error(), nil)
+	}
+	if !scoped {
+		// Mahasantri dihitung dari periode bimbingan yang berlaku pada tanggal tersebut, bukan mentor saat ini
+		assignments, err := mentorAssignmentsInRange(s.DB, mentorID, tanggal, tanggal)
+		if err != nil {
+			log.WithError(err).Error("Gagal mengambil periode bimbingan mentor")
+			return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal memproses data", err.Error())
+		}
+		for _, a := range assignments {
+			mahasantriIDs = append(mahasantriIDs, a.MahasantriID)
+		}
+	}
+	if !scoped || statuses != nil {
+		query := s.DB.Model(&models.Mahasantri{}).Where("id IN ?", append(mahasantriIDs, 0))
+		if statuses != nil {
+			query = query.Where("status IN ?", statuses)
+		}
+		mahasantriIDs = nil
+		if err := query.Pluck("id", &mahasantriIDs).Error; err != nil {
+			log.WithError(err).Error("Gagal mengambil daftar ID mahasantri bimbingan")
+			return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal memproses data", err.Error())
+		}
 	}
 
 	if len(mahasantriIDs) == 0 {
@@ -440,6 +511,12 @@ func (s *logMurojaahService) GetAllLogsForMentorDashboard(c *fiber.Ctx) error {
 		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil data log", err.Error())
 	}
 
+	qadhaTertunda, err := countQadhaTertunda(s.DB, mahasantriIDs)
+	if err != nil {
+		log.WithError(err).Error("Gagal menghitung qadha tertunda")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal memproses data", err.Error())
+	}
+
 	responseDTOs := make([]dto.LogHarianForMentorResponse, len(logHarians))
 	for i, logHarian := range logHarians {
 		detailDTOs := make([]dto.DetailLogResponse, len(logHarian.DetailLogs))
@@ -467,8 +544,9 @@ func (s *logMurojaahService) GetAllLogsForMentorDashboard(c *fiber.Ctx) error {
 			TotalTargetHalaman:  logHarian.TotalTargetHalaman,
 			TotalSelesaiHalaman: logHarian.TotalSelesaiHalaman,
 			Mahasantri: dto.MahasantriInfoForLog{
-				ID:   logHarian.Mahasantri.ID,
-				Nama: logHarian.Mahasantri.Nama,
+				ID:            logHarian.Mahasantri.ID,
+				Nama:          logHarian.Mahasantri.Nama,
+				QadhaTertunda: qadhaTertunda[logHarian.Mahasantri.ID],
 			},
 			DetailLogs: detailDTOs,
 		}
@@ -479,8 +557,10 @@ func (s *logMurojaahService) GetAllLogsForMentorDashboard(c *fiber.Ctx) error {
 }
 
 func (s *logMurojaahService) GetStatistikMurojaah(c *fiber.Ctx) error {
-	claims := c.Locals("user").(*utils.Claims)
-	mahasantriID := claims.ID
+	mahasantriID, err := targetMahasantriID(c)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "ID Mahasantri tidak valid pada parameter URL", nil)
+	}
 
 	log := logrus.WithFields(logrus.Fields{"handler": "GetStatistikMurojaah", "mahasantriID": mahasantriID})
 	log.Info("Menerima permintaan untuk statistik murojaah")
@@ -489,7 +569,7 @@ func (s *logMurojaahService) GetStatistikMurojaah(c *fiber.Ctx) error {
 		TotalSelesai int
 		HariAktif    int
 	}
-	err := s.DB.Model(&models.LogHarian{}).
+	err = s.DB.Model(&models.LogHarian{}).
 		Select("SUM(total_selesai_halaman) as total_selesai, COUNT(id) as hari_aktif").
 		Where("mahasantri_id = ? AND total_selesai_halaman > 0", mahasantriID).
 		Scan(&stats).Error
@@ -551,9 +631,12 @@ func (s *logMurojaahService) GetStatistikMurojaah(c *fiber.Ctx) error {
 // GetRekapBimbinganMingguan - Mengambil rekapitulasi progres mingguan semua mahasantri bimbingan.
 // @Summary Rekapitulasi Mingguan Bimbingan
 // @Description Endpoint untuk mengambil rekapitulasi progres muroja'ah (total halaman target vs selesai) selama 7 hari terakhir untuk semua mahasantri yang diampu oleh mentor yang sedang login. Hasil diurutkan berdasarkan halaman selesai terbanyak.
+// @Description Mahasantri yang dipindahkan dalam 7 hari terakhir hanya dihitung pada periode bimbingan mentor tersebut. Secara default hanya mahasantri berstatus aktif yang ditampilkan.
 // @Tags Mentor
 // @Accept json
 // @Produce json
+// @Param halaqah_id query int false "Batasi ke anggota aktif halaqah yang diampu mentor"
+// @Param status query string false "Filter status (aktif, cuti, lulus, keluar, dipisah koma) atau semua" default(aktif)
 // @Success 200 {object} utils.Response "Rekapitulasi mingguan berhasil diambil"
 // @Failure 500 {object} utils.Response "Gagal mengambil data rekapitulasi"
 // @Security BearerAuth
@@ -572,21 +655,53 @@ func (s *logMurojaahService) GetRekapBimbinganMingguan(c *fiber.Ctx) error {
 	startDate := endDate.AddDate(0, 0, -6)
 	startDate = time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, time.UTC)
 
+	anggotaHalaqahIDs, scoped, err := scopeHalaqah(c, s.DB, mentorID)
+	if err != nil {
+		return responseScopeHalaqahError(c, err)
+	}
+	statuses, err := parseFilterStatusMahasantri(c)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, err.Error(), nil)
+	}
+
 	var results []dto.RekapBimbinganResponse
 
-	err := s.DB.Table("mahasantris as m").
+	query := s.DB.Table("mahasantris as m").
 		Select(`
 			m.id as mahasantri_id,
 			m.nama as nama_mahasantri,
 			COALESCE(SUM(lh.total_target_halaman), 0) as total_target_halaman_mingguan,
 			COALESCE(SUM(lh.total_selesai_halaman), 0) as total_selesai_halaman_mingguan
-		`).
-		Joins(
-			"LEFT JOIN log_harians as lh ON m.id = lh.mahasantri_id AND lh.tanggal BETWEEN ? AND ?",
-			startDate,
-			endDate,
-		).
-		Where("m.mentor_id = ?", mentorID).
+		`)
+	if scoped {
+		query = query.
+			Joins(
+				"LEFT JOIN log_harians as lh ON m.id = lh.mahasantri_id AND lh.tanggal BETWEEN ? AND ?",
+				startDate,
+				endDate,
+			).
+			Where("m.id IN ?", append(anggotaHalaqahIDs, 0))
+	} else {
+		// Hanya log pada periode mahasantri dibimbing oleh mentor ini yang dihitung
+		query = query.
+			Joins(
+				"JOIN mentor_assignments as ma ON ma.mahasantri_id = m.id AND ma.mentor_id = ? AND ma.mulai_tanggal <= ? AND (ma.selesai_tanggal IS NULL OR ma.selesai_tanggal > ?)",
+				mentorID,
+				endDate,
+				startDate,
+			).
+			Joins(
+				"LEFT JOIN log_harians as lh ON m.id = lh.mahasantri_id AND lh.tanggal BETWEEN ? AND ? AND lh.tanggal >= ma.mulai_tanggal AND (ma.selesai_tanggal IS NULL OR lh.tanggal < ma.selesai_tanggal)",
+				startDate,
+				endDate,
+			)
+	}
+	// Query tabel mentah tidak memakai soft delete GORM, mahasantri yang diarsipkan harus dikecualikan manual
+	query = query.Where("m.deleted_at IS NULL")
+	if statuses != nil {
+		query = query.Where("m.status IN ?", statuses)
+	}
+	err = query.
 		Group("m.id, m.nama").
 		Order("total_selesai_halaman_mingguan DESC").
 		Scan(&results).Error
@@ -614,6 +729,10 @@ func (s *logMurojaahService) ApplyAIRekomendasi(c *fiber.Ctx) error {
 
 	log := logrus.WithFields(logrus.Fields{"handler": "ApplyAIRekomendasi", "mahasantriID": mahasantriID})
 
+	if err := ensureMahasantriWritable(s.DB, mahasantriID); err != nil {
+		return responseMahasantriFrozen(c, err)
+	}
+
 	var req dto.ApplyAIRekomendasiRequest
 	if err := c.BodyParser(&req); err != nil {
 		return utils.ResponseError(c, fiber.StatusBadRequest, "Request body tidak valid", err.Error())
@@ -625,6 +744,9 @@ func (s *logMurojaahService) ApplyAIRekomendasi(c *fiber.Ctx) error {
 		if err := tx.Where("id = ? AND mahasantri_id = ?", req.RekomendasiID, mahasantriID).First(&rekomendasi).Error; err != nil {
 			return errors.New("riwayat rekomendasi tidak ditemukan atau bukan milik anda")
 		}
+		if rekomendasi.TipeRekomendasi == TipeRekomendasiTidakAda {
+			return errRekomendasiTanpaJadwal
+		}
 
 		today := time.Now()
 		today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
@@ -632,6 +754,9 @@ func (s *logMurojaahService) ApplyAIRekomendasi(c *fiber.Ctx) error {
 		if err := tx.Where(models.LogHarian{MahasantriID: mahasantriID, Tanggal: today}).FirstOrCreate(&logHarian).Error; err != nil {
 			return err
 		}
+		if err := catatJadwalLogHarian(tx, &logHarian); err != nil {
+			return err
+		}
 
 		totalTarget, err := calculateTotalPages(req.TargetStartJuz, req.TargetStartHalaman, req.TargetEndJuz, req.TargetEndHalaman)
 		if err != nil {
@@ -639,15 +764,16 @@ func (s *logMurojaahService) ApplyAIRekomendasi(c *fiber.Ctx) error {
 		}
 
 		newDetail = models.DetailLog{
-			LogHarianID:        logHarian.ID,
-			WaktuMurojaah:      fmt.Sprintf("AI: %s", rekomendasi.RekomendasiJadwal),
-			TargetStartJuz:     req.TargetStartJuz,
-			TargetStartHalaman: req.TargetStartHalaman,
-			TargetEndJuz:       req.TargetEndJuz,
-			TargetEndHalaman:   req.TargetEndHalaman,
-			TotalTargetHalaman: totalTarget,
-			Status:             models.StatusSesiBelumSelesai,
-			Catatan:            req.Catatan,
+			LogHarianID:         logHarian.ID,
+			WaktuMurojaah:       fmt.Sprintf("AI: %s", rekomendasi.RekomendasiJadwal),
+			TargetStartJuz:      req.TargetStartJuz,
+			TargetStartHalaman:  req.TargetStartHalaman,
+			TargetEndJuz:        req.TargetEndJuz,
+			TargetEndHalaman:    req.TargetEndHalaman,
+			TotalTargetHalaman:  totalTarget,
+			Status:              models.StatusSesiBelumSelesai,
+			Catatan:             req.Catatan,
+			JadwalRekomendasiID: &rekomendasi.ID,
 		}
 		if err := tx.Create(&newDetail).Error; err != nil {
 			return err
@@ -661,7 +787,7 @@ func (s *logMurojaahService) ApplyAIRekomendasi(c *fiber.Ctx) error {
 		if err.Error() == "riwayat rekomendasi tidak ditemukan atau bukan milik anda" {
 			return utils.ResponseError(c, fiber.StatusNotFound, err.Error(), nil)
 		}
-		if err.Error() == "target/progres akhir tidak boleh lebih kecil dari awal" {
+		if err.Error() == "target/progres akhir tidak boleh lebih kecil dari awal" || errors.Is(err, errRekomendasiTanpaJadwal) {
 			return utils.ResponseError(c, fiber.StatusBadRequest, err.Error(), nil)
 		}
 		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal menerapkan rekomendasi", err.Error())
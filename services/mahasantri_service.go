@@ -1,6 +1,7 @@
 package services
 
 import (
+	"errors"
 	"math"
 	"strconv"
 
@@ -13,12 +14,14 @@ import (
 )
 
 type MahasantriService struct {
-	DB *gorm.DB
+	DB       *gorm.DB
+	Notifier utils.Notifier
+	Storage  utils.Storage
 }
 
 // GetAllMahasantri - Mengambil semua mahasantri dengan pagination (Hanya untuk mentor)
 // @Summary Get All Mahasantri
-// @Description Get a list of all Mahasantri with pagination, only accessible by mentor
+// @Description Get a list of all Mahasantri with pagination, only accessible by mentor. Breaking change: this endpoint used to be public and now requires a mentor JWT; use /api/v1/mahasantri/search for filtered search.
 // @Tags Mahasantri
 // @Accept json
 // @Produce json
@@ -28,6 +31,8 @@ type MahasantriService struct {
 // @Security BearerAuth
 // @Success 200 {object} utils.Response{data=[]dto.MahasantriResponse,pagination=utils.Pagination} "Mahasantri retrieved successfully"
 // @Failure 400 {object} utils.Response "Invalid request"
+// @Failure 401 {object} utils.Response "Unauthorized"
+// @Failure 403 {object} utils.Response "Forbidden"
 // @Failure 500 {object} utils.Response "Internal server error"
 // @Router /api/v1/mahasantri [get]
 func (s *MahasantriService) GetAllMahasantri(c *fiber.Ctx) error {
@@ -65,6 +70,7 @@ func (s *MahasantriService) GetAllMahasantri(c *fiber.Ctx) error {
 			Jurusan:  m.Jurusan,
 			Gender:   m.Gender,
 			MentorID: m.MentorID,
+			Status:   m.Status,
 		}
 	}
 
@@ -120,6 +126,12 @@ func (s *MahasantriService) GetMahasantriByID(c *fiber.Ctx) error {
 		}
 	}
 
+	qadhaTertunda, err := countQadhaTertunda(s.DB, []uint{mahasantri.ID})
+	if err != nil {
+		logrus.WithError(err).Error("Failed to count outstanding qadha")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to fetch mahasantri", err.Error())
+	}
+
 	response := dto.MahasantriResponse{
 		ID:                   mahasantri.ID,
 		Nama:                 mahasantri.Nama,
@@ -127,8 +139,11 @@ func (s *MahasantriService) GetMahasantriByID(c *fiber.Ctx) error {
 		Jurusan:              mahasantri.Jurusan,
 		Gender:               mahasantri.Gender,
 		MentorID:             mahasantri.MentorID,
+		Status:               mahasantri.Status,
 		IsDataMurojaahFilled: mahasantri.IsDataMurojaahFilled,
+		QadhaTertunda:        qadhaTertunda[mahasantri.ID],
 		JadwalPersonal:       jadwalPersonalDTO,
+		ProfilMahasantri:     newProfilMahasantri(mahasantri),
 	}
 
 	logrus.WithFields(logrus.Fields{
@@ -145,6 +160,8 @@ func (s *MahasantriService) GetMahasantriByID(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param mentor_id path int true "ID Mentor"
+// @Param halaqah_id query int false "Batasi ke anggota aktif halaqah yang diampu mentor"
+// @Param status query string false "Filter status (aktif, cuti, lulus, keluar, dipisah koma) atau semua" default(aktif)
 // @Success 200 {array} dto.MahasantriResponse "List of Mahasantri"
 // @Failure 400 {object} utils.Response "Invalid mentor ID format"
 // @Failure 500 {object} utils.Response "Failed to fetch mahasantri for mentor"
@@ -158,12 +175,43 @@ func (s *MahasantriService) GetMahasantriByMentorID(c *fiber.Ctx) error {
 		return utils.ResponseError(c, fiber.StatusBadRequest, "Invalid mentor ID format", nil)
 	}
 
+	mentorIDUint, _ := strconv.ParseUint(mentorID, 10, 64)
+	anggotaHalaqahIDs, scoped, err := scopeHalaqah(c, s.DB, uint(mentorIDUint))
+	if err != nil {
+		return responseScopeHalaqahError(c, err)
+	}
+
+	statuses, err := parseFilterStatusMahasantri(c)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, err.Error(), nil)
+	}
+
+	query := s.DB.Preload("JadwalPersonal")
+	if statuses != nil {
+		query = query.Where("status IN ?", statuses)
+	}
+	if scoped {
+		query = query.Where("id IN ?", append(anggotaHalaqahIDs, 0))
+	} else {
+		query = query.Where("mentor_id = ?", mentorID)
+	}
+
 	var mahasantriList []models.Mahasantri
-	if err := s.DB.Preload("JadwalPersonal").Where("mentor_id = ?", mentorID).Find(&mahasantriList).Error; err != nil {
+	if err := query.Find(&mahasantriList).Error; err != nil {
 		logrus.WithError(err).Error("Failed to fetch mahasantri for mentor")
 		return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to fetch mahasantri for mentor", err.Error())
 	}
 
+	mahasantriIDs := make([]uint, len(mahasantriList))
+	for i, m := range mahasantriList {
+		mahasantriIDs[i] = m.ID
+	}
+	qadhaTertunda, err := countQadhaTertunda(s.DB, mahasantriIDs)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to count outstanding qadha")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to fetch mahasantri for mentor", err.Error())
+	}
+
 	response := make([]dto.MahasantriResponse, len(mahasantriList))
 	for i, m := range mahasantriList {
 		var jadwalPersonalDTO *dto.JadwalPersonalResponse
@@ -184,8 +232,11 @@ func (s *MahasantriService) GetMahasantriByMentorID(c *fiber.Ctx) error {
 			Jurusan:              m.Jurusan,
 			Gender:               m.Gender,
 			MentorID:             m.MentorID,
+			Status:               m.Status,
 			IsDataMurojaahFilled: m.IsDataMurojaahFilled,
+			QadhaTertunda:        qadhaTertunda[m.ID],
 			JadwalPersonal:       jadwalPersonalDTO,
+			ProfilMahasantri:     newProfilMahasantri(m),
 		}
 	}
 
@@ -198,7 +249,7 @@ func (s *MahasantriService) GetMahasantriByMentorID(c *fiber.Ctx) error {
 
 // UpdateMahasantri - Memperbarui data mahasantri berdasarkan ID (Hanya untuk mentor)
 // @Summary Memperbarui data mahasantri berdasarkan ID
-// @Description Memperbarui data mahasantri seperti nama, NIM, jurusan, dan gender berdasarkan ID. Hanya dapat diakses oleh mentor.
+// @Description Memperbarui data mahasantri seperti nama, NIM, jurusan, gender, serta profil (angkatan, asrama, kamar, kontak, wali, tanggal lahir) berdasarkan ID. Hanya dapat diakses oleh mentor.
 // @Tags Mahasantri
 // @Accept json
 // @Produce json
@@ -218,6 +269,9 @@ func (s *MahasantriService) UpdateMahasantri(c *fiber.Ctx) error {
 		logrus.WithError(err).Warn("Mahasantri not found")
 		return utils.ResponseError(c, fiber.StatusNotFound, "Mahasantri not found", nil)
 	}
+	if mahasantri.Status == models.StatusMahasantriLulus {
+		return responseMahasantriFrozen(c, errMahasantriAlumni)
+	}
 
 	// Bind request body ke DTO
 	var updateRequest dto.UpdateMahasantriRequest
@@ -239,12 +293,24 @@ func (s *MahasantriService) UpdateMahasantri(c *fiber.Ctx) error {
 		mahasantri.Jurusan = *updateRequest.Jurusan
 		updated = true
 	}
-	if updateRequest.Gender != nil && *updateRequest.Gender != mahasantri.Gender {
+	genderBerubah := updateRequest.Gender != nil && *updateRequest.Gender != mahasantri.Gender
+	if genderBerubah {
 		mahasantri.Gender = *updateRequest.Gender
 		updated = true
 	}
-	if updateRequest.MentorID != nil && *updateRequest.MentorID != mahasantri.MentorID {
-		mahasantri.MentorID = *updateRequest.MentorID
+	// Pergantian mentor dicatat sebagai pemindahan agar riwayat bimbingan tetap tersimpan
+	pindahMentor := updateRequest.MentorID != nil && *updateRequest.MentorID != mahasantri.MentorID
+	if pindahMentor {
+		if claims, ok := c.Locals("user").(*utils.Claims); ok && (claims.Role != RoleMentor || claims.ID != mahasantri.MentorID) {
+			return utils.ResponseError(c, fiber.StatusForbidden, errBukanMentorPembimbing.Error(), nil)
+		}
+		updated = true
+	}
+	profilUpdated, err := applyProfilMahasantri(&mahasantri, updateRequest)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, err.Error(), nil)
+	}
+	if profilUpdated {
 		updated = true
 	}
 
@@ -254,11 +320,59 @@ func (s *MahasantriService) UpdateMahasantri(c *fiber.Ctx) error {
 	}
 
 	// Simpan perubahan ke database
-	if err := s.DB.Save(&mahasantri).Error; err != nil {
+	var transfer mentorTransfer
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("mentor_id").Save(&mahasantri).Error; err != nil {
+			return err
+		}
+		if !pindahMentor {
+			// Gender baru tetap harus sesuai dengan mentor saat ini
+			if genderBerubah {
+				var mentor models.Mentor
+				if err := tx.Select("id", "gender").First(&mentor, mahasantri.MentorID).Error; err != nil {
+					return err
+				}
+				if mentor.Gender != mahasantri.Gender {
+					return errGenderMentorTidakSesuai
+				}
+			}
+			return nil
+		}
+
+		var oleh *uint
+		if claims, ok := c.Locals("user").(*utils.Claims); ok && claims.Role == RoleMentor {
+			oleh = &claims.ID
+		}
+		today, _ := parseTanggalOrToday("")
+		if err := kunciMentor(tx, *updateRequest.MentorID); err != nil {
+			return err
+		}
+		var err error
+		transfer, err = transferMentor(tx, mahasantri.ID, *updateRequest.MentorID, today, "", oleh)
+		if err != nil {
+			return err
+		}
+		return cekKapasitasMentor(tx, *updateRequest.MentorID)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.ResponseError(c, fiber.StatusBadRequest, "Invalid mentor ID", nil)
+		}
+		if errors.Is(err, errKapasitasMentorPenuh) {
+			return utils.ResponseError(c, fiber.StatusUnprocessableEntity, err.Error(), nil)
+		}
+		if errors.Is(err, errGenderMentorTidakSesuai) {
+			return utils.ResponseError(c, fiber.StatusBadRequest, err.Error(), nil)
+		}
 		logrus.WithError(err).Error("Failed to update mahasantri")
 		return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to update mahasantri", err.Error())
 	}
 
+	if pindahMentor {
+		mahasantri.MentorID = transfer.MentorBaruID
+		notifyMentorTransfer(s.Notifier, transfer)
+	}
+
 	logrus.WithFields(logrus.Fields{
 		"mahasantri_id": mahasantri.ID,
 	}).Info("Mahasantri updated successfully")
@@ -271,6 +385,9 @@ func (s *MahasantriService) UpdateMahasantri(c *fiber.Ctx) error {
 		Jurusan:  mahasantri.Jurusan,
 		Gender:   mahasantri.Gender,
 		MentorID: mahasantri.MentorID,
+		Status:   mahasantri.Status,
+
+		ProfilMahasantri: newProfilMahasantri(mahasantri),
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Mahasantri updated successfully", response)
@@ -278,7 +395,7 @@ func (s *MahasantriService) UpdateMahasantri(c *fiber.Ctx) error {
 
 // DeleteMahasantri - Menghapus mahasantri berdasarkan ID (Hanya untuk mentor)
 // @Summary Menghapus mahasantri berdasarkan ID
-// @Description Menghapus data mahasantri berdasarkan ID. Hanya dapat diakses oleh mentor.
+// @Description Mengarsipkan (soft delete) data mahasantri berdasarkan ID. Hafalan, absensi, target, dan log tetap tersimpan dan dapat dipulihkan. Hanya dapat diakses oleh mentor.
 // @Tags Mahasantri
 // @Accept json
 // @Produce json
@@ -297,7 +414,10 @@ func (s *MahasantriService) DeleteMahasantri(c *fiber.Ctx) error {
 		return utils.ResponseError(c, fiber.StatusNotFound, "Mahasantri not found", nil)
 	}
 
-	s.DB.Delete(&mahasantri)
+	if err := s.DB.Delete(&mahasantri).Error; err != nil {
+		logrus.WithError(err).Error("Failed to delete mahasantri")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to delete mahasantri", err.Error())
+	}
 	logrus.WithFields(logrus.Fields{
 		"mahasantri_id": mahasantri.ID,
 	}).Info("Mahasantri deleted successfully")
@@ -0,0 +1,321 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/mahad-service-go/dto"
+	"github.com/habbazettt/mahad-service-go/models"
+	"github.com/habbazettt/mahad-service-go/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errMentorSama            = errors.New("mahasantri sudah dibimbing oleh mentor tersebut")
+	errTanggalEfektifInvalid = errors.New("tanggal efektif tidak boleh sebelum periode mentor saat ini dimulai atau setelah hari ini")
+	errBukanMentorPembimbing = errors.New("hanya mentor pembimbing saat ini yang dapat memindahkan mahasantri")
+)
+
+// mentorTransfer adalah hasil pemindahan mentor yang digunakan untuk notifikasi
+type mentorTransfer struct {
+	Mahasantri     models.Mahasantri
+	MentorLamaID   uint
+	MentorBaruID   uint
+	TanggalEfektif time.Time
+}
+
+// createInitialMentorAssignment mencatat periode mentor pertama saat mahasantri didaftarkan
+func createInitialMentorAssignment(tx *gorm.DB, mahasantri models.Mahasantri) error {
+	now := time.Now()
+	return tx.Create(&models.MentorAssignment{
+		MahasantriID: mahasantri.ID,
+		MentorID:     mahasantri.MentorID,
+		MulaiTanggal: time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+	}).Error
+}
+
+// transferMentor menutup periode mentor yang sedang berlaku dan membuka periode baru mulai tanggal efektif.
+// Absensi, hafalan, dan qadha yang belum selesai sejak tanggal efektif ikut dipindahkan ke mentor baru,
+// sedangkan data sebelum tanggal efektif tetap tercatat atas nama mentor lama.
+func transferMentor(tx *gorm.DB, mahasantriID, mentorBaruID uint, tanggalEfektif time.Time, alasan string, oleh *uint) (mentorTransfer, error) {
+	var result mentorTransfer
+
+	var mahasantri models.Mahasantri
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&mahasantri, mahasantriID).Error; err != nil {
+		return result, err
+	}
+	if mahasantri.Status == models.StatusMahasantriLulus {
+		return result, errMahasantriAlumni
+	}
+	if mahasantri.MentorID == mentorBaruID {
+		return result, errMentorSama
+	}
+
+	var mentorBaru models.Mentor
+	if err := tx.First(&mentorBaru, mentorBaruID).Error; err != nil {
+		return result, err
+	}
+	if mentorBaru.Gender != mahasantri.Gender {
+		return result, errGenderMentorTidakSesuai
+	}
+
+	var current models.MentorAssignment
+	err := tx.Where("mahasantri_id = ? AND selesai_tanggal IS NULL", mahasantriID).
+		Order("mulai_tanggal DESC").First(&current).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return result, err
+	}
+
+	today := time.Now()
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	if tanggalEfektif.After(today) || (err == nil && tanggalEfektif.Before(current.MulaiTanggal)) {
+		return result, errTanggalEfektifInvalid
+	}
+
+	if err == nil {
+		if err := tx.Model(&current).Update("selesai_tanggal", tanggalEfektif).Error; err != nil {
+			return result, err
+		}
+	}
+
+	if err := tx.Create(&models.MentorAssignment{
+		MahasantriID:    mahasantriID,
+		MentorID:        mentorBaruID,
+		MulaiTanggal:    tanggalEfektif,
+		Alasan:          alasan,
+		DipindahkanOleh: oleh,
+	}).Error; err != nil {
+		return result, err
+	}
+
+	mentorLamaID := mahasantri.MentorID
+	if err := tx.Model(&mahasantri).Update("mentor_id", mentorBaruID).Error; err != nil {
+		return result, err
+	}
+
+	// Data sejak tanggal efektif menjadi tanggung jawab mentor baru
+	if err := tx.Model(&models.Absensi{}).
+		Where("mahasantri_id = ? AND mentor_id = ? AND tanggal >= ?", mahasantriID, mentorLamaID, tanggalEfektif).
+		Update("mentor_id", mentorBaruID).Error; err != nil {
+		return result, err
+	}
+	if err := tx.Model(&models.Hafalan{}).
+		Where("mahasantri_id = ? AND mentor_id = ? AND created_at >= ?", mahasantriID, mentorLamaID, tanggalEfektif).
+		Update("mentor_id", mentorBaruID).Error; err != nil {
+		return result, err
+	}
+	if err := tx.Model(&models.Qadha{}).
+		Where("mahasantri_id = ? AND status <> ?", mahasantriID, models.StatusQadhaSelesai).
+		Update("mentor_id", mentorBaruID).Error; err != nil {
+		return result, err
+	}
+
+	mahasantri.MentorID = mentorBaruID
+	result = mentorTransfer{
+		Mahasantri:     mahasantri,
+		MentorLamaID:   mentorLamaID,
+		MentorBaruID:   mentorBaruID,
+		TanggalEfektif: tanggalEfektif,
+	}
+	return result, nil
+}
+
+// notifyMentorTransfer memberi tahu mentor lama dan mentor baru tentang pemindahan mahasantri
+func notifyMentorTransfer(notifier utils.Notifier, t mentorTransfer) {
+	data := map[string]interface{}{
+		"mahasantri_id":   t.Mahasantri.ID,
+		"mentor_lama_id":  t.MentorLamaID,
+		"mentor_baru_id":  t.MentorBaruID,
+		"tanggal_efektif": t.TanggalEfektif.Format("02-01-2006"),
+	}
+
+	utils.SendNotifications(notifier,
+		utils.Notification{
+			RecipientRole: RoleMentor,
+			RecipientID:   t.MentorLamaID,
+			Title:         "Mahasantri dipindahkan",
+			Message:       fmt.Sprintf("%s (%s) tidak lagi dalam bimbingan Anda sejak %s", t.Mahasantri.Nama, t.Mahasantri.NIM, t.TanggalEfektif.Format("02-01-2006")),
+			Data:          data,
+		},
+		utils.Notification{
+			RecipientRole: RoleMentor,
+			RecipientID:   t.MentorBaruID,
+			Title:         "Mahasantri bimbingan baru",
+			Message:       fmt.Sprintf("%s (%s) menjadi mahasantri bimbingan Anda sejak %s", t.Mahasantri.Nama, t.Mahasantri.NIM, t.TanggalEfektif.Format("02-01-2006")),
+			Data:          data,
+		},
+	)
+}
+
+// mentorAssignmentsInRange mengambil periode bimbingan mentor yang beririsan dengan rentang tanggal
+func mentorAssignmentsInRange(db *gorm.DB, mentorID uint, startDate, endDate time.Time) ([]models.MentorAssignment, error) {
+	var assignments []models.MentorAssignment
+	err := db.Where("mentor_id = ? AND mulai_tanggal <= ? AND (selesai_tanggal IS NULL OR selesai_tanggal > ?)", mentorID, endDate, startDate).
+		Find(&assignments).Error
+	return assignments, err
+}
+
+// isDalamPeriodeAssignment memeriksa apakah tanggal termasuk dalam salah satu periode bimbingan
+func isDalamPeriodeAssignment(assignments []models.MentorAssignment, tanggal time.Time) bool {
+	t := time.Date(tanggal.Year(), tanggal.Month(), tanggal.Day(), 0, 0, 0, 0, time.UTC)
+	for _, a := range assignments {
+		mulai := time.Date(a.MulaiTanggal.Year(), a.MulaiTanggal.Month(), a.MulaiTanggal.Day(), 0, 0, 0, 0, time.UTC)
+		if t.Before(mulai) {
+			continue
+		}
+		if a.SelesaiTanggal != nil {
+			selesai := time.Date(a.SelesaiTanggal.Year(), a.SelesaiTanggal.Month(), a.SelesaiTanggal.Day(), 0, 0, 0, 0, time.UTC)
+			if !t.Before(selesai) {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// TransferMentor - Memindahkan mahasantri ke mentor lain
+// @Summary Memindahkan mahasantri ke mentor lain
+// @Description Memindahkan mahasantri ke mentor baru mulai tanggal efektif dan mencatat riwayatnya. Hanya mentor pembimbing saat ini yang dapat memindahkan mahasantri. Data sebelum tanggal efektif tetap tercatat atas nama mentor lama. Mentor lama dan mentor baru akan menerima notifikasi.
+// @Tags Mahasantri
+// @Accept json
+// @Produce json
+// @Param id path int true "ID Mahasantri"
+// @Param request body dto.TransferMentorRequest true "Mentor tujuan dan tanggal efektif"
+// @Success 200 {object} utils.Response "Mahasantri berhasil dipindahkan"
+// @Failure 400 {object} utils.Response "Request tidak valid"
+// @Failure 403 {object} utils.Response "Bukan mentor pembimbing mahasantri"
+// @Failure 404 {object} utils.Response "Mahasantri atau mentor tidak ditemukan"
+// @Failure 422 {object} utils.Response "Mentor tujuan sudah mencapai batas bimbingan"
+// @Failure 500 {object} utils.Response "Gagal memindahkan mahasantri"
+// @Security BearerAuth
+// @Router /api/v1/mahasantri/{id}/transfer [post]
+func (s *MahasantriService) TransferMentor(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Invalid ID format", nil)
+	}
+
+	var req dto.TransferMentorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+	if req.MentorID == 0 {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "mentor_id wajib diisi", nil)
+	}
+
+	tanggalEfektif, err := parseTanggalOrToday(req.TanggalEfektif)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Format tanggal tidak valid, gunakan DD-MM-YYYY", nil)
+	}
+
+	log := logrus.WithFields(logrus.Fields{
+		"handler":        "TransferMentor",
+		"mahasantri_id":  id,
+		"mentor_baru_id": req.MentorID,
+	})
+
+	var transfer mentorTransfer
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		var mahasantri models.Mahasantri
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "mentor_id").First(&mahasantri, id).Error; err != nil {
+			return err
+		}
+		if mahasantri.MentorID != claims.ID {
+			return errBukanMentorPembimbing
+		}
+		if err := kunciMentor(tx, req.MentorID); err != nil {
+			return err
+		}
+
+		var err error
+		transfer, err = transferMentor(tx, uint(id), req.MentorID, tanggalEfektif, req.Alasan, &claims.ID)
+		if err != nil {
+			return err
+		}
+		return cekKapasitasMentor(tx, req.MentorID)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return utils.ResponseError(c, fiber.StatusNotFound, "Mahasantri atau mentor tidak ditemukan", nil)
+		case errors.Is(err, errMentorSama), errors.Is(err, errTanggalEfektifInvalid), errors.Is(err, errGenderMentorTidakSesuai):
+			return utils.ResponseError(c, fiber.StatusBadRequest, err.Error(), nil)
+		case errors.Is(err, errKapasitasMentorPenuh):
+			return utils.ResponseError(c, fiber.StatusUnprocessableEntity, errKapasitasMentorPenuh.Error(), nil)
+		case errors.Is(err, errBukanMentorPembimbing):
+			return utils.ResponseError(c, fiber.StatusForbidden, err.Error(), nil)
+		case errors.Is(err, errMahasantriAlumni):
+			return responseMahasantriFrozen(c, err)
+		}
+		log.WithError(err).Error("Gagal memindahkan mahasantri")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal memindahkan mahasantri", err.Error())
+	}
+
+	notifyMentorTransfer(s.Notifier, transfer)
+
+	log.WithField("mentor_lama_id", transfer.MentorLamaID).Info("Mahasantri berhasil dipindahkan")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Mahasantri berhasil dipindahkan", dto.MahasantriResponse{
+		ID:                   transfer.Mahasantri.ID,
+		Nama:                 transfer.Mahasantri.Nama,
+		NIM:                  transfer.Mahasantri.NIM,
+		Jurusan:              transfer.Mahasantri.Jurusan,
+		Gender:               transfer.Mahasantri.Gender,
+		MentorID:             transfer.Mahasantri.MentorID,
+		Status:               transfer.Mahasantri.Status,
+		IsDataMurojaahFilled: transfer.Mahasantri.IsDataMurojaahFilled,
+	})
+}
+
+// GetRiwayatMentor - Mengambil riwayat mentor pembimbing mahasantri
+// @Summary Riwayat mentor mahasantri
+// @Description Mengambil seluruh periode bimbingan mahasantri, diurutkan dari yang terbaru.
+// @Tags Mahasantri
+// @Produce json
+// @Param id path int true "ID Mahasantri"
+// @Success 200 {object} utils.Response "Riwayat mentor berhasil diambil"
+// @Failure 400 {object} utils.Response "Invalid ID format"
+// @Failure 500 {object} utils.Response "Gagal mengambil riwayat mentor"
+// @Security BearerAuth
+// @Router /api/v1/mahasantri/{id}/riwayat-mentor [get]
+func (s *MahasantriService) GetRiwayatMentor(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Invalid ID format", nil)
+	}
+	if claims.Role == RoleMahasantri && uint(id) != claims.ID {
+		return utils.ResponseError(c, fiber.StatusForbidden, "You are not authorized to access this resource", nil)
+	}
+
+	var assignments []models.MentorAssignment
+	if err := s.DB.Preload("Mentor").
+		Where("mahasantri_id = ?", id).
+		Order("mulai_tanggal DESC, id DESC").
+		Find(&assignments).Error; err != nil {
+		logrus.WithError(err).WithField("mahasantri_id", id).Error("Gagal mengambil riwayat mentor")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil riwayat mentor", err.Error())
+	}
+
+	response := make([]dto.MentorAssignmentResponse, len(assignments))
+	for i, a := range assignments {
+		response[i] = dto.MentorAssignmentResponse{
+			ID:              a.ID,
+			MentorID:        a.MentorID,
+			NamaMentor:      a.Mentor.Nama,
+			MulaiTanggal:    a.MulaiTanggal.Format("02-01-2006"),
+			Alasan:          a.Alasan,
+			DipindahkanOleh: a.DipindahkanOleh,
+		}
+		if a.SelesaiTanggal != nil {
+			response[i].SelesaiTanggal = a.SelesaiTanggal.Format("02-01-2006")
+		}
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Riwayat mentor berhasil diambil", response)
+}
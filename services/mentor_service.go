@@ -261,13 +261,14 @@ func (s *MentorService) UpdateMentor(c *fiber.Ctx) error {
 
 // DeleteMentor - Menghapus mentor berdasarkan ID
 // @Summary Menghapus mentor berdasarkan ID
-// @Description Endpoint untuk menghapus data mentor berdasarkan ID. Pastikan mentor yang ingin dihapus ada di database.
+// @Description Endpoint untuk mengarsipkan (soft delete) data mentor berdasarkan ID. Mentor yang masih memiliki mahasantri bimbingan aktif harus memindahkan mahasantrinya terlebih dahulu.
 // @Tags Mentor
 // @Accept json
 // @Produce json
 // @Param id path int true "Mentor ID"
 // @Success 200 {object} utils.Response "Mentor deleted successfully"
 // @Failure 404 {object} utils.Response "Mentor not found"
+// @Failure 409 {object} utils.Response "Mentor still has active mahasantri"
 // @Failure 500 {object} utils.Response "Failed to delete mentor"
 // @Security BearerAuth
 // @Router /api/v1/mentors/{id} [delete]
@@ -280,7 +281,19 @@ func (s *MentorService) DeleteMentor(c *fiber.Ctx) error {
 		return utils.ResponseError(c, fiber.StatusNotFound, "Mentor not found", nil)
 	}
 
-	s.DB.Delete(&mentor)
+	var mahasantriCount int64
+	if err := s.DB.Model(&models.Mahasantri{}).Where("mentor_id = ?", mentor.ID).Count(&mahasantriCount).Error; err != nil {
+		logrus.WithError(err).Error("Failed to count mahasantri")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to delete mentor", err.Error())
+	}
+	if mahasantriCount > 0 {
+		return utils.ResponseError(c, fiber.StatusConflict, "Mentor still has active mahasantri, transfer them first", fiber.Map{"mahasantri_count": mahasantriCount})
+	}
+
+	if err := s.DB.Delete(&mentor).Error; err != nil {
+		logrus.WithError(err).Error("Failed to delete mentor")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Failed to delete mentor", err.Error())
+	}
 	logrus.WithFields(logrus.Fields{
 		"mentor_id": mentor.ID,
 	}).Info("Mentor deleted successfully")
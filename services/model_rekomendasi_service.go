@@ -0,0 +1,266 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/mahad-service-go/config"
+	"github.com/habbazettt/mahad-service-go/dto"
+	"github.com/habbazettt/mahad-service-go/models"
+	"github.com/habbazettt/mahad-service-go/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const maxModelFileSize = 2 << 20 // 2 MB
+
+var errModelFileTooLarge = errors.New("ukuran file model maksimal 2 MB")
+
+type ModelRekomendasiService interface {
+	GetAllModelRekomendasi(c *fiber.Ctx) error
+	UploadModelRekomendasi(c *fiber.Ctx) error
+	AktifkanModelRekomendasi(c *fiber.Ctx) error
+	RollbackModelRekomendasi(c *fiber.Ctx) error
+}
+
+type modelRekomendasiService struct {
+	DB *gorm.DB
+}
+
+func NewModelRekomendasiService(db *gorm.DB) ModelRekomendasiService {
+	return &modelRekomendasiService{DB: db}
+}
+
+func toModelRekomendasiResponse(m models.ModelRekomendasi) dto.ModelRekomendasiResponse {
+	return dto.ModelRekomendasiResponse{
+		Versi:       m.Versi,
+		Checksum:    m.Checksum,
+		JumlahState: m.JumlahState,
+		JumlahAksi:  m.JumlahAksi,
+		Keterangan:  m.Keterangan,
+		IsActive:    m.IsActive,
+		AktifSejak:  m.AktifSejak,
+		CreatedAt:   m.CreatedAt,
+	}
+}
+
+func readModelFile(fileHeader *multipart.FileHeader) ([]byte, error) {
+	if fileHeader.Size > maxModelFileSize {
+		return nil, errModelFileTooLarge
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxModelFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxModelFileSize {
+		return nil, errModelFileTooLarge
+	}
+	return data, nil
+}
+
+// responseAktivasiModel mengaktifkan versi model dan mengembalikan metadata versi tersebut
+func (s *modelRekomendasiService) responseAktivasiModel(c *fiber.Ctx, versi int, message string) error {
+	log := logrus.WithField("versi", versi)
+
+	if _, err := config.ActivateModelVersion(s.DB, versi); err != nil {
+		if errors.Is(err, config.ErrModelVersiNotFound) {
+			return utils.ResponseError(c, fiber.StatusNotFound, err.Error(), nil)
+		}
+		log.WithError(err).Error("Gagal mengaktifkan model rekomendasi")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengaktifkan model rekomendasi", err.Error())
+	}
+
+	var model models.ModelRekomendasi
+	if err := s.DB.Where("versi = ?", versi).First(&model).Error; err != nil {
+		log.WithError(err).Error("Gagal mengambil model rekomendasi")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil model rekomendasi", err.Error())
+	}
+
+	log.Info("Model rekomendasi diaktifkan")
+	return utils.SuccessResponse(c, fiber.StatusOK, message, toModelRekomendasiResponse(model))
+}
+
+// GetAllModelRekomendasi godoc
+// @Summary Daftar versi model rekomendasi
+// @Description Mengambil seluruh versi model Q-learning beserta metadatanya, diurutkan dari versi terbaru.
+// @Tags Model Rekomendasi
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Success 200 {object} utils.Response{data=[]dto.ModelRekomendasiResponse} "Daftar versi model berhasil diambil"
+// @Failure 403 {object} utils.Response "Admin key tidak valid"
+// @Failure 500 {object} utils.Response "Gagal mengambil daftar versi model"
+// @Router /api/v1/model-rekomendasi [get]
+func (s *modelRekomendasiService) GetAllModelRekomendasi(c *fiber.Ctx) error {
+	var modelList []models.ModelRekomendasi
+	if err := s.DB.Omit("q_table", "historical_best").Order("versi DESC").Find(&modelList).Error; err != nil {
+		logrus.WithError(err).Error("Gagal mengambil daftar versi model")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil daftar versi model", err.Error())
+	}
+
+	responses := make([]dto.ModelRekomendasiResponse, len(modelList))
+	for i, m := range modelList {
+		responses[i] = toModelRekomendasiResponse(m)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, "Daftar versi model berhasil diambil", responses)
+}
+
+// UploadModelRekomendasi godoc
+// @Summary Mengunggah versi model rekomendasi
+// @Description Mengunggah Q-Table (dan opsional data historis) sebagai versi model baru. File divalidasi sebelum disimpan.
+// @Description Jika historical_best tidak diunggah, data historis versi aktif dipakai. Versi baru langsung diaktifkan kecuali aktifkan=false.
+// @Tags Model Rekomendasi
+// @Accept multipart/form-data
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Param q_table formData file true "File JSON Q-Table"
+// @Param historical_best formData file false "File JSON data historis"
+// @Param keterangan formData string false "Keterangan versi"
+// @Param aktifkan query bool false "Langsung aktifkan versi baru" default(true)
+// @Success 201 {object} utils.Response{data=dto.ModelRekomendasiResponse} "Versi model berhasil diunggah"
+// @Failure 400 {object} utils.Response "File model tidak valid"
+// @Failure 403 {object} utils.Response "Admin key tidak valid"
+// @Failure 413 {object} utils.Response "File terlalu besar"
+// @Failure 500 {object} utils.Response "Gagal menyimpan versi model"
+// @Router /api/v1/model-rekomendasi [post]
+func (s *modelRekomendasiService) UploadModelRekomendasi(c *fiber.Ctx) error {
+	qTableHeader, err := c.FormFile("q_table")
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "File Q-Table wajib diunggah pada field 'q_table'", err.Error())
+	}
+	qTableJSON, err := readModelFile(qTableHeader)
+	if err != nil {
+		if errors.Is(err, errModelFileTooLarge) {
+			return utils.ResponseError(c, fiber.StatusRequestEntityTooLarge, err.Error(), nil)
+		}
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Gagal membaca file Q-Table", err.Error())
+	}
+
+	var historicalJSON []byte
+	if historicalHeader, err := c.FormFile("historical_best"); err == nil {
+		historicalJSON, err = readModelFile(historicalHeader)
+		if err != nil {
+			if errors.Is(err, errModelFileTooLarge) {
+				return utils.ResponseError(c, fiber.StatusRequestEntityTooLarge, err.Error(), nil)
+			}
+			return utils.ResponseError(c, fiber.StatusBadRequest, "Gagal membaca file data historis", err.Error())
+		}
+	} else {
+		var aktif models.ModelRekomendasi
+		if err := s.DB.Select("historical_best").Where("is_active = ?", true).First(&aktif).Error; err == nil && aktif.HistoricalBest != nil {
+			historicalJSON = []byte(*aktif.HistoricalBest)
+		}
+	}
+
+	qTable, _, err := config.ParseModel(qTableJSON, historicalJSON)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "File model tidak valid", err.Error())
+	}
+
+	aktifkan := true
+	if raw := c.Query("aktifkan"); raw != "" {
+		aktifkan, err = strconv.ParseBool(raw)
+		if err != nil {
+			return utils.ResponseError(c, fiber.StatusBadRequest, "Nilai aktifkan harus true atau false", nil)
+		}
+	}
+
+	var model models.ModelRekomendasi
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		// Kunci tabel agar dua unggahan bersamaan tidak mendapat nomor versi yang sama
+		if err := tx.Exec("LOCK TABLE model_rekomendasis IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+			return err
+		}
+		var versiTerakhir int
+		if err := tx.Model(&models.ModelRekomendasi{}).Select("COALESCE(MAX(versi), 0)").Scan(&versiTerakhir).Error; err != nil {
+			return err
+		}
+		model = config.NewModelRekomendasi(versiTerakhir+1, qTableJSON, historicalJSON, qTable, strings.TrimSpace(c.FormValue("keterangan")))
+		return tx.Create(&model).Error
+	})
+	if err != nil {
+		logrus.WithError(err).Error("Gagal menyimpan versi model")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal menyimpan versi model", err.Error())
+	}
+
+	log := logrus.WithFields(logrus.Fields{"versi": model.Versi, "checksum": model.Checksum})
+	if aktifkan {
+		if _, err := config.ActivateModelVersion(s.DB, model.Versi); err != nil {
+			log.WithError(err).Error("Versi model tersimpan tetapi gagal diaktifkan")
+			return utils.ResponseError(c, fiber.StatusInternalServerError, "Versi model tersimpan tetapi gagal diaktifkan", err.Error())
+		}
+		if err := s.DB.Where("versi = ?", model.Versi).First(&model).Error; err != nil {
+			log.WithError(err).Error("Gagal mengambil model rekomendasi")
+			return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil model rekomendasi", err.Error())
+		}
+	}
+
+	log.WithField("aktif", aktifkan).Info("Versi model rekomendasi berhasil diunggah")
+	return utils.SuccessResponse(c, fiber.StatusCreated, "Versi model berhasil diunggah", toModelRekomendasiResponse(model))
+}
+
+// AktifkanModelRekomendasi godoc
+// @Summary Mengaktifkan versi model rekomendasi
+// @Description Mengganti model yang dipakai untuk rekomendasi ke versi tertentu tanpa restart server.
+// @Description Instance server lain mengikuti versi aktif paling lambat setelah MODEL_SYNC_INTERVAL.
+// @Tags Model Rekomendasi
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Param versi path int true "Versi model"
+// @Success 200 {object} utils.Response{data=dto.ModelRekomendasiResponse} "Versi model diaktifkan"
+// @Failure 400 {object} utils.Response "Versi tidak valid"
+// @Failure 403 {object} utils.Response "Admin key tidak valid"
+// @Failure 404 {object} utils.Response "Versi model tidak ditemukan"
+// @Failure 500 {object} utils.Response "Gagal mengaktifkan model"
+// @Router /api/v1/model-rekomendasi/{versi}/aktifkan [put]
+func (s *modelRekomendasiService) AktifkanModelRekomendasi(c *fiber.Ctx) error {
+	versi, err := c.ParamsInt("versi")
+	if err != nil || versi < 1 {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Versi model tidak valid", nil)
+	}
+	return s.responseAktivasiModel(c, versi, "Versi model diaktifkan")
+}
+
+// RollbackModelRekomendasi godoc
+// @Summary Rollback model rekomendasi
+// @Description Mengaktifkan kembali versi model sebelum versi yang sedang aktif.
+// @Tags Model Rekomendasi
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Success 200 {object} utils.Response{data=dto.ModelRekomendasiResponse} "Rollback berhasil"
+// @Failure 403 {object} utils.Response "Admin key tidak valid"
+// @Failure 404 {object} utils.Response "Tidak ada versi sebelumnya"
+// @Failure 500 {object} utils.Response "Gagal melakukan rollback"
+// @Router /api/v1/model-rekomendasi/rollback [post]
+func (s *modelRekomendasiService) RollbackModelRekomendasi(c *fiber.Ctx) error {
+	var versiAktif int
+	if err := s.DB.Model(&models.ModelRekomendasi{}).Where("is_active = ?", true).Limit(1).Pluck("versi", &versiAktif).Error; err != nil {
+		logrus.WithError(err).Error("Gagal mengambil versi model aktif")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal melakukan rollback", err.Error())
+	}
+
+	var versiSebelumnya int
+	if err := s.DB.Model(&models.ModelRekomendasi{}).
+		Where("versi < ?", versiAktif).
+		Order("versi DESC").
+		Limit(1).
+		Pluck("versi", &versiSebelumnya).Error; err != nil {
+		logrus.WithError(err).Error("Gagal mengambil versi model sebelumnya")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal melakukan rollback", err.Error())
+	}
+	if versiSebelumnya == 0 {
+		return utils.ResponseError(c, fiber.StatusNotFound, "Tidak ada versi model sebelum versi aktif", nil)
+	}
+
+	logrus.WithFields(logrus.Fields{"dari_versi": versiAktif, "ke_versi": versiSebelumnya}).Info("Rollback model rekomendasi")
+	return s.responseAktivasiModel(c, versiSebelumnya, "Rollback model berhasil")
+}
@@ -0,0 +1,418 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/mahad-service-go/dto"
+	"github.com/habbazettt/mahad-service-go/models"
+	"github.com/habbazettt/mahad-service-go/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errGenderMentorTidakSesuai = errors.New("gender mentor harus sama dengan gender mahasantri")
+	errTidakAdaMentorSesuai    = errors.New("tidak ada mentor yang memenuhi syarat gender, kapasitas, dan hafalan")
+	errKapasitasMentorPenuh    = errors.New("mentor sudah mencapai batas maksimal bimbingan (MENTOR_MAX_BIMBINGAN)")
+)
+
+// kandidatMentor adalah mentor beserta jumlah mahasantri aktif (aktif/cuti) yang sedang dibimbing
+type kandidatMentor struct {
+	ID           uint
+	Nama         string
+	Gender       string
+	Beban        int
+	TotalHafalan *int
+}
+
+// penempatanPlanner menyimpan beban mentor di memori agar beberapa penempatan dalam satu permintaan
+// saling memperhitungkan satu sama lain
+type penempatanPlanner struct {
+	mentors   []*kandidatMentor
+	byID      map[uint]*kandidatMentor
+	maxBeban  int
+	hafalanOK bool
+}
+
+// getMaxBebanMentor membaca MENTOR_MAX_BIMBINGAN, 0 berarti tanpa batas
+func getMaxBebanMentor() int {
+	maxBeban, err := strconv.Atoi(os.Getenv("MENTOR_MAX_BIMBINGAN"))
+	if err != nil || maxBeban < 0 {
+		return 0
+	}
+	return maxBeban
+}
+
+// uniqueMentorIDs membuang ID kosong dan ganda lalu mengurutkannya dari yang terkecil
+func uniqueMentorIDs(mentorIDs []uint) []uint {
+	ids := make([]uint, 0, len(mentorIDs))
+	seen := make(map[uint]bool, len(mentorIDs))
+	for _, id := range mentorIDs {
+		if id != 0 && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// kunciMentor mengunci baris mentor (SELECT ... FOR UPDATE) sampai transaksi selesai, agar penghitungan beban
+// dan penempatan mahasantri ke mentor yang sama tidak berjalan bersamaan. Mentor dikunci berurutan dari ID terkecil
+// untuk menghindari deadlock antar transaksi.
+func kunciMentor(tx *gorm.DB, mentorIDs ...uint) error {
+	for _, id := range uniqueMentorIDs(mentorIDs) {
+		var mentor models.Mentor
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&mentor, id).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cekKapasitasMentor memastikan beban mentor tidak melebihi MENTOR_MAX_BIMBINGAN setelah penempatan.
+// Dipanggil dalam transaksi yang sama setelah kunciMentor, sehingga penempatan lain ke mentor tersebut menunggu.
+func cekKapasitasMentor(tx *gorm.DB, mentorIDs ...uint) error {
+	maxBeban := getMaxBebanMentor()
+	if maxBeban == 0 {
+		return nil
+	}
+	for _, id := range uniqueMentorIDs(mentorIDs) {
+		var beban int64
+		if err := tx.Model(&models.Mahasantri{}).
+			Where("mentor_id = ? AND status IN ?", id, []string{models.StatusMahasantriAktif, models.StatusMahasantriCuti}).
+			Count(&beban).Error; err != nil {
+			return err
+		}
+		if beban > int64(maxBeban) {
+			return fmt.Errorf("mentor %d: %w", id, errKapasitasMentorPenuh)
+		}
+	}
+	return nil
+}
+
+func loadPenempatanPlanner(db *gorm.DB, pertimbangkanHafalan bool) (*penempatanPlanner, error) {
+	var mentors []*kandidatMentor
+	err := db.Table("mentors").
+		Select("mentors.id, mentors.nama, mentors.gender, COUNT(m.id) AS beban, jp.total_hafalan").
+		Joins("LEFT JOIN mahasantris m ON m.mentor_id = mentors.id AND m.deleted_at IS NULL AND m.status IN ?",
+			[]string{models.StatusMahasantriAktif, models.StatusMahasantriCuti}).
+		Joins("LEFT JOIN jadwal_personals jp ON jp.mentor_id = mentors.id").
+		Where("mentors.deleted_at IS NULL").
+		Group("mentors.id, mentors.nama, mentors.gender, jp.total_hafalan").
+		Order("mentors.id ASC").
+		Scan(&mentors).Error
+	if err != nil {
+		return nil, err
+	}
+
+	planner := &penempatanPlanner{
+		mentors:   mentors,
+		byID:      make(map[uint]*kandidatMentor, len(mentors)),
+		maxBeban:  getMaxBebanMentor(),
+		hafalanOK: pertimbangkanHafalan,
+	}
+	for _, m := range mentors {
+		planner.byID[m.ID] = m
+	}
+	return planner, nil
+}
+
+// eligible memeriksa aturan gender, kapasitas, dan (opsional) hafalan mentor minimal setara mahasantri
+func (p *penempatanPlanner) eligible(m *kandidatMentor, gender string, totalHafalan *int) bool {
+	if m.Gender != gender {
+		return false
+	}
+	if p.maxBeban > 0 && m.Beban >= p.maxBeban {
+		return false
+	}
+	if p.hafalanOK && totalHafalan != nil {
+		if m.TotalHafalan == nil || *m.TotalHafalan < *totalHafalan {
+			return false
+		}
+	}
+	return true
+}
+
+// kandidat mengembalikan mentor yang memenuhi syarat, diurutkan dari beban paling ringan.
+// Jika hafalan dipertimbangkan, mentor dengan selisih hafalan terkecil didahulukan pada beban yang sama.
+func (p *penempatanPlanner) kandidat(gender string, totalHafalan *int) []*kandidatMentor {
+	var result []*kandidatMentor
+	for _, m := range p.mentors {
+		if p.eligible(m, gender, totalHafalan) {
+			result = append(result, m)
+		}
+	}
+
+	selisih := func(m *kandidatMentor) int {
+		if !p.hafalanOK || totalHafalan == nil || m.TotalHafalan == nil {
+			return 0
+		}
+		return *m.TotalHafalan - *totalHafalan
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Beban != result[j].Beban {
+			return result[i].Beban < result[j].Beban
+		}
+		if si, sj := selisih(result[i]), selisih(result[j]); si != sj {
+			return si < sj
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// tempatkan memilih mentor untuk mahasantri dan langsung menambah bebannya.
+// mentorSaatIni diisi untuk mahasantri lama agar tidak dipindahkan jika mentornya sudah seimbang.
+func (p *penempatanPlanner) tempatkan(gender string, totalHafalan *int, mentorSaatIni uint) (*kandidatMentor, error) {
+	current := p.byID[mentorSaatIni]
+	if current != nil {
+		current.Beban--
+	}
+
+	kandidat := p.kandidat(gender, totalHafalan)
+	if len(kandidat) == 0 {
+		if current != nil {
+			current.Beban++
+		}
+		return nil, errTidakAdaMentorSesuai
+	}
+
+	pilihan := kandidat[0]
+	if current != nil && p.eligible(current, gender, totalHafalan) && current.Beban <= pilihan.Beban {
+		pilihan = current
+	}
+	pilihan.Beban++
+	return pilihan, nil
+}
+
+func (p *penempatanPlanner) bebanResponse() []dto.KandidatMentorResponse {
+	response := make([]dto.KandidatMentorResponse, len(p.mentors))
+	for i, m := range p.mentors {
+		response[i] = toKandidatMentorResponse(m)
+	}
+	return response
+}
+
+func toKandidatMentorResponse(m *kandidatMentor) dto.KandidatMentorResponse {
+	return dto.KandidatMentorResponse{
+		MentorID:     m.ID,
+		Nama:         m.Nama,
+		Gender:       m.Gender,
+		Beban:        m.Beban,
+		TotalHafalan: m.TotalHafalan,
+	}
+}
+
+// GetRekomendasiMentor - Pratinjau mentor untuk mahasantri baru
+// @Summary Pratinjau penempatan mentor
+// @Description Menampilkan mentor yang akan dipilih secara otomatis untuk mahasantri baru berdasarkan gender, beban bimbingan paling ringan, kapasitas (MENTOR_MAX_BIMBINGAN), dan opsional tingkat hafalan.
+// @Tags Mahasantri
+// @Produce json
+// @Param gender query string true "Gender mahasantri (L/P)"
+// @Param total_hafalan query int false "Jumlah juz hafalan mahasantri"
+// @Param pertimbangkan_hafalan query bool false "Hanya mentor dengan hafalan minimal setara mahasantri"
+// @Success 200 {object} utils.Response{data=[]dto.KandidatMentorResponse} "Daftar kandidat mentor, urutan pertama adalah pilihan otomatis"
+// @Failure 400 {object} utils.Response "Parameter tidak valid"
+// @Failure 404 {object} utils.Response "Tidak ada mentor yang memenuhi syarat"
+// @Failure 500 {object} utils.Response "Gagal memuat data mentor"
+// @Security BearerAuth
+// @Router /api/v1/mahasantri/penempatan/rekomendasi [get]
+func (s *MahasantriService) GetRekomendasiMentor(c *fiber.Ctx) error {
+	gender := strings.ToUpper(c.Query("gender"))
+	if gender != "L" && gender != "P" {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "gender harus L atau P", nil)
+	}
+
+	var totalHafalan *int
+	if raw := c.Query("total_hafalan"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 0 || v > 30 {
+			return utils.ResponseError(c, fiber.StatusBadRequest, "total_hafalan harus angka 0-30", nil)
+		}
+		totalHafalan = &v
+	}
+
+	planner, err := loadPenempatanPlanner(s.DB, c.QueryBool("pertimbangkan_hafalan"))
+	if err != nil {
+		logrus.WithError(err).Error("Gagal memuat beban mentor")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal memuat data mentor", err.Error())
+	}
+
+	kandidat := planner.kandidat(gender, totalHafalan)
+	if len(kandidat) == 0 {
+		return utils.ResponseError(c, fiber.StatusNotFound, errTidakAdaMentorSesuai.Error(), nil)
+	}
+
+	response := make([]dto.KandidatMentorResponse, len(kandidat))
+	for i, m := range kandidat {
+		response[i] = toKandidatMentorResponse(m)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, "Kandidat mentor berhasil diambil", response)
+}
+
+// PenempatanMentor - Menyeimbangkan mentor untuk sejumlah mahasantri
+// @Summary Penempatan dan penyeimbangan mentor otomatis
+// @Description Menempatkan mahasantri yang dipilih ke mentor dengan gender sama dan beban paling ringan. Mahasantri tetap pada mentornya jika mentor tersebut sudah termasuk yang paling ringan.
+// @Description Sama seperti transfer mentor, hanya mahasantri bimbingan mentor yang sedang login yang dapat ditempatkan.
+// @Description Secara default hanya pratinjau (dry_run=true). Dengan dry_run=false pemindahan dicatat sebagai transfer mentor dalam satu transaksi.
+// @Tags Mahasantri
+// @Accept json
+// @Produce json
+// @Param request body dto.PenempatanMentorRequest true "Mahasantri yang akan ditempatkan"
+// @Param dry_run query bool false "Hanya pratinjau tanpa menyimpan" default(true)
+// @Success 200 {object} utils.Response{data=dto.PenempatanMentorResponse} "Rencana atau hasil penempatan"
+// @Failure 400 {object} utils.Response "Request tidak valid atau ada mahasantri yang tidak dapat ditempatkan"
+// @Failure 403 {object} utils.Response "Ada mahasantri yang bukan bimbingan mentor"
+// @Failure 409 {object} utils.Response "Beban mentor berubah saat penempatan disimpan"
+// @Failure 500 {object} utils.Response "Gagal menempatkan mahasantri"
+// @Security BearerAuth
+// @Router /api/v1/mahasantri/penempatan [post]
+func (s *MahasantriService) PenempatanMentor(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	dryRun := c.QueryBool("dry_run", true)
+
+	var req dto.PenempatanMentorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+	if len(req.MahasantriIDs) == 0 {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "mahasantri_ids wajib diisi", nil)
+	}
+	if len(req.MahasantriIDs) > maxImportMahasantriRows {
+		return utils.ResponseError(c, fiber.StatusBadRequest, fmt.Sprintf("maksimal %d mahasantri per permintaan", maxImportMahasantriRows), nil)
+	}
+
+	log := logrus.WithFields(logrus.Fields{
+		"handler":  "PenempatanMentor",
+		"mentorID": claims.ID,
+		"dry_run":  dryRun,
+	})
+
+	var mahasantris []models.Mahasantri
+	if err := s.DB.Preload("JadwalPersonal").Where("id IN ?", req.MahasantriIDs).Order("id ASC").Find(&mahasantris).Error; err != nil {
+		log.WithError(err).Error("Gagal mengambil mahasantri")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal menempatkan mahasantri", err.Error())
+	}
+	found := make(map[uint]bool, len(mahasantris))
+	for _, m := range mahasantris {
+		found[m.ID] = true
+	}
+
+	planner, err := loadPenempatanPlanner(s.DB, req.PertimbangkanHafalan)
+	if err != nil {
+		log.WithError(err).Error("Gagal memuat beban mentor")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal menempatkan mahasantri", err.Error())
+	}
+
+	response := dto.PenempatanMentorResponse{DryRun: dryRun}
+	gagal, bukanBimbingan := false, false
+	for _, id := range req.MahasantriIDs {
+		if !found[id] {
+			response.Penempatan = append(response.Penempatan, dto.PenempatanMentorItem{MahasantriID: id, Error: "Mahasantri not found"})
+			gagal = true
+		}
+	}
+
+	for _, m := range mahasantris {
+		item := dto.PenempatanMentorItem{MahasantriID: m.ID, Nama: m.Nama, MentorLamaID: m.MentorID}
+		if m.MentorID != claims.ID {
+			item.Error = errBukanMentorPembimbing.Error()
+			gagal, bukanBimbingan = true, true
+			response.Penempatan = append(response.Penempatan, item)
+			continue
+		}
+		if m.Status == models.StatusMahasantriLulus || m.Status == models.StatusMahasantriKeluar {
+			item.Error = "mahasantri sudah tidak aktif"
+			gagal = true
+			response.Penempatan = append(response.Penempatan, item)
+			continue
+		}
+
+		var totalHafalan *int
+		if m.JadwalPersonal != nil {
+			totalHafalan = &m.JadwalPersonal.TotalHafalan
+		}
+		mentor, err := planner.tempatkan(m.Gender, totalHafalan, m.MentorID)
+		if err != nil {
+			item.Error = err.Error()
+			gagal = true
+		} else {
+			item.MentorBaruID = mentor.ID
+			item.NamaMentorBaru = mentor.Nama
+			item.Berubah = mentor.ID != m.MentorID
+			if item.Berubah {
+				response.Dipindahkan++
+			}
+		}
+		response.Penempatan = append(response.Penempatan, item)
+	}
+	response.BebanMentor = planner.bebanResponse()
+
+	if dryRun {
+		return utils.SuccessResponse(c, fiber.StatusOK, "Pratinjau penempatan mentor", response)
+	}
+	if bukanBimbingan {
+		log.Warn("Mentor mencoba menempatkan mahasantri yang bukan bimbingannya")
+		return utils.ResponseError(c, fiber.StatusForbidden, "Sebagian mahasantri bukan bimbingan anda, tidak ada perubahan yang disimpan", response)
+	}
+	if gagal {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Sebagian mahasantri tidak dapat ditempatkan, tidak ada perubahan yang disimpan", response)
+	}
+
+	today, _ := parseTanggalOrToday("")
+	var transfers []mentorTransfer
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		// Semua mentor tujuan dikunci lebih dulu, kapasitas diperiksa setelah seluruh pemindahan
+		// karena urutan pemindahan dapat membuat beban sementara melebihi batas
+		var mentorTujuan []uint
+		for _, item := range response.Penempatan {
+			if item.Berubah {
+				mentorTujuan = append(mentorTujuan, item.MentorBaruID)
+			}
+		}
+		if err := kunciMentor(tx, mentorTujuan...); err != nil {
+			return err
+		}
+
+		for _, item := range response.Penempatan {
+			if !item.Berubah {
+				continue
+			}
+			transfer, err := transferMentor(tx, item.MahasantriID, item.MentorBaruID, today, req.Alasan, &claims.ID)
+			if err != nil {
+				return fmt.Errorf("mahasantri %d: %w", item.MahasantriID, err)
+			}
+			// Mentor mahasantri bisa berubah sejak rencana disusun, pemeriksaan diulang setelah baris mahasantri dikunci
+			if transfer.MentorLamaID != claims.ID {
+				return fmt.Errorf("mahasantri %d: %w", item.MahasantriID, errBukanMentorPembimbing)
+			}
+			transfers = append(transfers, transfer)
+		}
+		return cekKapasitasMentor(tx, mentorTujuan...)
+	})
+	if errors.Is(err, errKapasitasMentorPenuh) {
+		return utils.ResponseError(c, fiber.StatusConflict, "Beban mentor berubah saat penempatan disimpan, silakan ulangi", err.Error())
+	}
+	if errors.Is(err, errBukanMentorPembimbing) {
+		return utils.ResponseError(c, fiber.StatusForbidden, err.Error(), nil)
+	}
+	if err != nil {
+		log.WithError(err).Error("Gagal menyimpan penempatan mentor")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal menempatkan mahasantri", err.Error())
+	}
+
+	for _, transfer := range transfers {
+		notifyMentorTransfer(s.Notifier, transfer)
+	}
+
+	log.WithField("dipindahkan", response.Dipindahkan).Info("Penempatan mentor berhasil disimpan")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Penempatan mentor berhasil disimpan", response)
+}
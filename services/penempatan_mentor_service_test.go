@@ -0,0 +1,109 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func intPtr(v int) *int { return &v }
+
+func plannerUji(maxBeban int, hafalanOK bool, mentors ...*kandidatMentor) *penempatanPlanner {
+	p := &penempatanPlanner{mentors: mentors, byID: make(map[uint]*kandidatMentor), maxBeban: maxBeban, hafalanOK: hafalanOK}
+	for _, m := range mentors {
+		p.byID[m.ID] = m
+	}
+	return p
+}
+
+func idKandidat(kandidat []*kandidatMentor) []uint {
+	ids := make([]uint, len(kandidat))
+	for i, m := range kandidat {
+		ids[i] = m.ID
+	}
+	return ids
+}
+
+func TestPenempatanPlannerKandidatUrutan(t *testing.T) {
+	p := plannerUji(0, false,
+		&kandidatMentor{ID: 1, Gender: "L", Beban: 3},
+		&kandidatMentor{ID: 2, Gender: "L", Beban: 1},
+		&kandidatMentor{ID: 3, Gender: "P", Beban: 0},
+		&kandidatMentor{ID: 4, Gender: "L", Beban: 1},
+	)
+
+	// Beban paling ringan lebih dulu, beban sama diurutkan berdasarkan ID
+	assert.Equal(t, []uint{2, 4, 1}, idKandidat(p.kandidat("L", nil)))
+	assert.Equal(t, []uint{3}, idKandidat(p.kandidat("P", nil)))
+}
+
+func TestPenempatanPlannerKandidatHafalan(t *testing.T) {
+	p := plannerUji(2, true,
+		&kandidatMentor{ID: 1, Gender: "L", Beban: 1, TotalHafalan: intPtr(30)},
+		&kandidatMentor{ID: 2, Gender: "L", Beban: 1, TotalHafalan: intPtr(12)},
+		&kandidatMentor{ID: 3, Gender: "L", Beban: 1, TotalHafalan: intPtr(5)},
+		&kandidatMentor{ID: 4, Gender: "L", Beban: 1},
+		&kandidatMentor{ID: 5, Gender: "L", Beban: 2, TotalHafalan: intPtr(30)},
+	)
+
+	// Mentor 3 hafalannya kurang, mentor 4 tanpa data hafalan, dan mentor 5 sudah penuh
+	assert.Equal(t, []uint{2, 1}, idKandidat(p.kandidat("L", intPtr(10))))
+
+	// Tanpa data hafalan mahasantri, hanya beban dan ID yang menentukan
+	assert.Equal(t, []uint{1, 2, 3, 4}, idKandidat(p.kandidat("L", nil)))
+}
+
+func TestPenempatanPlannerTempatkanMeratakanBeban(t *testing.T) {
+	p := plannerUji(0, false,
+		&kandidatMentor{ID: 1, Gender: "L", Beban: 0},
+		&kandidatMentor{ID: 2, Gender: "L", Beban: 1},
+	)
+
+	var urutan []uint
+	for i := 0; i < 4; i++ {
+		m, err := p.tempatkan("L", nil, 0)
+		if assert.NoError(t, err) {
+			urutan = append(urutan, m.ID)
+		}
+	}
+	assert.Equal(t, []uint{1, 1, 2, 1}, urutan)
+	assert.Equal(t, 3, p.byID[1].Beban)
+	assert.Equal(t, 2, p.byID[2].Beban)
+}
+
+func TestPenempatanPlannerTempatkanMentorSaatIni(t *testing.T) {
+	p := plannerUji(0, false,
+		&kandidatMentor{ID: 1, Gender: "L", Beban: 2},
+		&kandidatMentor{ID: 2, Gender: "L", Beban: 2},
+	)
+
+	// Mentor lama tetap dipakai jika bebannya sudah seimbang
+	m, err := p.tempatkan("L", nil, 2)
+	if assert.NoError(t, err) {
+		assert.Equal(t, uint(2), m.ID)
+	}
+	assert.Equal(t, 2, p.byID[2].Beban)
+
+	p.byID[2].Beban = 4
+	m, err = p.tempatkan("L", nil, 2)
+	if assert.NoError(t, err) {
+		assert.Equal(t, uint(1), m.ID)
+	}
+	assert.Equal(t, 3, p.byID[1].Beban)
+	assert.Equal(t, 3, p.byID[2].Beban)
+}
+
+func TestPenempatanPlannerTempatkanTidakAdaMentor(t *testing.T) {
+	p := plannerUji(1, false,
+		&kandidatMentor{ID: 1, Gender: "L", Beban: 1},
+		&kandidatMentor{ID: 2, Gender: "P", Beban: 0},
+	)
+
+	_, err := p.tempatkan("L", nil, 0)
+	assert.ErrorIs(t, err, errTidakAdaMentorSesuai)
+
+	// Beban mentor lama dikembalikan jika penempatan gagal
+	_, err = p.tempatkan("L", nil, 2)
+	assert.ErrorIs(t, err, errTidakAdaMentorSesuai)
+	assert.Equal(t, 0, p.byID[2].Beban)
+}
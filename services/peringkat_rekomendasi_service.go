@@ -0,0 +1,127 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/habbazettt/mahad-service-go/config"
+	"github.com/habbazettt/mahad-service-go/dto"
+)
+
+const (
+	defaultTopRekomendasi = 3
+	maxTopRekomendasi     = 10
+)
+
+// normalizeTopRekomendasi membatasi jumlah alternatif jadwal antara 1 dan maxTopRekomendasi
+func normalizeTopRekomendasi(top int) int {
+	switch {
+	case top <= 0:
+		return defaultTopRekomendasi
+	case top > maxTopRekomendasi:
+		return maxTopRekomendasi
+	default:
+		return top
+	}
+}
+
+// deskripsiState mengubah state "kuliah_21-30 Juz" menjadi kalimat yang mudah dibaca
+func deskripsiState(state string) string {
+	idx := strings.LastIndex(state, "_")
+	if idx < 0 {
+		return state
+	}
+	return fmt.Sprintf("kesibukan %s dengan hafalan %s", state[:idx], state[idx+1:])
+}
+
+func kalimatHistoris(info config.HistoricalInfo) string {
+	if info.TotalPenggunaan > 0 {
+		return fmt.Sprintf("Secara historis efektif pada %.1f%% dari %d penggunaan.", info.PersentaseEfektif, info.TotalPenggunaan)
+	}
+	return fmt.Sprintf("Secara historis efektif pada %.1f%% penggunaan.", info.PersentaseEfektif)
+}
+
+// peringkatDariQTable mengurutkan jadwal pada satu state berdasarkan nilai Q (tertinggi lebih dulu).
+// Nilai Q yang sama diurutkan berdasarkan persentase efektif historis, lalu nama jadwal, agar hasilnya selalu sama.
+func peringkatDariQTable(state string, actions map[string]float64, historical []config.HistoricalInfo, umpanBalik map[string]int, top int) []dto.PeringkatRekomendasi {
+	historisPerJadwal := make(map[string]config.HistoricalInfo, len(historical))
+	for _, info := range historical {
+		historisPerJadwal[info.Jadwal] = info
+	}
+
+	jadwal := make([]string, 0, len(actions))
+	for action := range actions {
+		jadwal = append(jadwal, action)
+	}
+	sort.Slice(jadwal, func(i, j int) bool {
+		qi, qj := actions[jadwal[i]], actions[jadwal[j]]
+		if qi != qj {
+			return qi > qj
+		}
+		hi, hj := historisPerJadwal[jadwal[i]].PersentaseEfektif, historisPerJadwal[jadwal[j]].PersentaseEfektif
+		if hi != hj {
+			return hi > hj
+		}
+		return jadwal[i] < jadwal[j]
+	})
+	if len(jadwal) > top {
+		jadwal = jadwal[:top]
+	}
+
+	var qTeratas float64
+	if len(jadwal) > 0 {
+		qTeratas = actions[jadwal[0]]
+	}
+
+	hasil := make([]dto.PeringkatRekomendasi, len(jadwal))
+	for i, action := range jadwal {
+		q := actions[action]
+		item := dto.PeringkatRekomendasi{
+			Peringkat:        i + 1,
+			Jadwal:           action,
+			EstimasiQValue:   &q,
+			JumlahUmpanBalik: umpanBalik[action],
+		}
+
+		var penjelasan []string
+		if i == 0 {
+			penjelasan = append(penjelasan, fmt.Sprintf("Nilai Q tertinggi (%.2f) untuk %s.", q, deskripsiState(state)))
+		} else {
+			penjelasan = append(penjelasan, fmt.Sprintf("Alternatif ke-%d untuk %s dengan nilai Q %.2f (selisih %.2f dari jadwal teratas).", i+1, deskripsiState(state), q, qTeratas-q))
+		}
+		if info, ok := historisPerJadwal[action]; ok {
+			persen := info.PersentaseEfektif
+			item.PersentaseEfektifHistoris = &persen
+			item.JumlahSampelHistoris = info.TotalPenggunaan
+			penjelasan = append(penjelasan, kalimatHistoris(info))
+		}
+		if item.JumlahUmpanBalik > 0 {
+			penjelasan = append(penjelasan, fmt.Sprintf("Nilai Q telah diperbarui dari %d umpan balik pengguna.", item.JumlahUmpanBalik))
+		}
+		item.Penjelasan = strings.Join(penjelasan, " ")
+		hasil[i] = item
+	}
+	return hasil
+}
+
+// peringkatDariHistoris dipakai jika state tidak ada di Q-Table: jadwal diurutkan dari persentase efektif historis tertinggi
+func peringkatDariHistoris(state string, historical []config.HistoricalInfo, top int) []dto.PeringkatRekomendasi {
+	if len(historical) > top {
+		historical = historical[:top]
+	}
+
+	hasil := make([]dto.PeringkatRekomendasi, len(historical))
+	for i, info := range historical {
+		persen := info.PersentaseEfektif
+		hasil[i] = dto.PeringkatRekomendasi{
+			Peringkat:                 i + 1,
+			Jadwal:                    info.Jadwal,
+			PersentaseEfektifHistoris: &persen,
+			JumlahSampelHistoris:      info.TotalPenggunaan,
+			Penjelasan: fmt.Sprintf("Model belum memiliki data untuk %s, jadwal dipilih dari data historis seluruh pengguna (peringkat %d). %s",
+				deskripsiState(state), i+1, kalimatHistoris(info)),
+		}
+	}
+	return hasil
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/habbazettt/mahad-service-go/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func jadwalPeringkat(t *testing.T, actions map[string]float64, historical []config.HistoricalInfo, top int) []string {
+	t.Helper()
+	peringkat := peringkatDariQTable("kuliah_1-10 Juz", actions, historical, nil, top)
+	jadwal := make([]string, len(peringkat))
+	for i, p := range peringkat {
+		assert.Equal(t, i+1, p.Peringkat)
+		jadwal[i] = p.Jadwal
+	}
+	return jadwal
+}
+
+func TestPeringkatDariQTableUrutanNilaiQ(t *testing.T) {
+	actions := map[string]float64{"Sebelum Subuh": 1.5, "Setelah Isya": 3.2, "Setelah Maghrib": 2.1}
+
+	assert.Equal(t, []string{"Setelah Isya", "Setelah Maghrib", "Sebelum Subuh"}, jadwalPeringkat(t, actions, nil, 10))
+	assert.Equal(t, []string{"Setelah Isya"}, jadwalPeringkat(t, actions, nil, 1))
+}
+
+func TestPeringkatDariQTableNilaiQSama(t *testing.T) {
+	actions := map[string]float64{"Setelah Ashar": 2, "Setelah Isya": 2, "Setelah Maghrib": 2, "Sebelum Subuh": 1}
+	historical := []config.HistoricalInfo{
+		{Jadwal: "Setelah Maghrib", PersentaseEfektif: 80, TotalPenggunaan: 10},
+		{Jadwal: "Setelah Isya", PersentaseEfektif: 60, TotalPenggunaan: 5},
+	}
+
+	// Nilai Q sama diurutkan berdasarkan persentase efektif historis, lalu nama jadwal
+	want := []string{"Setelah Maghrib", "Setelah Isya", "Setelah Ashar", "Sebelum Subuh"}
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, want, jadwalPeringkat(t, actions, historical, 10))
+	}
+
+	tanpaHistoris := []string{"Setelah Ashar", "Setelah Isya", "Setelah Maghrib", "Sebelum Subuh"}
+	assert.Equal(t, tanpaHistoris, jadwalPeringkat(t, actions, nil, 10))
+}
+
+func TestPeringkatDariQTableDetail(t *testing.T) {
+	actions := map[string]float64{"Setelah Isya": 3, "Setelah Maghrib": 2}
+	historical := []config.HistoricalInfo{{Jadwal: "Setelah Maghrib", PersentaseEfektif: 75, TotalPenggunaan: 8}}
+
+	peringkat := peringkatDariQTable("kuliah_1-10 Juz", actions, historical, map[string]int{"Setelah Isya": 4}, 3)
+	if assert.Len(t, peringkat, 2) {
+		assert.InDelta(t, 3, *peringkat[0].EstimasiQValue, 1e-9)
+		assert.Nil(t, peringkat[0].PersentaseEfektifHistoris)
+		assert.Equal(t, 4, peringkat[0].JumlahUmpanBalik)
+		assert.Contains(t, peringkat[0].Penjelasan, "kesibukan kuliah dengan hafalan 1-10 Juz")
+
+		assert.InDelta(t, 75, *peringkat[1].PersentaseEfektifHistoris, 1e-9)
+		assert.Equal(t, 8, peringkat[1].JumlahSampelHistoris)
+		assert.Contains(t, peringkat[1].Penjelasan, "selisih 1.00")
+	}
+
+	assert.Empty(t, peringkatDariQTable("kuliah_1-10 Juz", nil, nil, nil, 3))
+}
@@ -0,0 +1,313 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/mahad-service-go/dto"
+	"github.com/habbazettt/mahad-service-go/models"
+	"github.com/habbazettt/mahad-service-go/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	maxFotoSize       = 2 << 20 // 2 MB
+	fotoThumbnailSize = 200
+)
+
+// newProfilMahasantri memetakan data profil tambahan mahasantri ke DTO
+func newProfilMahasantri(m models.Mahasantri) *dto.ProfilMahasantri {
+	profil := &dto.ProfilMahasantri{
+		Angkatan: m.Angkatan,
+		Asrama:   m.Asrama,
+		Kamar:    m.Kamar,
+		NoHP:     m.NoHP,
+		NamaWali: m.NamaWali,
+		NoHPWali: m.NoHPWali,
+	}
+	if m.TanggalLahir != nil {
+		profil.TanggalLahir = m.TanggalLahir.Format("02-01-2006")
+	}
+	if m.Foto != "" {
+		profil.FotoURL = fmt.Sprintf("/api/v1/mahasantri/%d/foto", m.ID)
+		profil.FotoThumbnailURL = profil.FotoURL + "?ukuran=thumbnail"
+	}
+	return profil
+}
+
+// applyProfilMahasantri menerapkan perubahan profil dari request, mengembalikan true jika ada perubahan
+func applyProfilMahasantri(m *models.Mahasantri, req dto.UpdateMahasantriRequest) (bool, error) {
+	updated := false
+
+	if req.Angkatan != nil && *req.Angkatan != m.Angkatan {
+		if *req.Angkatan < 1900 || *req.Angkatan > time.Now().Year()+1 {
+			return false, errors.New("angkatan tidak valid")
+		}
+		m.Angkatan = *req.Angkatan
+		updated = true
+	}
+	if req.Asrama != nil && *req.Asrama != m.Asrama {
+		m.Asrama = strings.TrimSpace(*req.Asrama)
+		updated = true
+	}
+	if req.Kamar != nil && *req.Kamar != m.Kamar {
+		m.Kamar = strings.TrimSpace(*req.Kamar)
+		updated = true
+	}
+	if req.NoHP != nil && *req.NoHP != m.NoHP {
+		if *req.NoHP != "" && !utils.IsValidPhone(*req.NoHP) {
+			return false, errors.New("format no_hp tidak valid")
+		}
+		m.NoHP = *req.NoHP
+		updated = true
+	}
+	if req.NamaWali != nil && *req.NamaWali != m.NamaWali {
+		m.NamaWali = strings.TrimSpace(*req.NamaWali)
+		updated = true
+	}
+	if req.NoHPWali != nil && *req.NoHPWali != m.NoHPWali {
+		if *req.NoHPWali != "" && !utils.IsValidPhone(*req.NoHPWali) {
+			return false, errors.New("format no_hp_wali tidak valid")
+		}
+		m.NoHPWali = *req.NoHPWali
+		updated = true
+	}
+	if req.TanggalLahir != nil {
+		if *req.TanggalLahir == "" {
+			if m.TanggalLahir != nil {
+				m.TanggalLahir = nil
+				updated = true
+			}
+		} else {
+			tanggal, err := time.Parse("02-01-2006", *req.TanggalLahir)
+			if err != nil {
+				return false, errors.New("format tanggal_lahir harus dd-mm-yyyy")
+			}
+			if tanggal.After(time.Now()) {
+				return false, errors.New("tanggal_lahir tidak boleh di masa depan")
+			}
+			if m.TanggalLahir == nil || !m.TanggalLahir.Equal(tanggal) {
+				m.TanggalLahir = &tanggal
+				updated = true
+			}
+		}
+	}
+
+	return updated, nil
+}
+
+// findMahasantriForFoto memuat mahasantri dan memastikan mahasantri hanya mengakses fotonya sendiri.
+// Status dan pesan error dikembalikan agar handler dapat langsung meneruskannya ke response.
+func (s *MahasantriService) findMahasantriForFoto(c *fiber.Ctx) (models.Mahasantri, int, string) {
+	var mahasantri models.Mahasantri
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return mahasantri, fiber.StatusBadRequest, "Invalid ID format"
+	}
+
+	claims := c.Locals("user").(*utils.Claims)
+	if claims.Role == RoleMahasantri && uint(id) != claims.ID {
+		return mahasantri, fiber.StatusForbidden, "Anda hanya dapat mengakses foto Anda sendiri"
+	}
+
+	if err := s.DB.First(&mahasantri, id).Error; err != nil {
+		return mahasantri, fiber.StatusNotFound, "Mahasantri not found"
+	}
+	return mahasantri, 0, ""
+}
+
+// UploadFotoMahasantri - Mengunggah foto profil mahasantri
+// @Summary Mengunggah foto profil mahasantri
+// @Description Mengunggah foto profil (JPEG/PNG, maksimal 2 MB, sisi terpanjang 6000 piksel, dan 16 megapiksel) melalui multipart field "foto". Thumbnail dibuat otomatis dan foto lama diganti. Mahasantri hanya dapat mengunggah fotonya sendiri.
+// @Tags Mahasantri
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Mahasantri ID"
+// @Param foto formData file true "File foto (JPEG/PNG)"
+// @Success 200 {object} utils.Response{data=dto.ProfilMahasantri} "Foto berhasil diunggah"
+// @Failure 400 {object} utils.Response "File tidak valid"
+// @Failure 403 {object} utils.Response "Forbidden"
+// @Failure 404 {object} utils.Response "Mahasantri not found"
+// @Failure 413 {object} utils.Response "Ukuran file terlalu besar"
+// @Failure 500 {object} utils.Response "Gagal menyimpan foto"
+// @Security BearerAuth
+// @Router /api/v1/mahasantri/{id}/foto [put]
+func (s *MahasantriService) UploadFotoMahasantri(c *fiber.Ctx) error {
+	mahasantri, status, msg := s.findMahasantriForFoto(c)
+	if status != 0 {
+		return utils.ResponseError(c, status, msg, nil)
+	}
+	if mahasantri.Status == models.StatusMahasantriLulus {
+		return responseMahasantriFrozen(c, errMahasantriAlumni)
+	}
+
+	log := logrus.WithField("mahasantri_id", mahasantri.ID)
+
+	fileHeader, err := c.FormFile("foto")
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Foto wajib diunggah pada field 'foto'", err.Error())
+	}
+	if fileHeader.Size > maxFotoSize {
+		return utils.ResponseError(c, fiber.StatusRequestEntityTooLarge, "Ukuran foto maksimal 2 MB", nil)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Gagal membaca foto", err.Error())
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxFotoSize+1))
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Gagal membaca foto", err.Error())
+	}
+	if len(data) > maxFotoSize {
+		return utils.ResponseError(c, fiber.StatusRequestEntityTooLarge, "Ukuran foto maksimal 2 MB", nil)
+	}
+
+	_, ext, err := utils.DetectImageType(data)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Foto tidak valid", err.Error())
+	}
+	thumbnail, err := utils.MakeThumbnail(data, fotoThumbnailSize)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Foto tidak valid", err.Error())
+	}
+
+	// Nama file unik per unggahan agar foto lama tetap utuh sampai database diperbarui
+	stamp := time.Now().UnixNano()
+	fotoKey := fmt.Sprintf("mahasantri/%d/foto-%d%s", mahasantri.ID, stamp, ext)
+	thumbnailKey := fmt.Sprintf("mahasantri/%d/foto-%d-thumb.jpg", mahasantri.ID, stamp)
+
+	if err := s.Storage.Save(fotoKey, bytes.NewReader(data)); err != nil {
+		log.WithError(err).Error("Gagal menyimpan foto")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal menyimpan foto", err.Error())
+	}
+	if err := s.Storage.Save(thumbnailKey, bytes.NewReader(thumbnail)); err != nil {
+		s.deleteFotoFiles(fotoKey)
+		log.WithError(err).Error("Gagal menyimpan thumbnail foto")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal menyimpan foto", err.Error())
+	}
+
+	oldFoto, oldThumbnail := mahasantri.Foto, mahasantri.FotoThumbnail
+	if err := s.DB.Model(&mahasantri).Updates(map[string]interface{}{
+		"foto":           fotoKey,
+		"foto_thumbnail": thumbnailKey,
+	}).Error; err != nil {
+		s.deleteFotoFiles(fotoKey, thumbnailKey)
+		log.WithError(err).Error("Gagal memperbarui foto mahasantri")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal menyimpan foto", err.Error())
+	}
+	s.deleteFotoFiles(oldFoto, oldThumbnail)
+
+	mahasantri.Foto, mahasantri.FotoThumbnail = fotoKey, thumbnailKey
+	log.Info("Foto mahasantri berhasil diunggah")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Foto berhasil diunggah", newProfilMahasantri(mahasantri))
+}
+
+// GetFotoMahasantri - Mengambil foto profil mahasantri
+// @Summary Mengambil foto profil mahasantri
+// @Description Mengembalikan file foto profil mahasantri. Gunakan ukuran=thumbnail untuk versi kecil (JPEG).
+// @Tags Mahasantri
+// @Produce image/jpeg
+// @Produce image/png
+// @Param id path int true "Mahasantri ID"
+// @Param ukuran query string false "asli atau thumbnail" default(asli)
+// @Success 200 {file} file "Foto profil"
+// @Failure 403 {object} utils.Response "Forbidden"
+// @Failure 404 {object} utils.Response "Foto tidak ditemukan"
+// @Security BearerAuth
+// @Router /api/v1/mahasantri/{id}/foto [get]
+func (s *MahasantriService) GetFotoMahasantri(c *fiber.Ctx) error {
+	mahasantri, status, msg := s.findMahasantriForFoto(c)
+	if status != 0 {
+		return utils.ResponseError(c, status, msg, nil)
+	}
+
+	key := mahasantri.Foto
+	if c.Query("ukuran") == "thumbnail" {
+		key = mahasantri.FotoThumbnail
+	}
+	if key == "" {
+		return utils.ResponseError(c, fiber.StatusNotFound, "Foto tidak ditemukan", nil)
+	}
+
+	file, err := s.Storage.Open(key)
+	if err != nil {
+		if errors.Is(err, utils.ErrFileNotFound) {
+			return utils.ResponseError(c, fiber.StatusNotFound, "Foto tidak ditemukan", nil)
+		}
+		logrus.WithError(err).WithField("mahasantri_id", mahasantri.ID).Error("Gagal membaca foto")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal membaca foto", err.Error())
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal membaca foto", err.Error())
+	}
+
+	contentType, _, err := utils.DetectImageType(data)
+	if err != nil {
+		contentType = fiber.MIMEOctetStream
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderCacheControl, "private, max-age=3600")
+	return c.Send(data)
+}
+
+// DeleteFotoMahasantri - Menghapus foto profil mahasantri
+// @Summary Menghapus foto profil mahasantri
+// @Description Menghapus foto profil beserta thumbnail. Mahasantri hanya dapat menghapus fotonya sendiri.
+// @Tags Mahasantri
+// @Produce json
+// @Param id path int true "Mahasantri ID"
+// @Success 200 {object} utils.Response "Foto berhasil dihapus"
+// @Failure 403 {object} utils.Response "Forbidden"
+// @Failure 404 {object} utils.Response "Foto tidak ditemukan"
+// @Failure 500 {object} utils.Response "Gagal menghapus foto"
+// @Security BearerAuth
+// @Router /api/v1/mahasantri/{id}/foto [delete]
+func (s *MahasantriService) DeleteFotoMahasantri(c *fiber.Ctx) error {
+	mahasantri, status, msg := s.findMahasantriForFoto(c)
+	if status != 0 {
+		return utils.ResponseError(c, status, msg, nil)
+	}
+	if mahasantri.Status == models.StatusMahasantriLulus {
+		return responseMahasantriFrozen(c, errMahasantriAlumni)
+	}
+	if mahasantri.Foto == "" {
+		return utils.ResponseError(c, fiber.StatusNotFound, "Foto tidak ditemukan", nil)
+	}
+
+	if err := s.DB.Model(&mahasantri).Updates(map[string]interface{}{
+		"foto":           "",
+		"foto_thumbnail": "",
+	}).Error; err != nil {
+		logrus.WithError(err).WithField("mahasantri_id", mahasantri.ID).Error("Gagal menghapus foto")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal menghapus foto", err.Error())
+	}
+	s.deleteFotoFiles(mahasantri.Foto, mahasantri.FotoThumbnail)
+
+	logrus.WithField("mahasantri_id", mahasantri.ID).Info("Foto mahasantri berhasil dihapus")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Foto berhasil dihapus", nil)
+}
+
+// deleteFotoFiles menghapus file dari storage, kegagalan hanya dicatat karena data di database sudah konsisten
+func (s *MahasantriService) deleteFotoFiles(keys ...string) {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := s.Storage.Delete(key); err != nil {
+			logrus.WithError(err).WithField("key", key).Warn("Gagal menghapus file foto")
+		}
+	}
+}
@@ -0,0 +1,403 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/mahad-service-go/dto"
+	"github.com/habbazettt/mahad-service-go/models"
+	"github.com/habbazettt/mahad-service-go/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type QadhaService interface {
+	GetAllQadha(c *fiber.Ctx) error
+	GetQadhaByID(c *fiber.Ctx) error
+	JadwalkanQadha(c *fiber.Ctx) error
+	SelesaikanQadha(c *fiber.Ctx) error
+}
+
+type qadhaService struct {
+	DB       *gorm.DB
+	Notifier utils.Notifier
+}
+
+func NewQadhaService(db *gorm.DB, notifier utils.Notifier) QadhaService {
+	return &qadhaService{DB: db, Notifier: notifier}
+}
+
+// syncQadhaAbsensi menyesuaikan kewajiban qadha dengan status absensi.
+// Alpa atau izin membuat qadha pending, sedangkan status lain menghapus qadha yang belum selesai.
+func syncQadhaAbsensi(tx *gorm.DB, absensi models.Absensi) error {
+	status := strings.ToLower(absensi.Status)
+
+	if status != "alpa" && status != "izin" {
+		return tx.Where("absensi_id = ? AND status <> ?", absensi.ID, models.StatusQadhaSelesai).
+			Delete(&models.Qadha{}).Error
+	}
+
+	qadha := models.Qadha{
+		AbsensiID:      absensi.ID,
+		MahasantriID:   absensi.MahasantriID,
+		MentorID:       absensi.MentorID,
+		Alasan:         status,
+		TanggalAbsensi: absensi.Tanggal,
+		WaktuAbsensi:   strings.ToLower(absensi.Waktu),
+		Status:         models.StatusQadhaPending,
+	}
+
+	// Qadha yang sudah ada tetap dipertahankan, hanya data absensinya yang diperbarui
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "absensi_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"alasan", "tanggal_absensi", "waktu_absensi", "updated_at"}),
+	}).Create(&qadha).Error
+}
+
+// countQadhaTertunda menghitung qadha yang belum selesai per mahasantri
+func countQadhaTertunda(db *gorm.DB, mahasantriIDs []uint) (map[uint]int, error) {
+	counts := make(map[uint]int)
+	if len(mahasantriIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		MahasantriID uint
+		Total        int
+	}
+	if err := db.Model(&models.Qadha{}).
+		Select("mahasantri_id, COUNT(*) as total").
+		Where("mahasantri_id IN ? AND status <> ?", mahasantriIDs, models.StatusQadhaSelesai).
+		Group("mahasantri_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.MahasantriID] = row.Total
+	}
+	return counts, nil
+}
+
+func toQadhaResponse(q models.Qadha) dto.QadhaResponse {
+	response := dto.QadhaResponse{
+		ID:             q.ID,
+		AbsensiID:      q.AbsensiID,
+		Alasan:         q.Alasan,
+		TanggalAbsensi: q.TanggalAbsensi.Format("02-01-2006"),
+		WaktuAbsensi:   q.WaktuAbsensi,
+		Status:         string(q.Status),
+		JadwalWaktu:    q.JadwalWaktu,
+		HafalanID:      q.HafalanID,
+		SelesaiPada:    q.SelesaiPada,
+		Catatan:        q.Catatan,
+		MentorID:       q.MentorID,
+		Mahasantri: dto.MahasantriResponseDTO{
+			ID:      q.Mahasantri.ID,
+			Nama:    q.Mahasantri.Nama,
+			NIM:     q.Mahasantri.NIM,
+			Jurusan: q.Mahasantri.Jurusan,
+			Gender:  q.Mahasantri.Gender,
+		},
+		CreatedAt: q.CreatedAt,
+	}
+	if q.JadwalTanggal != nil {
+		response.JadwalTanggal = q.JadwalTanggal.Format("02-01-2006")
+	}
+	return response
+}
+
+// findQadhaForMentor mengambil qadha yang dimiliki mahasantri bimbingan mentor
+func (s *qadhaService) findQadhaForMentor(id int, mentorID uint) (models.Qadha, error) {
+	var qadha models.Qadha
+	err := s.DB.Preload("Mahasantri").
+		Joins("JOIN mahasantris ON mahasantris.id = qadhas.mahasantri_id").
+		Where("qadhas.id = ? AND mahasantris.mentor_id = ?", id, mentorID).
+		First(&qadha).Error
+	return qadha, err
+}
+
+// GetAllQadha - Mengambil daftar qadha setoran
+// @Summary Mengambil daftar qadha setoran
+// @Description Mentor melihat qadha milik mahasantri bimbingannya, mahasantri hanya melihat qadha miliknya sendiri. Qadha dibuat otomatis saat mahasantri alpa atau izin.
+// @Tags Qadha
+// @Accept json
+// @Produce json
+// @Param page query int false "Nomor halaman" default(1)
+// @Param limit query int false "Jumlah data per halaman" default(10)
+// @Param mahasantri_id query int false "Filter berdasarkan ID Mahasantri (hanya untuk Mentor)"
+// @Param status query string false "Filter berdasarkan status" Enums(pending, terjadwal, selesai)
+// @Param outstanding query bool false "Hanya qadha yang belum selesai"
+// @Success 200 {object} utils.Response "Daftar qadha berhasil diambil"
+// @Failure 500 {object} utils.Response "Gagal mengambil qadha"
+// @Security BearerAuth
+// @Router /api/v1/qadha [get]
+func (s *qadhaService) GetAllQadha(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+
+	log := logrus.WithFields(logrus.Fields{
+		"handler":  "GetAllQadha",
+		"userID":   claims.ID,
+		"userRole": claims.Role,
+	})
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	offset := (page - 1) * limit
+
+	query := s.DB.Model(&models.Qadha{})
+
+	switch claims.Role {
+	case RoleMahasantri:
+		query = query.Where("qadhas.mahasantri_id = ?", claims.ID)
+	case RoleMentor:
+		query = query.Joins("JOIN mahasantris ON mahasantris.id = qadhas.mahasantri_id").
+			Where("mahasantris.mentor_id = ?", claims.ID)
+		if mahasantriID := c.Query("mahasantri_id"); mahasantriID != "" {
+			query = query.Where("qadhas.mahasantri_id = ?", mahasantriID)
+		}
+	}
+
+	if status := c.Query("status"); status != "" {
+		query = query.Where("qadhas.status = ?", status)
+	}
+	if c.QueryBool("outstanding", false) {
+		query = query.Where("qadhas.status <> ?", models.StatusQadhaSelesai)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		log.WithError(err).Error("Gagal menghitung qadha")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil qadha", err.Error())
+	}
+
+	var qadhas []models.Qadha
+	if err := query.Preload("Mahasantri").
+		Order("qadhas.tanggal_absensi DESC").
+		Limit(limit).Offset(offset).
+		Find(&qadhas).Error; err != nil {
+		log.WithError(err).Error("Gagal mengambil qadha")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil qadha", err.Error())
+	}
+
+	response := make([]dto.QadhaResponse, len(qadhas))
+	for i, q := range qadhas {
+		response[i] = toQadhaResponse(q)
+	}
+
+	log.WithField("total_data", total).Info("Berhasil mengambil daftar qadha")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Daftar qadha berhasil diambil", fiber.Map{
+		"pagination": fiber.Map{
+			"current_page": page,
+			"total_data":   total,
+			"total_pages":  int(math.Ceil(float64(total) / float64(limit))),
+		},
+		"qadha": response,
+	})
+}
+
+// GetQadhaByID - Mengambil detail qadha
+// @Summary Mengambil detail qadha setoran
+// @Description Mengambil detail qadha berdasarkan ID. Mentor hanya dapat melihat qadha milik mahasantri bimbingannya.
+// @Tags Qadha
+// @Accept json
+// @Produce json
+// @Param id path int true "ID Qadha"
+// @Success 200 {object} utils.Response "Qadha ditemukan"
+// @Failure 400 {object} utils.Response "ID tidak valid"
+// @Failure 404 {object} utils.Response "Qadha tidak ditemukan"
+// @Security BearerAuth
+// @Router /api/v1/qadha/{id} [get]
+func (s *qadhaService) GetQadhaByID(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "ID qadha tidak valid", nil)
+	}
+
+	var qadha models.Qadha
+	switch claims.Role {
+	case RoleMentor:
+		qadha, err = s.findQadhaForMentor(id, claims.ID)
+	default:
+		err = s.DB.Preload("Mahasantri").Where("id = ? AND mahasantri_id = ?", id, claims.ID).First(&qadha).Error
+	}
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.ResponseError(c, fiber.StatusNotFound, "Qadha tidak ditemukan", nil)
+		}
+		logrus.WithError(err).WithField("qadha_id", id).Error("Gagal mengambil qadha")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil qadha", err.Error())
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Qadha ditemukan", toQadhaResponse(qadha))
+}
+
+// JadwalkanQadha - Mentor menjadwalkan sesi qadha
+// @Summary Menjadwalkan qadha setoran
+// @Description Mentor menentukan tanggal dan waktu sesi pengganti. Qadha yang sudah terjadwal dapat dijadwalkan ulang selama belum selesai.
+// @Tags Qadha
+// @Accept json
+// @Produce json
+// @Param id path int true "ID Qadha"
+// @Param request body dto.JadwalkanQadhaRequest true "Jadwal qadha"
+// @Success 200 {object} utils.Response "Qadha berhasil dijadwalkan"
+// @Failure 400 {object} utils.Response "Request tidak valid atau qadha sudah selesai"
+// @Failure 404 {object} utils.Response "Qadha tidak ditemukan"
+// @Security BearerAuth
+// @Router /api/v1/qadha/{id}/jadwal [put]
+func (s *qadhaService) JadwalkanQadha(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "ID qadha tidak valid", nil)
+	}
+
+	log := logrus.WithFields(logrus.Fields{"handler": "JadwalkanQadha", "mentorID": claims.ID, "qadha_id": id})
+
+	var req dto.JadwalkanQadhaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Request body tidak valid", err.Error())
+	}
+
+	tanggal, err := time.Parse("02-01-2006", req.Tanggal)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Format tanggal tidak valid, gunakan DD-MM-YYYY", nil)
+	}
+	waktu := strings.ToLower(req.Waktu)
+	if waktu != "shubuh" && waktu != "isya" {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Waktu harus shubuh atau isya", nil)
+	}
+
+	qadha, err := s.findQadhaForMentor(id, claims.ID)
+	if err != nil {
+		log.WithError(err).Warn("Qadha tidak ditemukan")
+		return utils.ResponseError(c, fiber.StatusNotFound, "Qadha tidak ditemukan", nil)
+	}
+	if err := ensureMahasantriWritable(s.DB, qadha.MahasantriID); err != nil {
+		return responseMahasantriFrozen(c, err)
+	}
+
+	if qadha.Status == models.StatusQadhaSelesai {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Qadha sudah selesai dan tidak dapat dijadwalkan ulang", nil)
+	}
+
+	qadha.Status = models.StatusQadhaTerjadwal
+	qadha.JadwalTanggal = &tanggal
+	qadha.JadwalWaktu = waktu
+	if req.Catatan != "" {
+		qadha.Catatan = req.Catatan
+	}
+	if err := s.DB.Omit(clause.Associations).Save(&qadha).Error; err != nil {
+		log.WithError(err).Error("Gagal menjadwalkan qadha")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal menjadwalkan qadha", err.Error())
+	}
+
+	utils.SendNotifications(s.Notifier, utils.Notification{
+		RecipientRole: RoleMahasantri,
+		RecipientID:   qadha.MahasantriID,
+		Title:         "Jadwal qadha setoran",
+		Message: fmt.Sprintf("Qadha setoran %s %s dijadwalkan pada %s (%s)",
+			qadha.WaktuAbsensi, qadha.TanggalAbsensi.Format("02-01-2006"), tanggal.Format("02-01-2006"), waktu),
+		Data: map[string]interface{}{
+			"qadha_id":       qadha.ID,
+			"jadwal_tanggal": tanggal.Format("02-01-2006"),
+			"jadwal_waktu":   waktu,
+		},
+	})
+
+	log.Info("Qadha berhasil dijadwalkan")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Qadha berhasil dijadwalkan", toQadhaResponse(qadha))
+}
+
+// SelesaikanQadha - Mentor menutup qadha dengan menautkan setoran hafalan
+// @Summary Menyelesaikan qadha setoran
+// @Description Menutup qadha dengan menautkan data hafalan (setoran pengganti) milik mahasantri yang sama.
+// @Tags Qadha
+// @Accept json
+// @Produce json
+// @Param id path int true "ID Qadha"
+// @Param request body dto.SelesaikanQadhaRequest true "Hafalan pengganti"
+// @Success 200 {object} utils.Response "Qadha berhasil diselesaikan"
+// @Failure 400 {object} utils.Response "Request tidak valid atau qadha sudah selesai"
+// @Failure 404 {object} utils.Response "Qadha atau hafalan tidak ditemukan"
+// @Security BearerAuth
+// @Router /api/v1/qadha/{id}/selesai [put]
+func (s *qadhaService) SelesaikanQadha(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "ID qadha tidak valid", nil)
+	}
+
+	log := logrus.WithFields(logrus.Fields{"handler": "SelesaikanQadha", "mentorID": claims.ID, "qadha_id": id})
+
+	var req dto.SelesaikanQadhaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Request body tidak valid", err.Error())
+	}
+	if req.HafalanID == 0 {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "hafalan_id wajib diisi", nil)
+	}
+
+	qadha, err := s.findQadhaForMentor(id, claims.ID)
+	if err != nil {
+		log.WithError(err).Warn("Qadha tidak ditemukan")
+		return utils.ResponseError(c, fiber.StatusNotFound, "Qadha tidak ditemukan", nil)
+	}
+	if err := ensureMahasantriWritable(s.DB, qadha.MahasantriID); err != nil {
+		return responseMahasantriFrozen(c, err)
+	}
+
+	if qadha.Status == models.StatusQadhaSelesai {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Qadha sudah selesai", nil)
+	}
+
+	var hafalan models.Hafalan
+	if err := s.DB.Where("id = ? AND mahasantri_id = ?", req.HafalanID, qadha.MahasantriID).First(&hafalan).Error; err != nil {
+		log.WithError(err).WithField("hafalan_id", req.HafalanID).Warn("Hafalan tidak ditemukan untuk mahasantri ini")
+		return utils.ResponseError(c, fiber.StatusNotFound, "Hafalan tidak ditemukan untuk mahasantri ini", nil)
+	}
+
+	// Satu setoran hafalan hanya dapat menutup satu qadha
+	var used int64
+	if err := s.DB.Model(&models.Qadha{}).Where("hafalan_id = ? AND id <> ?", hafalan.ID, qadha.ID).Count(&used).Error; err != nil {
+		log.WithError(err).Error("Gagal memeriksa hafalan qadha")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal menyelesaikan qadha", err.Error())
+	}
+	if used > 0 {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Hafalan sudah digunakan untuk qadha lain", nil)
+	}
+
+	now := time.Now()
+	qadha.Status = models.StatusQadhaSelesai
+	qadha.HafalanID = &hafalan.ID
+	qadha.SelesaiPada = &now
+	if req.Catatan != "" {
+		qadha.Catatan = req.Catatan
+	}
+	if err := s.DB.Omit(clause.Associations).Save(&qadha).Error; err != nil {
+		// Pemeriksaan di atas dapat kalah cepat dengan permintaan lain, unique index idx_qadha_hafalan yang menentukan
+		if utils.IsUniqueViolation(err) {
+			return utils.ResponseError(c, fiber.StatusBadRequest, "Hafalan sudah digunakan untuk qadha lain", nil)
+		}
+		log.WithError(err).Error("Gagal menyelesaikan qadha")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal menyelesaikan qadha", err.Error())
+	}
+
+	log.WithField("hafalan_id", hafalan.ID).Info("Qadha berhasil diselesaikan")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Qadha berhasil diselesaikan", toQadhaResponse(qadha))
+}
@@ -0,0 +1,410 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/habbazettt/mahad-service-go/config"
+	"github.com/habbazettt/mahad-service-go/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultQLearningAlpha = 0.1
+	defaultQLearningGamma = 0.1
+
+	// rewardMaksimal menyamakan skala reward dengan skor efektivitas jadwal (1-5)
+	rewardMaksimal = 5.0
+
+	// batasHariRewardMurojaah membatasi log lama yang dipakai sebagai reward agar data lama tidak membanjiri pembaruan
+	batasHariRewardMurojaah = 7
+)
+
+var errQStateTidakDikenal = errors.New("state atau jadwal tidak ada di Q-Table")
+
+// errQVersiBerubah dikembalikan saat koreksi reward ditujukan ke versi model yang sudah tidak aktif
+var errQVersiBerubah = errors.New("versi model aktif berubah sejak reward diterapkan")
+
+// qUpdateMu menyerialkan pembaruan nilai Q di dalam satu proses.
+// Antar instance, konsistensi dijaga oleh row lock di tabel q_values.
+var qUpdateMu sync.Mutex
+
+// getQLearningParams membaca learning rate (QLEARNING_ALPHA) dan discount factor (QLEARNING_GAMMA)
+func getQLearningParams() (alpha, gamma float64) {
+	alpha, gamma = defaultQLearningAlpha, defaultQLearningGamma
+	if v, err := strconv.ParseFloat(os.Getenv("QLEARNING_ALPHA"), 64); err == nil && v > 0 && v <= 1 {
+		alpha = v
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("QLEARNING_GAMMA"), 64); err == nil && v >= 0 && v < 1 {
+		gamma = v
+	}
+	return alpha, gamma
+}
+
+// daftarKategoriHafalan adalah seluruh kategori hafalan yang dipakai pada state Q-Table, terurut dari yang terkecil
+var daftarKategoriHafalan = []string{"1-10 Juz", "11-20 Juz", "21-30 Juz"}
+
+// kategoriHafalan mengubah jumlah juz hafalan menjadi kategori yang dipakai pada state Q-Table
+func kategoriHafalan(totalJuz int) string {
+	switch {
+	case totalJuz <= 10:
+		return "1-10 Juz"
+	case totalJuz <= 20:
+		return "11-20 Juz"
+	default:
+		return "21-30 Juz"
+	}
+}
+
+// filterKategoriHafalan mengembalikan kondisi SQL total_hafalan yang setara dengan kategoriHafalan
+func filterKategoriHafalan(kategori string) (string, []interface{}, bool) {
+	switch kategori {
+	case "1-10 Juz":
+		return "total_hafalan <= ?", []interface{}{10}, true
+	case "11-20 Juz":
+		return "total_hafalan BETWEEN ? AND ?", []interface{}{11, 20}, true
+	case "21-30 Juz":
+		return "total_hafalan > ?", []interface{}{20}, true
+	}
+	return "", nil, false
+}
+
+// StateJadwalPersonal menyusun state Q-Table dari jadwal personal pengguna.
+// Dipakai juga oleh cmd/train agar state hasil training sama dengan state saat pembelajaran online.
+func StateJadwalPersonal(jp models.JadwalPersonal) string {
+	return fmt.Sprintf("%s_%s", normalizeKesibukan(jp.Kesibukan), kategoriHafalan(jp.TotalHafalan))
+}
+
+// updateQValue menerapkan aturan Q-learning
+// Q(s,a) <- Q(s,a) + alpha * (reward + gamma * max Q(s,a') - Q(s,a)).
+// Jadwal murojaah tidak mengubah kondisi pengguna, sehingga state berikutnya adalah state yang sama.
+// Hanya pasangan state/jadwal yang sudah ada di Q-Table yang diperbarui agar input bebas tidak menambah aksi baru.
+// db boleh berupa transaksi pemanggil; nilai di memori selalu dibaca ulang dari database pada pembaruan berikutnya.
+func updateQValue(db *gorm.DB, state, action string, reward float64) (float64, error) {
+	newValue, _, err := updateQValueVersi(db, state, action, reward)
+	return newValue, err
+}
+
+// updateQValueVersi sama dengan updateQValue, dan juga mengembalikan versi model yang diperbarui
+func updateQValueVersi(db *gorm.DB, state, action string, reward float64) (float64, int, error) {
+	qUpdateMu.Lock()
+	defer qUpdateMu.Unlock()
+
+	actions, versi, ok := config.Models.GetQActions(state)
+	if !ok {
+		return 0, 0, errQStateTidakDikenal
+	}
+	if _, ok := actions[action]; !ok {
+		return 0, 0, errQStateTidakDikenal
+	}
+
+	alpha, gamma := getQLearningParams()
+
+	var newValue float64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var qValue models.QValue
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("model_versi = ? AND state = ? AND action = ?", versi, state, action).
+			Attrs(models.QValue{ModelVersi: versi, State: state, Action: action, Value: actions[action]}).
+			FirstOrCreate(&qValue).Error; err != nil {
+			return err
+		}
+		// Nilai di database adalah sumber kebenaran jika instance lain sudah memperbaruinya
+		actions[action] = qValue.Value
+
+		maxNext := qValue.Value
+		for _, v := range actions {
+			if v > maxNext {
+				maxNext = v
+			}
+		}
+
+		newValue = qValue.Value + alpha*(reward+gamma*maxNext-qValue.Value)
+		return tx.Model(&qValue).Updates(map[string]interface{}{
+			"value":         newValue,
+			"jumlah_update": gorm.Expr("jumlah_update + 1"),
+		}).Error
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	config.Models.SetQValue(versi, state, action, newValue)
+	return newValue, versi, nil
+}
+
+// koreksiQValue mengoreksi reward yang sudah pernah diterapkan ke Q(s,a) pada versi model tertentu.
+// Reward masuk ke aturan Q-learning dengan koefisien alpha, sehingga perubahan reward cukup ditambahkan sebagai
+// alpha * selisih tanpa dihitung sebagai pembaruan baru.
+func koreksiQValue(db *gorm.DB, versi int, state, action string, selisihReward float64) (float64, error) {
+	qUpdateMu.Lock()
+	defer qUpdateMu.Unlock()
+
+	actions, versiAktif, ok := config.Models.GetQActions(state)
+	if !ok {
+		return 0, errQStateTidakDikenal
+	}
+	if _, ok := actions[action]; !ok {
+		return 0, errQStateTidakDikenal
+	}
+	if versiAktif != versi {
+		return 0, errQVersiBerubah
+	}
+
+	alpha, _ := getQLearningParams()
+
+	var newValue float64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var qValue models.QValue
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("model_versi = ? AND state = ? AND action = ?", versi, state, action).
+			Attrs(models.QValue{ModelVersi: versi, State: state, Action: action, Value: actions[action]}).
+			FirstOrCreate(&qValue).Error; err != nil {
+			return err
+		}
+
+		newValue = qValue.Value + alpha*selisihReward
+		return tx.Model(&qValue).Update("value", newValue).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	config.Models.SetQValue(versi, state, action, newValue)
+	return newValue, nil
+}
+
+// terapkanRewardSekali menerapkan reward rating untuk satu pasangan state/jadwal. Jika pasangan dan versi model sama
+// dengan reward sebelumnya, hanya selisih rating yang diterapkan sehingga rating yang diubah bolak-balik tidak
+// menggeser nilai Q lebih jauh dari satu reward. rewardLama bernilai 0 jika pasangan tersebut belum pernah diberi reward.
+func terapkanRewardSekali(tx *gorm.DB, state, action string, reward, rewardLama, versiLama int) (float64, int, error) {
+	if rewardLama != 0 && versiLama == config.Models.Versi() {
+		if reward == rewardLama {
+			return 0, versiLama, nil
+		}
+		newValue, err := koreksiQValue(tx, versiLama, state, action, float64(reward-rewardLama))
+		if !errors.Is(err, errQVersiBerubah) {
+			return newValue, versiLama, err
+		}
+	}
+	return updateQValueVersi(tx, state, action, float64(reward))
+}
+
+// rewardEfektifitasJadwal memakai skor efektivitas jadwal (1-5) dari pengguna sebagai reward untuk jadwal tersebut.
+// Reward yang sudah diterapkan dicatat di jadwal personal, sehingga menyimpan ulang atau mengubah rating hanya
+// mengoreksi reward sebelumnya untuk state dan jadwal yang sama.
+func rewardEfektifitasJadwal(db *gorm.DB, jadwalPersonalID uint) {
+	log := logrus.WithField("jadwal_personal_id", jadwalPersonalID)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var jp models.JadwalPersonal
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&jp, jadwalPersonalID).Error; err != nil {
+			return err
+		}
+		if jp.EfektifitasJadwal < 1 || jp.EfektifitasJadwal > 5 || jp.Jadwal == "" {
+			return nil
+		}
+
+		state := StateJadwalPersonal(jp)
+		log = log.WithFields(logrus.Fields{"state": state, "jadwal": jp.Jadwal, "reward": jp.EfektifitasJadwal})
+
+		rewardLama := 0
+		if jp.RewardState == state && jp.RewardJadwal == jp.Jadwal {
+			rewardLama = jp.RewardEfektifitas
+		}
+		newValue, versi, err := terapkanRewardSekali(tx, state, jp.Jadwal, jp.EfektifitasJadwal, rewardLama, jp.RewardModelVersi)
+		if err != nil {
+			return err
+		}
+		if rewardLama == jp.EfektifitasJadwal && versi == jp.RewardModelVersi {
+			return nil
+		}
+
+		log.WithField("q_value", newValue).Info("Nilai Q diperbarui dari efektivitas jadwal")
+		return tx.Model(&jp).UpdateColumns(map[string]interface{}{
+			"reward_state":       state,
+			"reward_jadwal":      jp.Jadwal,
+			"reward_efektifitas": jp.EfektifitasJadwal,
+			"reward_model_versi": versi,
+		}).Error
+	})
+	if errors.Is(err, errQStateTidakDikenal) {
+		log.Debug("Jadwal personal tidak cocok dengan Q-Table, nilai Q tidak diperbarui")
+		return
+	}
+	if err != nil {
+		log.WithError(err).Error("Gagal memperbarui nilai Q dari efektivitas jadwal")
+	}
+}
+
+// rewardUmpanBalikRekomendasi memakai skor efektivitas (1-5) dari umpan balik sebagai reward untuk jadwal yang
+// direkomendasikan. Setiap rekomendasi hanya memberi satu reward; skor yang diubah hanya mengoreksi reward sebelumnya.
+func rewardUmpanBalikRekomendasi(db *gorm.DB, umpanBalikID uint) {
+	log := logrus.WithField("umpan_balik_id", umpanBalikID)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var umpanBalik models.UmpanBalikRekomendasi
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("JadwalRekomendasi").First(&umpanBalik, umpanBalikID).Error; err != nil {
+			return err
+		}
+		if umpanBalik.Efektivitas == nil {
+			return nil
+		}
+
+		rekomendasi := umpanBalik.JadwalRekomendasi
+		skor := *umpanBalik.Efektivitas
+		log = log.WithFields(logrus.Fields{"state": rekomendasi.State, "jadwal": rekomendasi.RekomendasiJadwal, "reward": skor})
+
+		newValue, versi, err := terapkanRewardSekali(tx, rekomendasi.State, rekomendasi.RekomendasiJadwal, skor, umpanBalik.RewardEfektivitas, umpanBalik.RewardModelVersi)
+		if err != nil {
+			return err
+		}
+		if umpanBalik.RewardEfektivitas == skor && versi == umpanBalik.RewardModelVersi {
+			return nil
+		}
+
+		log.WithField("q_value", newValue).Info("Nilai Q diperbarui dari umpan balik rekomendasi")
+		return tx.Model(&umpanBalik).UpdateColumns(map[string]interface{}{
+			"reward_efektivitas": skor,
+			"reward_model_versi": versi,
+		}).Error
+	})
+	if errors.Is(err, errQStateTidakDikenal) {
+		log.Debug("Rekomendasi tidak cocok dengan Q-Table, nilai Q tidak diperbarui")
+		return
+	}
+	if err != nil {
+		log.WithError(err).Error("Gagal memperbarui nilai Q dari umpan balik rekomendasi")
+	}
+}
+
+// catatJadwalLogHarian menyimpan jadwal dan state jadwal personal yang berlaku pada log harian saat sesi pertama
+// dicatat, agar reward murojaah diberikan ke jadwal hari itu walaupun jadwal personal diubah setelahnya.
+func catatJadwalLogHarian(tx *gorm.DB, logHarian *models.LogHarian) error {
+	if logHarian.Jadwal != "" {
+		return nil
+	}
+
+	var jp models.JadwalPersonal
+	if err := tx.Where("mahasantri_id = ?", logHarian.MahasantriID).Limit(1).Find(&jp).Error; err != nil {
+		return err
+	}
+	if jp.ID == 0 || jp.Jadwal == "" {
+		return nil
+	}
+
+	logHarian.Jadwal = jp.Jadwal
+	logHarian.StateJadwal = StateJadwalPersonal(jp)
+	return tx.Model(logHarian).Updates(map[string]interface{}{
+		"jadwal":       logHarian.Jadwal,
+		"state_jadwal": logHarian.StateJadwal,
+	}).Error
+}
+
+// getIntervalRewardMurojaah membaca REWARD_MUROJAAH_INTERVAL (menit), default satu jam
+func getIntervalRewardMurojaah() time.Duration {
+	menit, err := strconv.Atoi(os.Getenv("REWARD_MUROJAAH_INTERVAL"))
+	if err != nil || menit <= 0 {
+		return time.Hour
+	}
+	return time.Duration(menit) * time.Minute
+}
+
+// JalankanRewardMurojaah menjalankan settleRewardMurojaah di background secara berkala sampai ctx selesai,
+// sehingga pembaruan nilai Q tidak membebani request pengguna
+func JalankanRewardMurojaah(ctx context.Context, db *gorm.DB) {
+	interval := getIntervalRewardMurojaah()
+	logrus.WithField("interval", interval.String()).Info("Reward murojaah dijalankan di background")
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		settleRewardMurojaah(db)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				settleRewardMurojaah(db)
+			}
+		}
+	}()
+}
+
+// settleRewardMurojaah memakai tingkat penyelesaian log harian yang sudah lewat sebagai reward untuk jadwal yang
+// berlaku pada hari tersebut. Log hari ini belum dihitung karena masih bisa berubah, dan setiap log hanya dipakai sekali.
+func settleRewardMurojaah(db *gorm.DB) {
+	today := time.Now()
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+
+	var logs []models.LogHarian
+	if err := db.Where("reward_diterapkan = ? AND total_target_halaman > 0", false).
+		Where("tanggal >= ? AND tanggal < ?", today.AddDate(0, 0, -batasHariRewardMurojaah), today).
+		Order("tanggal ASC, id ASC").
+		Find(&logs).Error; err != nil {
+		logrus.WithError(err).Error("Gagal mengambil log harian untuk reward murojaah")
+		return
+	}
+
+	for _, logHarian := range logs {
+		settleRewardLogHarian(db, logHarian)
+	}
+}
+
+// settleRewardLogHarian menandai log dan memperbarui nilai Q dalam satu transaksi, sehingga log tidak tertandai
+// tanpa reward jika pembaruan gagal dan tidak dihitung dua kali oleh instance lain
+func settleRewardLogHarian(db *gorm.DB, logHarian models.LogHarian) {
+	log := logrus.WithFields(logrus.Fields{
+		"mahasantri_id": logHarian.MahasantriID,
+		"tanggal":       logHarian.Tanggal.Format("02-01-2006"),
+		"state":         logHarian.StateJadwal,
+		"jadwal":        logHarian.Jadwal,
+	})
+
+	rasio := float64(logHarian.TotalSelesaiHalaman) / float64(logHarian.TotalTargetHalaman)
+	if rasio > 1 {
+		rasio = 1
+	}
+	reward := rasio * rewardMaksimal
+
+	var newValue float64
+	diperbarui := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.LogHarian{}).
+			Where("id = ? AND reward_diterapkan = ?", logHarian.ID, false).
+			Update("reward_diterapkan", true)
+		if result.Error != nil {
+			return result.Error
+		}
+		// Sudah diproses instance lain, atau log lama yang tidak mencatat jadwal hari itu
+		if result.RowsAffected == 0 || logHarian.Jadwal == "" {
+			return nil
+		}
+
+		var err error
+		newValue, err = updateQValue(tx, logHarian.StateJadwal, logHarian.Jadwal, reward)
+		if errors.Is(err, errQStateTidakDikenal) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		diperbarui = true
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("Gagal memperbarui nilai Q dari penyelesaian murojaah")
+		return
+	}
+	if diperbarui {
+		log.WithFields(logrus.Fields{"reward": reward, "q_value": newValue}).Info("Nilai Q diperbarui dari penyelesaian murojaah")
+	}
+}
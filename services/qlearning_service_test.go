@@ -0,0 +1,45 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// cocokFilterKategori mengevaluasi kondisi dari filterKategoriHafalan terhadap satu nilai total_hafalan
+func cocokFilterKategori(t *testing.T, kondisi string, args []interface{}, total int) bool {
+	t.Helper()
+	switch kondisi {
+	case "total_hafalan <= ?":
+		return total <= args[0].(int)
+	case "total_hafalan BETWEEN ? AND ?":
+		return total >= args[0].(int) && total <= args[1].(int)
+	case "total_hafalan > ?":
+		return total > args[0].(int)
+	}
+	t.Fatalf("kondisi tidak dikenal: %q", kondisi)
+	return false
+}
+
+func TestKategoriHafalan(t *testing.T) {
+	cases := map[int]string{0: "1-10 Juz", 1: "1-10 Juz", 10: "1-10 Juz", 11: "11-20 Juz", 20: "11-20 Juz", 21: "21-30 Juz", 30: "21-30 Juz"}
+	for total, want := range cases {
+		assert.Equal(t, want, kategoriHafalan(total), "total_hafalan %d", total)
+	}
+}
+
+func TestFilterKategoriHafalanSetaraKategoriHafalan(t *testing.T) {
+	for _, kategori := range daftarKategoriHafalan {
+		kondisi, args, ok := filterKategoriHafalan(kategori)
+		if !assert.True(t, ok, kategori) {
+			continue
+		}
+		for total := 0; total <= 30; total++ {
+			assert.Equal(t, kategoriHafalan(total) == kategori, cocokFilterKategori(t, kondisi, args, total),
+				"kategori %s, total_hafalan %d", kategori, total)
+		}
+	}
+
+	_, _, ok := filterKategoriHafalan("31-40 Juz")
+	assert.False(t, ok)
+}
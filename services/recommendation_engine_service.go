@@ -0,0 +1,402 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/habbazettt/mahad-service-go/config"
+	"github.com/habbazettt/mahad-service-go/dto"
+	"github.com/habbazettt/mahad-service-go/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Nama engine rekomendasi yang bisa dipilih lewat REKOMENDASI_ENGINE
+const (
+	EngineQLearning = "qlearning"
+	EngineHistoris  = "historis"
+	EngineRuleBased = "rule_based"
+	EngineThompson  = "thompson"
+
+	defaultRekomendasiEngine = EngineQLearning
+)
+
+// HasilRekomendasi adalah keluaran sebuah engine untuk satu state
+type HasilRekomendasi struct {
+	Peringkat       []dto.PeringkatRekomendasi
+	TipeDasar       string // Tipe tanpa detail tambahan, dipakai sebagai label metrics
+	TipeRekomendasi string
+	StateTetangga   []dto.StateTetangga
+	EngineVersi     string
+}
+
+// RecommendationEngine menghasilkan peringkat jadwal murojaah untuk sebuah state
+type RecommendationEngine interface {
+	Name() string
+	Recommend(state string, top int) (HasilRekomendasi, error)
+}
+
+// newRecommendationEngines menyiapkan seluruh engine yang tersedia, diindeks berdasarkan namanya
+func newRecommendationEngines(db *gorm.DB) map[string]RecommendationEngine {
+	engines := []RecommendationEngine{
+		&qLearningEngine{DB: db},
+		&historisEngine{},
+		&ruleBasedEngine{},
+		&thompsonEngine{DB: db},
+	}
+
+	hasil := make(map[string]RecommendationEngine, len(engines))
+	for _, e := range engines {
+		hasil[e.Name()] = e
+	}
+	return hasil
+}
+
+// namaEngineDefault membaca REKOMENDASI_ENGINE. Nilai yang tidak dikenal jatuh ke engine Q-learning.
+func namaEngineDefault(engines map[string]RecommendationEngine) string {
+	nama := strings.ToLower(strings.TrimSpace(os.Getenv("REKOMENDASI_ENGINE")))
+	if nama == "" {
+		return defaultRekomendasiEngine
+	}
+	if _, ok := engines[nama]; !ok {
+		logrus.WithField("engine", nama).Warn("REKOMENDASI_ENGINE tidak dikenal, memakai engine qlearning")
+		return defaultRekomendasiEngine
+	}
+	return nama
+}
+
+func versiModelEngine(versi int) string {
+	return strconv.Itoa(versi)
+}
+
+// daftarJadwalModel mengumpulkan seluruh jadwal yang dikenal model aktif, terurut
+func daftarJadwalModel() []string {
+	set := make(map[string]bool)
+	for _, state := range config.Models.States() {
+		actions, _, _ := config.Models.GetQActions(state)
+		for action := range actions {
+			set[action] = true
+		}
+	}
+	for _, info := range config.Models.HistoricalBest() {
+		set[info.Jadwal] = true
+	}
+
+	jadwal := make([]string, 0, len(set))
+	for j := range set {
+		jadwal = append(jadwal, j)
+	}
+	sort.Strings(jadwal)
+	return jadwal
+}
+
+// qLearningEngine memakai Q-Table aktif. State yang tidak dikenal diestimasi dari state serupa,
+// lalu jatuh ke jadwal historis terbaik jika tidak ada state serupa.
+type qLearningEngine struct {
+	DB *gorm.DB
+}
+
+func (e *qLearningEngine) Name() string { return EngineQLearning }
+
+func (e *qLearningEngine) Recommend(state string, top int) (HasilRekomendasi, error) {
+	historicalBest := config.Models.HistoricalBest()
+
+	if stateActions, versi, ok := config.Models.GetQActions(state); ok {
+		return HasilRekomendasi{
+			Peringkat:       peringkatDariQTable(state, stateActions, historicalBest, jumlahUmpanBalik(e.DB, versi, state), top),
+			TipeDasar:       TipeRekomendasiSpesifik,
+			TipeRekomendasi: TipeRekomendasiSpesifik,
+			EngineVersi:     versiModelEngine(versi),
+		}, nil
+	}
+
+	versi := versiModelEngine(config.Models.Versi())
+	if tetangga := tetanggaState(state, config.Models.States()); len(tetangga) > 0 {
+		return HasilRekomendasi{
+			Peringkat:       peringkatDariKemiripan(state, tetangga, gabungkanQTetangga(tetangga), historicalBest, top),
+			TipeDasar:       TipeRekomendasiKemiripan,
+			TipeRekomendasi: tipeRekomendasiKemiripan(tetangga),
+			StateTetangga:   tetangga,
+			EngineVersi:     versi,
+		}, nil
+	}
+
+	return (&historisEngine{}).Recommend(state, top)
+}
+
+// jumlahUmpanBalik menghitung berapa kali nilai Q tiap jadwal pada sebuah state diperbarui dari umpan balik pengguna
+func jumlahUmpanBalik(db *gorm.DB, versi int, state string) map[string]int {
+	var rows []models.QValue
+	if err := db.Select("action", "jumlah_update").
+		Where("model_versi = ? AND state = ? AND jumlah_update > 0", versi, state).
+		Find(&rows).Error; err != nil {
+		logrus.WithError(err).WithField("state", state).Warn("Gagal mengambil jumlah umpan balik nilai Q")
+		return nil
+	}
+
+	hasil := make(map[string]int, len(rows))
+	for _, row := range rows {
+		hasil[row.Action] = row.JumlahUpdate
+	}
+	return hasil
+}
+
+// historisEngine selalu memilih jadwal dengan persentase efektif historis tertinggi seluruh pengguna
+type historisEngine struct{}
+
+func (e *historisEngine) Name() string { return EngineHistoris }
+
+func (e *historisEngine) Recommend(state string, top int) (HasilRekomendasi, error) {
+	historicalBest := config.Models.HistoricalBest()
+	if len(historicalBest) == 0 {
+		return HasilRekomendasi{TipeDasar: TipeRekomendasiTidakAda, TipeRekomendasi: TipeRekomendasiTidakAda}, nil
+	}
+	return HasilRekomendasi{
+		Peringkat:       peringkatDariHistoris(state, historicalBest, top),
+		TipeDasar:       TipeRekomendasiHistoris,
+		TipeRekomendasi: TipeRekomendasiHistoris,
+		EngineVersi:     versiModelEngine(config.Models.Versi()),
+	}, nil
+}
+
+// ruleBasedEngine memilih jadwal berdasarkan aturan sederhana: semakin banyak hafalan semakin banyak sesi murojaah
+// yang dibutuhkan, dan setiap kesibukan tambahan mengurangi satu sesi yang realistis dijalankan.
+type ruleBasedEngine struct{}
+
+// versiRuleBased dinaikkan setiap kali aturan di bawah berubah
+const versiRuleBased = "1"
+
+func (e *ruleBasedEngine) Name() string { return EngineRuleBased }
+
+func targetSesiRuleBased(state string) int {
+	kesibukan, kategori := pecahState(state)
+
+	idx := indeksKategoriHafalan(kategori)
+	if idx < 0 {
+		idx = 0
+	}
+	target := 2 + idx - (len(komponenKesibukan(kesibukan)) - 1)
+	if target < 2 {
+		target = 2
+	}
+	return target
+}
+
+func jumlahSesiJadwal(jadwal string) int {
+	return len(strings.Split(jadwal, ","))
+}
+
+func (e *ruleBasedEngine) Recommend(state string, top int) (HasilRekomendasi, error) {
+	jadwal := daftarJadwalModel()
+	if len(jadwal) == 0 {
+		return HasilRekomendasi{TipeDasar: TipeRekomendasiTidakAda, TipeRekomendasi: TipeRekomendasiTidakAda}, nil
+	}
+
+	historis := make(map[string]config.HistoricalInfo)
+	for _, info := range config.Models.HistoricalBest() {
+		historis[info.Jadwal] = info
+	}
+
+	target := targetSesiRuleBased(state)
+	selisih := func(j string) int {
+		d := jumlahSesiJadwal(j) - target
+		if d < 0 {
+			return -d
+		}
+		return d
+	}
+	sort.Slice(jadwal, func(i, j int) bool {
+		if di, dj := selisih(jadwal[i]), selisih(jadwal[j]); di != dj {
+			return di < dj
+		}
+		if hi, hj := historis[jadwal[i]].PersentaseEfektif, historis[jadwal[j]].PersentaseEfektif; hi != hj {
+			return hi > hj
+		}
+		return jadwal[i] < jadwal[j]
+	})
+	if len(jadwal) > top {
+		jadwal = jadwal[:top]
+	}
+
+	peringkat := make([]dto.PeringkatRekomendasi, len(jadwal))
+	for i, j := range jadwal {
+		item := dto.PeringkatRekomendasi{
+			Peringkat: i + 1,
+			Jadwal:    j,
+			Penjelasan: fmt.Sprintf("Aturan: %s membutuhkan sekitar %d sesi murojaah per hari, jadwal ini memiliki %d sesi.",
+				deskripsiState(state), target, jumlahSesiJadwal(j)),
+		}
+		if info, ok := historis[j]; ok {
+			persen := info.PersentaseEfektif
+			item.PersentaseEfektifHistoris = &persen
+			item.JumlahSampelHistoris = info.TotalPenggunaan
+			item.Penjelasan += " " + kalimatHistoris(info)
+		}
+		peringkat[i] = item
+	}
+
+	return HasilRekomendasi{
+		Peringkat:       peringkat,
+		TipeDasar:       TipeRekomendasiRuleBased,
+		TipeRekomendasi: TipeRekomendasiRuleBased,
+		EngineVersi:     versiRuleBased,
+	}, nil
+}
+
+// thompsonEngine memperlakukan setiap jadwal sebagai bandit Beta-Bernoulli per state. Keberhasilan adalah penilaian
+// efektivitas >= 4 dari jadwal personal dan umpan balik rekomendasi; persentase efektif historis dipakai sebagai prior.
+type thompsonEngine struct {
+	DB *gorm.DB
+}
+
+// bobotPriorThompson adalah jumlah "pengamatan semu" yang diberikan oleh data historis
+const bobotPriorThompson = 2.0
+
+func (e *thompsonEngine) Name() string { return EngineThompson }
+
+func (e *thompsonEngine) Recommend(state string, top int) (HasilRekomendasi, error) {
+	jadwal := daftarJadwalModel()
+	if len(jadwal) == 0 {
+		return HasilRekomendasi{TipeDasar: TipeRekomendasiTidakAda, TipeRekomendasi: TipeRekomendasiTidakAda}, nil
+	}
+
+	berhasil, total, err := e.penilaianPerJadwal(state)
+	if err != nil {
+		return HasilRekomendasi{}, err
+	}
+
+	historis := make(map[string]config.HistoricalInfo)
+	for _, info := range config.Models.HistoricalBest() {
+		historis[info.Jadwal] = info
+	}
+
+	sampel := make(map[string]float64, len(jadwal))
+	for _, j := range jadwal {
+		prior := 0.5
+		if info, ok := historis[j]; ok {
+			prior = info.PersentaseEfektif / 100
+		}
+		alpha := 1 + prior*bobotPriorThompson + float64(berhasil[j])
+		beta := 1 + (1-prior)*bobotPriorThompson + float64(total[j]-berhasil[j])
+		sampel[j] = sampleBeta(alpha, beta)
+	}
+
+	sort.Slice(jadwal, func(i, j int) bool {
+		if sampel[jadwal[i]] != sampel[jadwal[j]] {
+			return sampel[jadwal[i]] > sampel[jadwal[j]]
+		}
+		return jadwal[i] < jadwal[j]
+	})
+	if len(jadwal) > top {
+		jadwal = jadwal[:top]
+	}
+
+	peringkat := make([]dto.PeringkatRekomendasi, len(jadwal))
+	for i, j := range jadwal {
+		item := dto.PeringkatRekomendasi{
+			Peringkat:        i + 1,
+			Jadwal:           j,
+			JumlahUmpanBalik: total[j],
+			Penjelasan: fmt.Sprintf("Sampel peluang efektif %.2f untuk %s dari %d penilaian efektif dari %d penilaian.",
+				sampel[j], deskripsiState(state), berhasil[j], total[j]),
+		}
+		if info, ok := historis[j]; ok {
+			persen := info.PersentaseEfektif
+			item.PersentaseEfektifHistoris = &persen
+			item.JumlahSampelHistoris = info.TotalPenggunaan
+			item.Penjelasan += " " + kalimatHistoris(info)
+		}
+		peringkat[i] = item
+	}
+
+	return HasilRekomendasi{
+		Peringkat:       peringkat,
+		TipeDasar:       TipeRekomendasiThompson,
+		TipeRekomendasi: TipeRekomendasiThompson,
+		EngineVersi:     versiModelEngine(config.Models.Versi()),
+	}, nil
+}
+
+// penilaianPerJadwal menghitung jumlah penilaian efektif (skor >= 4) dan total penilaian tiap jadwal pada sebuah state
+func (e *thompsonEngine) penilaianPerJadwal(state string) (berhasil, total map[string]int, err error) {
+	berhasil = make(map[string]int)
+	total = make(map[string]int)
+
+	var umpanBalik []struct {
+		Aksi        string
+		Efektivitas int
+	}
+	if err := e.DB.Table("umpan_balik_rekomendasis AS ub").
+		Select("jr.rekomendasi_jadwal AS aksi, ub.efektivitas").
+		Joins("JOIN jadwal_rekomendasis jr ON jr.id = ub.jadwal_rekomendasi_id").
+		Where("jr.state = ? AND ub.diterapkan AND ub.efektivitas IS NOT NULL", state).
+		Scan(&umpanBalik).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, ub := range umpanBalik {
+		total[ub.Aksi]++
+		if ub.Efektivitas >= 4 {
+			berhasil[ub.Aksi]++
+		}
+	}
+
+	// Kesibukan pada jadwal personal berupa teks bebas, sehingga database hanya menyaring kategori hafalan dan
+	// mengelompokkan per kesibukan. State kanonis dihitung sekali untuk setiap kesibukan berbeda.
+	_, kategori := pecahState(state)
+	filterKategori, args, ok := filterKategoriHafalan(kategori)
+	if !ok {
+		return berhasil, total, nil
+	}
+	var jadwalPersonal []struct {
+		Kesibukan string
+		Jadwal    string
+		Berhasil  int
+		Total     int
+	}
+	if err := e.DB.Model(&models.JadwalPersonal{}).
+		Select("LOWER(TRIM(kesibukan)) AS kesibukan, jadwal, COUNT(*) FILTER (WHERE efektifitas_jadwal >= 4) AS berhasil, COUNT(*) AS total").
+		Where("efektifitas_jadwal BETWEEN 1 AND 5 AND jadwal <> ''").
+		Where(filterKategori, args...).
+		Group("LOWER(TRIM(kesibukan)), jadwal").
+		Scan(&jadwalPersonal).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, jp := range jadwalPersonal {
+		if kanonisState(fmt.Sprintf("%s_%s", normalizeKesibukan(jp.Kesibukan), kategori)) != state {
+			continue
+		}
+		total[jp.Jadwal] += jp.Total
+		berhasil[jp.Jadwal] += jp.Berhasil
+	}
+	return berhasil, total, nil
+}
+
+// sampleBeta mengambil sampel distribusi Beta dari dua sampel Gamma
+func sampleBeta(alpha, beta float64) float64 {
+	x := sampleGamma(alpha)
+	y := sampleGamma(beta)
+	return x / (x + y)
+}
+
+// sampleGamma memakai metode Marsaglia-Tsang (berlaku untuk shape >= 1, yang selalu terpenuhi di sini)
+func sampleGamma(shape float64) float64 {
+	d := shape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := rand.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := rand.Float64()
+		if math.Log(u) < 0.5*x*x+d-d*v+d*math.Log(v) {
+			return d * v
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"sort"
@@ -16,29 +17,48 @@ import (
 	"gorm.io/gorm"
 )
 
+// Tipe rekomendasi yang disimpan di riwayat. Untuk fallback kemiripan, daftar state tetangga ditambahkan
+// setelah tipe dasarnya.
+const (
+	TipeRekomendasiSpesifik  = "Spesifik"
+	TipeRekomendasiKemiripan = "Kemiripan State"
+	TipeRekomendasiHistoris  = "Umum (Historis Terbaik)"
+	TipeRekomendasiTidakAda  = "Tidak Ada Rekomendasi"
+	TipeRekomendasiRuleBased = "Rule Based"
+	TipeRekomendasiThompson  = "Thompson Sampling"
+)
+
 type RekomendasiService interface {
 	GetRecommendation(c *fiber.Ctx) error
 	GetAllRekomendasi(c *fiber.Ctx) error
 	GetAllKesibukan(c *fiber.Ctx) error
+	GetStatesRekomendasi(c *fiber.Ctx) error
+	CreateUmpanBalikRekomendasi(c *fiber.Ctx) error
+	GetStatistikRekomendasi(c *fiber.Ctx) error
+	GetAnalitikRekomendasi(c *fiber.Ctx) error
+	GetTrenRekomendasi(c *fiber.Ctx) error
 }
 
 type rekomendasiService struct {
-	DB *gorm.DB
+	DB            *gorm.DB
+	Engines       map[string]RecommendationEngine
+	DefaultEngine string
 }
 
 func NewRekomendasiService(db *gorm.DB) RekomendasiService {
-	return &rekomendasiService{DB: db}
+	engines := newRecommendationEngines(db)
+	return &rekomendasiService{DB: db, Engines: engines, DefaultEngine: namaEngineDefault(engines)}
 }
 
 // GetRecommendation - Mendapatkan Rekomendasi Jadwal Muroja'ah
 // @Summary Mendapatkan rekomendasi jadwal muroja'ah
-// @Description Endpoint ini menghasilkan rekomendasi jadwal muroja'ah yang dipersonalisasi berdasarkan kondisi pengguna (kesibukan dan kategori hafalan).
+// @Description Endpoint ini menghasilkan rekomendasi jadwal muroja'ah yang dipersonalisasi berdasarkan kondisi pengguna (kesibukan dan kategori hafalan). Kondisi mahasantri selalu diambil dari jadwal personal yang tersimpan; `kesibukan` dan `kategori_hafalan` hanya boleh dikirim oleh mentor dan ditolak (400) untuk mahasantri. Jika mentor tidak mengirim keduanya, kondisi diambil dari jadwal personal mentor. Nilai yang dikirim harus sesuai dengan daftar di /api/v1/rekomendasi/states. Engine yang dipakai (qlearning, historis, rule_based, thompson) diatur lewat REKOMENDASI_ENGINE dan dicatat di riwayat rekomendasi. Field `top` (default 3, maksimal 10) menentukan jumlah alternatif jadwal pada `peringkat`, masing-masing dilengkapi efektivitas historis, jumlah sampel, dan penjelasan. Field di level atas tetap berisi rekomendasi peringkat pertama.
 // @Tags Rekomendasi
 // @Accept json
 // @Produce json
-// @Param rekomendasiRequest body dto.RecommendationRequest true "Data kondisi pengguna untuk menghasilkan rekomendasi"
+// @Param rekomendasiRequest body dto.RecommendationRequest false "Data kondisi pengguna untuk menghasilkan rekomendasi (opsional)"
 // @Success 200 {object} utils.Response "Rekomendasi berhasil dibuat"
-// @Failure 400 {object} utils.Response "Request body tidak valid"
+// @Failure 400 {object} utils.Response "Request body tidak valid, kondisi tidak dikenal atau dikirim oleh mahasantri, atau jadwal personal belum diisi"
 // @Failure 401 {object} utils.Response "Tidak terautentikasi (token tidak valid)"
 // @Failure 403 {object} utils.Response "Tidak memiliki hak akses (role tidak sesuai)"
 // @Security BearerAuth
@@ -54,88 +74,113 @@ func (s *rekomendasiService) GetRecommendation(c *fiber.Ctx) error {
 	log.Info("Menerima permintaan rekomendasi jadwal")
 
 	var req dto.RecommendationRequest
-	if err := c.BodyParser(&req); err != nil {
-		log.WithError(err).Error("Gagal mem-parsing request body")
-		return utils.ResponseError(c, fiber.StatusBadRequest, "Cannot parse request body", err.Error())
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			log.WithError(err).Error("Gagal mem-parsing request body")
+			return utils.ResponseError(c, fiber.StatusBadRequest, "Cannot parse request body", err.Error())
+		}
 	}
 
-	stateString := fmt.Sprintf("%s_%s", req.Kesibukan, req.KategoriHafalan)
-	log = log.WithField("state", stateString)
-
-	var bestAction string
-	var qValue *float64
-	var recType string
-	var persentaseEfektif *float64
-
-	if stateActions, ok := config.QTableModel[stateString]; ok {
-		var maxQ float64 = -1.0
-		isFirst := true
-		for action, val := range stateActions {
-			if isFirst || val > maxQ {
-				maxQ = val
-				bestAction = action
-				isFirst = false
-			}
+	stateString, sumberState, err := s.resolveStateRekomendasi(claims, req)
+	if err != nil {
+		if errors.Is(err, errStateRekomendasiTidakValid) {
+			log.WithError(err).Warn("State rekomendasi tidak valid")
+			return utils.ResponseError(c, fiber.StatusBadRequest, err.Error(), opsiStateRekomendasi())
 		}
-		qValue = &maxQ
-		recType = "Spesifik"
-	} else {
-		if len(config.HistoricalBest) > 0 {
-			bestAction = config.HistoricalBest[0].Jadwal
-			recType = "Umum (Historis Terbaik)"
+		log.WithError(err).Error("Gagal menentukan state rekomendasi")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal menentukan kondisi pengguna", err.Error())
+	}
+	log = log.WithFields(logrus.Fields{"state": stateString, "sumber_state": sumberState})
+
+	top := normalizeTopRekomendasi(req.Top)
+
+	engine := s.Engines[s.DefaultEngine]
+	eksperimen, varian, err := varianUntukPengguna(s.DB, claims.Role, claims.ID)
+	if err != nil {
+		// Eksperimen tidak boleh menghalangi rekomendasi, pengguna dilayani engine default
+		log.WithError(err).Warn("Gagal menentukan varian eksperimen rekomendasi")
+	} else if varian != nil {
+		if e, ok := s.Engines[varian.Engine]; ok {
+			engine = e
+			log = log.WithFields(logrus.Fields{"eksperimenID": eksperimen.ID, "varian": varian.Nama})
 		} else {
-			bestAction = "Tidak ada jadwal default"
-			recType = "Tidak Ada Rekomendasi"
+			eksperimen, varian = nil, nil
 		}
 	}
 
-	if bestAction != "Tidak ada jadwal default" && len(config.HistoricalBest) > 0 {
-		for _, info := range config.HistoricalBest {
-			if info.Jadwal == bestAction {
-				persen := info.PersentaseEfektif
-				persentaseEfektif = &persen
-				break
-			}
-		}
+	hasil, err := engine.Recommend(stateString, top)
+	if err != nil {
+		log.WithError(err).WithField("engine", engine.Name()).Error("Engine gagal menghasilkan rekomendasi")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal menghasilkan rekomendasi", err.Error())
+	}
+
+	var bestAction string
+	var qValue *float64
+	var persentaseEfektif *float64
+	recType, tipeDasar := hasil.TipeRekomendasi, hasil.TipeDasar
+	if len(hasil.Peringkat) > 0 {
+		bestAction = hasil.Peringkat[0].Jadwal
+		qValue = hasil.Peringkat[0].EstimasiQValue
+		persentaseEfektif = hasil.Peringkat[0].PersentaseEfektifHistoris
+	} else {
+		bestAction = "Tidak ada jadwal default"
+		tipeDasar, recType = TipeRekomendasiTidakAda, TipeRekomendasiTidakAda
 	}
 
 	log = log.WithFields(logrus.Fields{
 		"rekomendasi": bestAction,
 		"tipe":        recType,
+		"engine":      engine.Name(),
+		"top":         len(hasil.Peringkat),
 	})
 
 	response := dto.RecommendationResponse{
 		State:                     stateString,
+		SumberState:               sumberState,
 		RekomendasiJadwal:         bestAction,
 		TipeRekomendasi:           recType,
 		EstimasiQValue:            qValue,
 		PersentaseEfektifHistoris: persentaseEfektif,
+		Engine:                    engine.Name(),
+		EngineVersi:               hasil.EngineVersi,
+		Peringkat:                 hasil.Peringkat,
+		StateTetangga:             hasil.StateTetangga,
+	}
+	if varian != nil {
+		response.EksperimenID = &eksperimen.ID
+		response.VarianEksperimen = varian.Nama
 	}
 
-	if bestAction != "Tidak ada jadwal default" {
-		rekomendasiRecord := models.JadwalRekomendasi{
-			State:             stateString,
-			RekomendasiJadwal: response.RekomendasiJadwal,
-			TipeRekomendasi:   response.TipeRekomendasi,
-			EstimasiQValue:    response.EstimasiQValue,
-		}
+	// Permintaan tanpa jadwal tetap dicatat sebagai paparan agar hasil eksperimen dan analitik tidak bias
+	rekomendasiRecord := models.JadwalRekomendasi{
+		State:             stateString,
+		RekomendasiJadwal: response.RekomendasiJadwal,
+		TipeRekomendasi:   response.TipeRekomendasi,
+		EstimasiQValue:    response.EstimasiQValue,
+		Engine:            response.Engine,
+		EngineVersi:       response.EngineVersi,
+	}
+	if varian != nil {
+		rekomendasiRecord.EksperimenID = &eksperimen.ID
+		rekomendasiRecord.VarianID = &varian.ID
+	}
 
-		if claims.Role == "mahasantri" {
-			rekomendasiRecord.MahasantriID = &claims.ID
-		} else if claims.Role == "mentor" {
-			rekomendasiRecord.MentorID = &claims.ID
-		}
+	if claims.Role == "mahasantri" {
+		rekomendasiRecord.MahasantriID = &claims.ID
+	} else if claims.Role == "mentor" {
+		rekomendasiRecord.MentorID = &claims.ID
+	}
 
-		if err := s.DB.Create(&rekomendasiRecord).Error; err != nil {
-			log.WithError(err).Error("Gagal menyimpan riwayat rekomendasi ke database")
-		} else {
-			log.WithField("recordID", rekomendasiRecord.ID).Info("Riwayat rekomendasi berhasil disimpan")
-			response.ID = rekomendasiRecord.ID
-			response.MahasantriID = rekomendasiRecord.MahasantriID
-			response.MentorID = rekomendasiRecord.MentorID
-		}
+	if err := s.DB.Create(&rekomendasiRecord).Error; err != nil {
+		log.WithError(err).Error("Gagal menyimpan riwayat rekomendasi ke database")
+	} else {
+		log.WithField("recordID", rekomendasiRecord.ID).Info("Riwayat rekomendasi berhasil disimpan")
+		response.ID = rekomendasiRecord.ID
+		response.MahasantriID = rekomendasiRecord.MahasantriID
+		response.MentorID = rekomendasiRecord.MentorID
 	}
 
+	utils.RekomendasiServedTotal.WithLabelValues(tipeDasar).Inc()
 	log.Info("Rekomendasi berhasil dikirim ke pengguna")
 	return utils.SuccessResponse(c, fiber.StatusOK, "Rekomendasi berhasil dibuat", response)
 }
@@ -217,10 +262,11 @@ func (s *rekomendasiService) GetAllRekomendasi(c *fiber.Ctx) error {
 	}
 
 	// Mapping ke DTO Respons
+	historicalBest := config.Models.HistoricalBest()
 	responseDTOs := make([]dto.RecommendationResponse, len(riwayatRekomendasi))
 	for i, rec := range riwayatRekomendasi {
 		var persentaseEfektif *float64
-		for _, info := range config.HistoricalBest {
+		for _, info := range historicalBest {
 			if info.Jadwal == rec.RekomendasiJadwal {
 				persen := info.PersentaseEfektif
 				persentaseEfektif = &persen
@@ -236,6 +282,8 @@ func (s *rekomendasiService) GetAllRekomendasi(c *fiber.Ctx) error {
 			TipeRekomendasi:           rec.TipeRekomendasi,
 			EstimasiQValue:            rec.EstimasiQValue,
 			PersentaseEfektifHistoris: persentaseEfektif,
+			Engine:                    rec.Engine,
+			EngineVersi:               rec.EngineVersi,
 		}
 	}
 
@@ -259,13 +307,129 @@ func (s *rekomendasiService) GetAllKesibukan(c *fiber.Ctx) error {
 	log := logrus.WithField("handler", "GetAllKesibukan")
 	log.Info("Menerima permintaan untuk mengambil semua opsi kesibukan")
 
-	kesibukanSet := make(map[string]bool)
+	uniqueKesibukan := opsiStateRekomendasi().Kesibukan
+
+	log.WithField("count", len(uniqueKesibukan)).Info("Berhasil mengambil daftar kesibukan unik")
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Daftar kesibukan berhasil diambil", uniqueKesibukan)
+}
+
+// GetStatesRekomendasi - Daftar kondisi yang dikenal model rekomendasi
+// @Summary Daftar kesibukan, kategori hafalan, dan state yang valid
+// @Description Mengembalikan seluruh kesibukan, kategori hafalan, dan kombinasi state yang dikenal model rekomendasi aktif.
+// @Tags Rekomendasi
+// @Produce json
+// @Success 200 {object} utils.Response{data=dto.OpsiStateRekomendasiResponse} "Daftar state berhasil diambil"
+// @Security BearerAuth
+// @Router /api/v1/rekomendasi/states [get]
+func (s *rekomendasiService) GetStatesRekomendasi(c *fiber.Ctx) error {
+	opsi := opsiStateRekomendasi()
+	logrus.WithFields(logrus.Fields{
+		"handler":      "GetStatesRekomendasi",
+		"jumlah_state": len(opsi.States),
+	}).Info("Berhasil mengambil daftar state rekomendasi")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Daftar state rekomendasi berhasil diambil", opsi)
+}
+
+var errStateRekomendasiTidakValid = errors.New("kondisi rekomendasi tidak valid")
+
+// errRekomendasiTanpaJadwal dikembalikan saat riwayat rekomendasi yang tidak berisi jadwal hendak diterapkan atau dinilai
+var errRekomendasiTanpaJadwal = errors.New("rekomendasi tidak berisi jadwal sehingga tidak dapat diterapkan atau dinilai")
+
+// resolveStateRekomendasi menentukan state rekomendasi. State mahasantri selalu diturunkan dari jadwal personal yang
+// tersimpan, sehingga kesibukan dan kategori hafalan di request ditolak. Mentor boleh mengirim keduanya untuk melihat
+// rekomendasi kondisi lain; jika kosong, state diambil dari jadwal personal mentor. State harus dikenal model aktif
+// agar salah ketik tidak diam-diam jatuh ke rekomendasi historis.
+func (s *rekomendasiService) resolveStateRekomendasi(claims *utils.Claims, req dto.RecommendationRequest) (string, string, error) {
+	kesibukan := strings.ToLower(strings.TrimSpace(req.Kesibukan))
+	kategori := strings.TrimSpace(req.KategoriHafalan)
+
+	if claims.Role == RoleMahasantri && (kesibukan != "" || kategori != "") {
+		return "", "", fmt.Errorf("%w: kesibukan dan kategori_hafalan mahasantri diambil dari jadwal personal, perbarui jadwal personal untuk mengubahnya", errStateRekomendasiTidakValid)
+	}
+
+	if kesibukan == "" && kategori == "" {
+		ownerColumn := "mahasantri_id"
+		if claims.Role == RoleMentor {
+			ownerColumn = "mentor_id"
+		}
+
+		var jp models.JadwalPersonal
+		if err := s.DB.Where(ownerColumn+" = ?", claims.ID).First(&jp).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return "", "", fmt.Errorf("%w: jadwal personal belum diisi, lengkapi jadwal personal atau kirim kesibukan dan kategori_hafalan", errStateRekomendasiTidakValid)
+			}
+			return "", "", err
+		}
+
+		kesibukan, kategori = pecahState(StateJadwalPersonal(jp))
+		if err := validasiKondisiRekomendasi(kesibukan, kategori); err != nil {
+			return "", "", fmt.Errorf("%w, perbarui jadwal personal", err)
+		}
+		return kanonisState(fmt.Sprintf("%s_%s", normalizeKesibukan(kesibukan), kategori)), "jadwal_personal", nil
+	}
+
+	if kesibukan == "" || kategori == "" {
+		return "", "", fmt.Errorf("%w: kesibukan dan kategori_hafalan harus diisi bersamaan", errStateRekomendasiTidakValid)
+	}
+
+	for _, k := range daftarKategoriHafalan {
+		if strings.EqualFold(k, kategori) {
+			kategori = k
+			break
+		}
+	}
+	if err := validasiKondisiRekomendasi(kesibukan, kategori); err != nil {
+		return "", "", err
+	}
+	return kanonisState(fmt.Sprintf("%s_%s", normalizeKesibukan(kesibukan), kategori)), "request", nil
+}
 
-	for stateString := range config.QTableModel {
+// validasiKondisiRekomendasi memastikan kategori hafalan dikenal dan setiap komponen kesibukan pernah muncul di model.
+// Kombinasi komponen yang belum ada di Q-Table tetap diterima dan dilayani lewat fallback kemiripan.
+func validasiKondisiRekomendasi(kesibukan, kategori string) error {
+	if indeksKategoriHafalan(kategori) < 0 {
+		return fmt.Errorf("%w: kategori_hafalan %q tidak dikenal", errStateRekomendasiTidakValid, kategori)
+	}
+	if stateRekomendasiDikenal(fmt.Sprintf("%s_%s", normalizeKesibukan(kesibukan), kategori)) {
+		return nil
+	}
+
+	dikenal := make(map[string]bool)
+	for _, k := range opsiStateRekomendasi().Kesibukan {
+		for _, komponen := range komponenKesibukan(k) {
+			dikenal[komponen] = true
+		}
+	}
+	komponen := komponenKesibukan(kesibukan)
+	if len(komponen) == 0 {
+		return fmt.Errorf("%w: kesibukan tidak boleh kosong", errStateRekomendasiTidakValid)
+	}
+	for _, k := range komponen {
+		if !dikenal[k] {
+			return fmt.Errorf("%w: kesibukan %q tidak dikenal model", errStateRekomendasiTidakValid, k)
+		}
+	}
+	return nil
+}
+
+func stateRekomendasiDikenal(state string) bool {
+	_, _, ok := config.Models.GetQActions(state)
+	return ok
+}
+
+// opsiStateRekomendasi menyusun daftar kesibukan, kategori hafalan, dan state dari model aktif
+func opsiStateRekomendasi() dto.OpsiStateRekomendasiResponse {
+	states := config.Models.States()
+	sort.Strings(states)
+
+	kesibukanSet := make(map[string]bool)
+	kategoriSet := make(map[string]bool)
+	for _, stateString := range states {
 		lastIndex := strings.LastIndex(stateString, "_")
 		if lastIndex != -1 {
-			kesibukan := stateString[:lastIndex]
-			kesibukanSet[kesibukan] = true
+			kesibukanSet[stateString[:lastIndex]] = true
+			kategoriSet[stateString[lastIndex+1:]] = true
 		}
 	}
 
@@ -273,10 +437,19 @@ func (s *rekomendasiService) GetAllKesibukan(c *fiber.Ctx) error {
 	for k := range kesibukanSet {
 		uniqueKesibukan = append(uniqueKesibukan, k)
 	}
-
 	sort.Strings(uniqueKesibukan)
 
-	log.WithField("count", len(uniqueKesibukan)).Info("Berhasil mengambil daftar kesibukan unik")
+	// Kategori mengikuti urutan juz, bukan urutan alfabet
+	kategori := make([]string, 0, len(kategoriSet))
+	for _, k := range daftarKategoriHafalan {
+		if kategoriSet[k] {
+			kategori = append(kategori, k)
+		}
+	}
 
-	return utils.SuccessResponse(c, fiber.StatusOK, "Daftar kesibukan berhasil diambil", uniqueKesibukan)
+	return dto.OpsiStateRekomendasiResponse{
+		Kesibukan:       uniqueKesibukan,
+		KategoriHafalan: kategori,
+		States:          states,
+	}
 }
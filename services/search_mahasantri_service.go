@@ -0,0 +1,289 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/mahad-service-go/dto"
+	"github.com/habbazettt/mahad-service-go/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// kolom yang boleh dipakai untuk sorting pencarian mahasantri
+var sortSearchMahasantri = map[string]string{
+	"relevansi":            "relevansi",
+	"nama":                 "nama",
+	"nim":                  "nim",
+	"angkatan":             "angkatan",
+	"total_juz":            "total_juz",
+	"persentase_kehadiran": "persentase_kehadiran",
+	"aktivitas_terakhir":   "aktivitas_terakhir",
+}
+
+var (
+	pgTrgmMu        sync.Mutex
+	pgTrgmChecked   bool
+	pgTrgmAvailable bool
+)
+
+// hasPgTrgm memeriksa apakah ekstensi pg_trgm terpasang, jika tidak pencarian memakai ILIKE dan full-text saja.
+// Hanya hasil pemeriksaan yang berhasil yang disimpan, sehingga kegagalan sementara akan diperiksa ulang pada request berikutnya.
+func hasPgTrgm(db *gorm.DB) bool {
+	pgTrgmMu.Lock()
+	defer pgTrgmMu.Unlock()
+	if pgTrgmChecked {
+		return pgTrgmAvailable
+	}
+
+	var available bool
+	if err := db.Raw("SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm')").Scan(&available).Error; err != nil {
+		logrus.WithError(err).Warn("Gagal memeriksa ekstensi pg_trgm, pencarian tanpa trigram")
+		return false
+	}
+	pgTrgmChecked = true
+	pgTrgmAvailable = available
+	return pgTrgmAvailable
+}
+
+type searchMahasantriRow struct {
+	ID                  uint
+	Nama                string
+	NIM                 string
+	Jurusan             string
+	Gender              string
+	MentorID            uint
+	Angkatan            int
+	Status              string
+	TotalJuz            int
+	TotalAbsensi        int
+	PersentaseKehadiran *float64
+	AktivitasTerakhir   *time.Time
+	Relevansi           *float64
+}
+
+// queryNumber membaca query parameter angka opsional, nil jika tidak dikirim
+func queryNumber(c *fiber.Ctx, key string) (*float64, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || v < 0 {
+		return nil, fmt.Errorf("%s harus berupa angka positif", key)
+	}
+	return &v, nil
+}
+
+// SearchMahasantri - Pencarian dan filter mahasantri
+// @Summary Pencarian mahasantri
+// @Description Mencari mahasantri berdasarkan nama/NIM (full-text dan trigram) dengan filter jurusan, gender, mentor, angkatan, status, jumlah juz hafalan, persentase kehadiran, dan keaktifan terakhir.
+// @Description Total juz dihitung dari jumlah juz berbeda yang pernah disetorkan. Persentase kehadiran dihitung sejak kehadiran_sejak (default 30 hari terakhir). Aktivitas terakhir adalah tanggal terbaru dari setoran hafalan, log murojaah, atau kehadiran.
+// @Tags Mahasantri
+// @Produce json
+// @Param q query string false "Kata kunci nama atau NIM"
+// @Param jurusan query string false "Jurusan"
+// @Param gender query string false "Gender (L/P)"
+// @Param mentor_id query int false "ID mentor"
+// @Param angkatan query int false "Angkatan"
+// @Param status query string false "Filter status (aktif, cuti, lulus, keluar, dipisah koma) atau semua" default(aktif)
+// @Param min_juz query int false "Minimal total juz"
+// @Param max_juz query int false "Maksimal total juz"
+// @Param kehadiran_sejak query string false "Awal periode kehadiran (dd-mm-yyyy)"
+// @Param min_kehadiran query number false "Minimal persentase kehadiran (0-100)"
+// @Param max_kehadiran query number false "Maksimal persentase kehadiran (0-100)"
+// @Param aktif_dalam_hari query int false "Hanya yang memiliki aktivitas dalam N hari terakhir"
+// @Param tidak_aktif_hari query int false "Hanya yang tidak memiliki aktivitas selama N hari terakhir"
+// @Param sort query string false "relevansi, nama, nim, angkatan, total_juz, persentase_kehadiran, aktivitas_terakhir" default(nama)
+// @Param order query string false "asc atau desc" default(asc)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Limit per page" default(10)
+// @Success 200 {object} utils.Response{data=[]dto.MahasantriSearchResult} "Hasil pencarian mahasantri"
+// @Failure 400 {object} utils.Response "Parameter tidak valid"
+// @Failure 500 {object} utils.Response "Gagal mencari mahasantri"
+// @Security BearerAuth
+// @Router /api/v1/mahasantri/search [get]
+func (s *MahasantriService) SearchMahasantri(c *fiber.Ctx) error {
+	page, limit, offset := paginationParams(c)
+	keyword := strings.TrimSpace(c.Query("q"))
+
+	statuses, err := parseFilterStatusMahasantri(c)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, err.Error(), nil)
+	}
+
+	kehadiranSejak := time.Now().AddDate(0, 0, -30)
+	kehadiranSejak = time.Date(kehadiranSejak.Year(), kehadiranSejak.Month(), kehadiranSejak.Day(), 0, 0, 0, 0, time.UTC)
+	if raw := c.Query("kehadiran_sejak"); raw != "" {
+		if kehadiranSejak, err = time.Parse("02-01-2006", raw); err != nil {
+			return utils.ResponseError(c, fiber.StatusBadRequest, "Format kehadiran_sejak tidak valid, gunakan DD-MM-YYYY", nil)
+		}
+	}
+
+	numbers := make(map[string]*float64)
+	for _, key := range []string{"mentor_id", "angkatan", "min_juz", "max_juz", "min_kehadiran", "max_kehadiran", "aktif_dalam_hari", "tidak_aktif_hari"} {
+		v, err := queryNumber(c, key)
+		if err != nil {
+			return utils.ResponseError(c, fiber.StatusBadRequest, err.Error(), nil)
+		}
+		numbers[key] = v
+	}
+
+	sortKey := c.Query("sort")
+	if sortKey == "" {
+		sortKey = "nama"
+		if keyword != "" {
+			sortKey = "relevansi"
+		}
+	}
+	sortColumn, ok := sortSearchMahasantri[sortKey]
+	if !ok {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Parameter sort tidak valid", nil)
+	}
+	order := strings.ToUpper(c.Query("order"))
+	if order == "" {
+		order = "ASC"
+		if sortKey == "relevansi" {
+			order = "DESC"
+		}
+	}
+	if order != "ASC" && order != "DESC" {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Parameter order harus asc atau desc", nil)
+	}
+
+	relevansi := "NULL::float8"
+	var relevansiArgs []interface{}
+	if keyword != "" {
+		relevansi = "ts_rank(to_tsvector('simple', m.nama), plainto_tsquery('simple', ?))"
+		relevansiArgs = append(relevansiArgs, keyword)
+		if hasPgTrgm(s.DB) {
+			relevansi += " + GREATEST(similarity(m.nama, ?), similarity(m.nim, ?))"
+			relevansiArgs = append(relevansiArgs, keyword, keyword)
+		}
+	}
+
+	inner := s.DB.Table("mahasantris AS m").
+		Select(`
+			m.id, m.nama, m.nim, m.jurusan, m.gender, m.mentor_id, m.angkatan, m.status,
+			COALESCE(h.total_juz, 0) AS total_juz,
+			COALESCE(a.total_absensi, 0) AS total_absensi,
+			CASE WHEN a.total_absensi > 0 THEN ROUND(a.total_hadir * 100.0 / a.total_absensi, 2)::float8 END AS persentase_kehadiran,
+			GREATEST(h.terakhir, l.terakhir, a.terakhir) AS aktivitas_terakhir,
+			`+relevansi+` AS relevansi`, relevansiArgs...).
+		Joins("LEFT JOIN (SELECT mahasantri_id, COUNT(DISTINCT juz) AS total_juz, MAX(created_at)::date AS terakhir FROM hafalans GROUP BY mahasantri_id) h ON h.mahasantri_id = m.id").
+		Joins("LEFT JOIN (SELECT mahasantri_id, MAX(tanggal) AS terakhir FROM log_harians WHERE total_selesai_halaman > 0 GROUP BY mahasantri_id) l ON l.mahasantri_id = m.id").
+		Joins(`LEFT JOIN (
+			SELECT mahasantri_id,
+				COUNT(*) FILTER (WHERE tanggal >= ?) AS total_absensi,
+				COUNT(*) FILTER (WHERE tanggal >= ? AND LOWER(status) = 'hadir') AS total_hadir,
+				MAX(tanggal) FILTER (WHERE LOWER(status) = 'hadir') AS terakhir
+			FROM absensis GROUP BY mahasantri_id
+		) a ON a.mahasantri_id = m.id`, kehadiranSejak, kehadiranSejak).
+		Where("m.deleted_at IS NULL")
+
+	if keyword != "" {
+		like := "%" + keyword + "%"
+		if hasPgTrgm(s.DB) {
+			inner = inner.Where("(m.nama ILIKE ? OR m.nim ILIKE ? OR to_tsvector('simple', m.nama) @@ plainto_tsquery('simple', ?) OR m.nama % ? OR m.nim % ?)",
+				like, like, keyword, keyword, keyword)
+		} else {
+			inner = inner.Where("(m.nama ILIKE ? OR m.nim ILIKE ? OR to_tsvector('simple', m.nama) @@ plainto_tsquery('simple', ?))",
+				like, like, keyword)
+		}
+	}
+	if jurusan := c.Query("jurusan"); jurusan != "" {
+		inner = inner.Where("LOWER(m.jurusan) = LOWER(?)", jurusan)
+	}
+	if gender := strings.ToUpper(c.Query("gender")); gender != "" {
+		inner = inner.Where("m.gender = ?", gender)
+	}
+	if v := numbers["mentor_id"]; v != nil {
+		inner = inner.Where("m.mentor_id = ?", int(*v))
+	}
+	if v := numbers["angkatan"]; v != nil {
+		inner = inner.Where("m.angkatan = ?", int(*v))
+	}
+	if statuses != nil {
+		inner = inner.Where("m.status IN ?", statuses)
+	}
+
+	// Filter atas kolom agregat dilakukan di luar subquery
+	query := s.DB.Table("(?) AS hasil", inner)
+	if v := numbers["min_juz"]; v != nil {
+		query = query.Where("total_juz >= ?", *v)
+	}
+	if v := numbers["max_juz"]; v != nil {
+		query = query.Where("total_juz <= ?", *v)
+	}
+	if v := numbers["min_kehadiran"]; v != nil {
+		query = query.Where("persentase_kehadiran >= ?", *v)
+	}
+	if v := numbers["max_kehadiran"]; v != nil {
+		query = query.Where("persentase_kehadiran <= ?", *v)
+	}
+	today := time.Now()
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	if v := numbers["aktif_dalam_hari"]; v != nil {
+		query = query.Where("aktivitas_terakhir >= ?", today.AddDate(0, 0, -int(*v)))
+	}
+	if v := numbers["tidak_aktif_hari"]; v != nil {
+		query = query.Where("(aktivitas_terakhir IS NULL OR aktivitas_terakhir < ?)", today.AddDate(0, 0, -int(*v)))
+	}
+
+	log := logrus.WithFields(logrus.Fields{
+		"handler": "SearchMahasantri",
+		"q":       keyword,
+		"sort":    sortKey,
+	})
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		log.WithError(err).Error("Gagal menghitung hasil pencarian mahasantri")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mencari mahasantri", err.Error())
+	}
+
+	var rows []searchMahasantriRow
+	if err := query.
+		Order(fmt.Sprintf("%s %s NULLS LAST, id ASC", sortColumn, order)).
+		Limit(limit).Offset(offset).
+		Scan(&rows).Error; err != nil {
+		log.WithError(err).Error("Gagal mencari mahasantri")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mencari mahasantri", err.Error())
+	}
+
+	response := make([]dto.MahasantriSearchResult, len(rows))
+	for i, r := range rows {
+		response[i] = dto.MahasantriSearchResult{
+			ID:                  r.ID,
+			Nama:                r.Nama,
+			NIM:                 r.NIM,
+			Jurusan:             r.Jurusan,
+			Gender:              r.Gender,
+			MentorID:            r.MentorID,
+			Angkatan:            r.Angkatan,
+			Status:              r.Status,
+			TotalJuz:            r.TotalJuz,
+			TotalAbsensi:        r.TotalAbsensi,
+			PersentaseKehadiran: r.PersentaseKehadiran,
+			Relevansi:           r.Relevansi,
+		}
+		if r.AktivitasTerakhir != nil {
+			response[i].AktivitasTerakhir = r.AktivitasTerakhir.Format("02-01-2006")
+		}
+	}
+
+	log.WithField("total", total).Info("Pencarian mahasantri berhasil")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Mahasantri retrieved successfully", fiber.Map{
+		"pagination": fiber.Map{
+			"current_page": page,
+			"total_data":   total,
+			"total_pages":  int(math.Ceil(float64(total) / float64(limit))),
+		},
+		"mahasantri": response,
+	})
+}
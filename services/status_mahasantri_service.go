@@ -0,0 +1,347 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/mahad-service-go/dto"
+	"github.com/habbazettt/mahad-service-go/models"
+	"github.com/habbazettt/mahad-service-go/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errMahasantriAlumni     = errors.New("data alumni bersifat read-only dan tidak dapat diubah")
+	errStatusSama           = errors.New("status mahasantri tidak berubah")
+	errTanggalStatusInvalid = errors.New("tanggal efektif tidak boleh setelah hari ini atau sebelum perubahan status terakhir")
+)
+
+var statusMahasantriValid = map[string]bool{
+	models.StatusMahasantriAktif:  true,
+	models.StatusMahasantriCuti:   true,
+	models.StatusMahasantriLulus:  true,
+	models.StatusMahasantriKeluar: true,
+}
+
+// ensureMahasantriWritable menolak perubahan data milik mahasantri yang sudah lulus
+func ensureMahasantriWritable(db *gorm.DB, mahasantriID uint) error {
+	var status string
+	if err := db.Model(&models.Mahasantri{}).Where("id = ?", mahasantriID).Pluck("status", &status).Error; err != nil {
+		return err
+	}
+	if status == models.StatusMahasantriLulus {
+		return errMahasantriAlumni
+	}
+	return nil
+}
+
+// responseMahasantriFrozen mengubah error dari ensureMahasantriWritable menjadi response
+func responseMahasantriFrozen(c *fiber.Ctx, err error) error {
+	if errors.Is(err, errMahasantriAlumni) {
+		return utils.ResponseError(c, fiber.StatusForbidden, err.Error(), nil)
+	}
+	logrus.WithError(err).Error("Gagal memeriksa status mahasantri")
+	return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal memeriksa status mahasantri", err.Error())
+}
+
+// parseFilterStatusMahasantri membaca query status untuk dashboard. Default hanya mahasantri aktif,
+// "semua" menonaktifkan filter, dan beberapa status dapat dipisah koma (contoh: aktif,cuti).
+func parseFilterStatusMahasantri(c *fiber.Ctx) ([]string, error) {
+	raw := strings.ToLower(strings.TrimSpace(c.Query("status", models.StatusMahasantriAktif)))
+	if raw == "semua" {
+		return nil, nil
+	}
+
+	var statuses []string
+	for _, s := range strings.Split(raw, ",") {
+		s = strings.TrimSpace(s)
+		if !statusMahasantriValid[s] {
+			return nil, fmt.Errorf("status %q tidak valid, gunakan aktif, cuti, lulus, keluar, atau semua", s)
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// ubahStatusMahasantri menyimpan status baru beserta riwayatnya. Mahasantri yang lulus atau keluar
+// otomatis dikeluarkan dari keanggotaan halaqah aktif per tanggal efektif.
+func ubahStatusMahasantri(tx *gorm.DB, mahasantriID uint, statusBaru string, tanggalEfektif time.Time, keterangan string, oleh *uint) (models.Mahasantri, error) {
+	var mahasantri models.Mahasantri
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&mahasantri, mahasantriID).Error; err != nil {
+		return mahasantri, err
+	}
+	if mahasantri.Status == statusBaru {
+		return mahasantri, errStatusSama
+	}
+
+	today := time.Now()
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	if tanggalEfektif.After(today) || (mahasantri.StatusSejak != nil && tanggalEfektif.Before(*mahasantri.StatusSejak)) {
+		return mahasantri, errTanggalStatusInvalid
+	}
+
+	statusLama := mahasantri.Status
+	if err := tx.Model(&mahasantri).Updates(map[string]interface{}{
+		"status":       statusBaru,
+		"status_sejak": tanggalEfektif,
+	}).Error; err != nil {
+		return mahasantri, err
+	}
+
+	if err := tx.Create(&models.RiwayatStatusMahasantri{
+		MahasantriID:   mahasantri.ID,
+		StatusLama:     statusLama,
+		StatusBaru:     statusBaru,
+		TanggalEfektif: tanggalEfektif,
+		Keterangan:     keterangan,
+		DiubahOleh:     oleh,
+	}).Error; err != nil {
+		return mahasantri, err
+	}
+
+	if statusBaru == models.StatusMahasantriLulus || statusBaru == models.StatusMahasantriKeluar {
+		if err := tx.Model(&models.HalaqahAnggota{}).
+			Where("mahasantri_id = ? AND tanggal_keluar IS NULL", mahasantri.ID).
+			Update("tanggal_keluar", tanggalEfektif).Error; err != nil {
+			return mahasantri, err
+		}
+	}
+
+	mahasantri.Status = statusBaru
+	mahasantri.StatusSejak = &tanggalEfektif
+	return mahasantri, nil
+}
+
+// UpdateStatusMahasantri - Mengubah status mahasantri (aktif, cuti, lulus, keluar)
+// @Summary Mengubah status mahasantri
+// @Description Mengubah status mahasantri dengan tanggal efektif (default hari ini) dan mencatatnya di riwayat status. Mahasantri berstatus lulus dibekukan (read-only) sampai statusnya diubah kembali.
+// @Tags Mahasantri
+// @Accept json
+// @Produce json
+// @Param id path int true "ID Mahasantri"
+// @Param request body dto.UpdateStatusMahasantriRequest true "Status baru"
+// @Success 200 {object} utils.Response{data=dto.MahasantriResponse} "Status mahasantri berhasil diubah"
+// @Failure 400 {object} utils.Response "Request tidak valid"
+// @Failure 404 {object} utils.Response "Mahasantri not found"
+// @Failure 500 {object} utils.Response "Gagal mengubah status mahasantri"
+// @Security BearerAuth
+// @Router /api/v1/mahasantri/{id}/status [put]
+func (s *MahasantriService) UpdateStatusMahasantri(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Invalid ID format", nil)
+	}
+
+	var req dto.UpdateStatusMahasantriRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+	req.Status = strings.ToLower(strings.TrimSpace(req.Status))
+	if !statusMahasantriValid[req.Status] {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Status harus salah satu dari aktif, cuti, lulus, keluar", nil)
+	}
+
+	tanggalEfektif, err := parseTanggalOrToday(req.TanggalEfektif)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Format tanggal tidak valid, gunakan DD-MM-YYYY", nil)
+	}
+
+	log := logrus.WithFields(logrus.Fields{
+		"handler":       "UpdateStatusMahasantri",
+		"mahasantri_id": id,
+		"status":        req.Status,
+	})
+
+	var mahasantri models.Mahasantri
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		mahasantri, err = ubahStatusMahasantri(tx, uint(id), req.Status, tanggalEfektif, req.Keterangan, &claims.ID)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return utils.ResponseError(c, fiber.StatusNotFound, "Mahasantri not found", nil)
+		case errors.Is(err, errStatusSama), errors.Is(err, errTanggalStatusInvalid):
+			return utils.ResponseError(c, fiber.StatusBadRequest, err.Error(), nil)
+		}
+		log.WithError(err).Error("Gagal mengubah status mahasantri")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengubah status mahasantri", err.Error())
+	}
+
+	utils.SendNotifications(s.Notifier, utils.Notification{
+		RecipientRole: RoleMahasantri,
+		RecipientID:   mahasantri.ID,
+		Title:         "Status keanggotaan diperbarui",
+		Message:       fmt.Sprintf("Status Anda berubah menjadi %s per %s.", mahasantri.Status, tanggalEfektif.Format("02-01-2006")),
+		Data: map[string]interface{}{
+			"status":          mahasantri.Status,
+			"tanggal_efektif": tanggalEfektif.Format("02-01-2006"),
+		},
+	})
+
+	log.Info("Status mahasantri berhasil diubah")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Status mahasantri berhasil diubah", dto.MahasantriResponse{
+		ID:                   mahasantri.ID,
+		Nama:                 mahasantri.Nama,
+		NIM:                  mahasantri.NIM,
+		Jurusan:              mahasantri.Jurusan,
+		Gender:               mahasantri.Gender,
+		MentorID:             mahasantri.MentorID,
+		Status:               mahasantri.Status,
+		IsDataMurojaahFilled: mahasantri.IsDataMurojaahFilled,
+	})
+}
+
+// GetRiwayatStatusMahasantri - Mengambil riwayat perubahan status mahasantri
+// @Summary Riwayat status mahasantri
+// @Description Mengambil seluruh perubahan status mahasantri, diurutkan dari yang terbaru.
+// @Tags Mahasantri
+// @Produce json
+// @Param id path int true "ID Mahasantri"
+// @Success 200 {object} utils.Response{data=[]dto.RiwayatStatusMahasantriResponse} "Riwayat status berhasil diambil"
+// @Failure 400 {object} utils.Response "Invalid ID format"
+// @Failure 403 {object} utils.Response "Forbidden"
+// @Failure 500 {object} utils.Response "Gagal mengambil riwayat status"
+// @Security BearerAuth
+// @Router /api/v1/mahasantri/{id}/riwayat-status [get]
+func (s *MahasantriService) GetRiwayatStatusMahasantri(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Invalid ID format", nil)
+	}
+	if claims.Role == RoleMahasantri && uint(id) != claims.ID {
+		return utils.ResponseError(c, fiber.StatusForbidden, "You are not authorized to access this resource", nil)
+	}
+
+	var riwayat []models.RiwayatStatusMahasantri
+	if err := s.DB.Where("mahasantri_id = ?", id).
+		Order("tanggal_efektif DESC, id DESC").
+		Find(&riwayat).Error; err != nil {
+		logrus.WithError(err).WithField("mahasantri_id", id).Error("Gagal mengambil riwayat status")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil riwayat status", err.Error())
+	}
+
+	response := make([]dto.RiwayatStatusMahasantriResponse, len(riwayat))
+	for i, r := range riwayat {
+		response[i] = dto.RiwayatStatusMahasantriResponse{
+			ID:             r.ID,
+			StatusLama:     r.StatusLama,
+			StatusBaru:     r.StatusBaru,
+			TanggalEfektif: r.TanggalEfektif.Format("02-01-2006"),
+			Keterangan:     r.Keterangan,
+			DiubahOleh:     r.DiubahOleh,
+			CreatedAt:      r.CreatedAt,
+		}
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Riwayat status berhasil diambil", response)
+}
+
+// LuluskanAngkatan - Meluluskan seluruh mahasantri aktif dalam satu angkatan
+// @Summary Kelulusan satu angkatan
+// @Description Mengubah status seluruh mahasantri aktif pada angkatan tertentu menjadi lulus. Secara default hanya menampilkan pratinjau (dry run); kirim dry_run=false untuk menyimpan.
+// @Description Seluruh perubahan disimpan dalam satu transaksi dan setiap mahasantri mendapat entri riwayat status serta notifikasi.
+// @Tags Mahasantri
+// @Accept json
+// @Produce json
+// @Param request body dto.KelulusanAngkatanRequest true "Angkatan dan tanggal kelulusan"
+// @Param dry_run query bool false "Hanya pratinjau tanpa menyimpan" default(true)
+// @Success 200 {object} utils.Response{data=dto.KelulusanAngkatanResponse} "Pratinjau atau hasil kelulusan"
+// @Failure 400 {object} utils.Response "Request tidak valid"
+// @Failure 404 {object} utils.Response "Tidak ada mahasantri aktif pada angkatan tersebut"
+// @Failure 500 {object} utils.Response "Gagal memproses kelulusan"
+// @Security BearerAuth
+// @Router /api/v1/mahasantri/kelulusan [post]
+func (s *MahasantriService) LuluskanAngkatan(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	dryRun := c.QueryBool("dry_run", true)
+
+	var req dto.KelulusanAngkatanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+	if req.Angkatan == 0 {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "angkatan wajib diisi", nil)
+	}
+
+	tanggalEfektif, err := parseTanggalOrToday(req.TanggalEfektif)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Format tanggal tidak valid, gunakan DD-MM-YYYY", nil)
+	}
+
+	log := logrus.WithFields(logrus.Fields{
+		"handler":  "LuluskanAngkatan",
+		"mentorID": claims.ID,
+		"angkatan": req.Angkatan,
+		"dry_run":  dryRun,
+	})
+
+	var kandidat []models.Mahasantri
+	if err := s.DB.Select("id", "nama", "nim").
+		Where("angkatan = ? AND status = ?", req.Angkatan, models.StatusMahasantriAktif).
+		Order("nim ASC").
+		Find(&kandidat).Error; err != nil {
+		log.WithError(err).Error("Gagal mengambil mahasantri angkatan")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal memproses kelulusan", err.Error())
+	}
+	if len(kandidat) == 0 {
+		return utils.ResponseError(c, fiber.StatusNotFound, "Tidak ada mahasantri aktif pada angkatan tersebut", nil)
+	}
+
+	response := dto.KelulusanAngkatanResponse{
+		DryRun:         dryRun,
+		Angkatan:       req.Angkatan,
+		TanggalEfektif: tanggalEfektif.Format("02-01-2006"),
+		Total:          len(kandidat),
+		Mahasantri:     make([]dto.KelulusanMahasantriItem, len(kandidat)),
+	}
+	for i, m := range kandidat {
+		response.Mahasantri[i] = dto.KelulusanMahasantriItem{ID: m.ID, Nama: m.Nama, NIM: m.NIM}
+	}
+
+	if dryRun {
+		return utils.SuccessResponse(c, fiber.StatusOK, "Pratinjau kelulusan angkatan", response)
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		for _, m := range kandidat {
+			if _, err := ubahStatusMahasantri(tx, m.ID, models.StatusMahasantriLulus, tanggalEfektif, req.Keterangan, &claims.ID); err != nil {
+				return fmt.Errorf("mahasantri %s: %w", m.NIM, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errTanggalStatusInvalid) || errors.Is(err, errStatusSama) {
+			return utils.ResponseError(c, fiber.StatusBadRequest, err.Error(), nil)
+		}
+		log.WithError(err).Error("Gagal memproses kelulusan angkatan")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal memproses kelulusan", err.Error())
+	}
+
+	notifications := make([]utils.Notification, len(kandidat))
+	for i, m := range kandidat {
+		notifications[i] = utils.Notification{
+			RecipientRole: RoleMahasantri,
+			RecipientID:   m.ID,
+			Title:         "Selamat atas kelulusan Anda",
+			Message:       fmt.Sprintf("Anda dinyatakan lulus per %s. Data Anda kini tersimpan sebagai alumni.", response.TanggalEfektif),
+			Data: map[string]interface{}{
+				"status":          models.StatusMahasantriLulus,
+				"tanggal_efektif": response.TanggalEfektif,
+			},
+		}
+	}
+	utils.SendNotifications(s.Notifier, notifications...)
+
+	log.WithField("total", len(kandidat)).Info("Kelulusan angkatan berhasil diproses")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Kelulusan angkatan berhasil diproses", response)
+}
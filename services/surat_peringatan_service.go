@@ -0,0 +1,553 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/mahad-service-go/dto"
+	"github.com/habbazettt/mahad-service-go/models"
+	"github.com/habbazettt/mahad-service-go/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultSPThresholds adalah jumlah alpa dalam satu semester untuk SP1, SP2, dan SP3
+var defaultSPThresholds = []int{3, 6, 9}
+
+type SuratPeringatanService interface {
+	GetAllSuratPeringatan(c *fiber.Ctx) error
+	GetSuratPeringatanByID(c *fiber.Ctx) error
+	AkuiSuratPeringatan(c *fiber.Ctx) error
+	CabutSuratPeringatan(c *fiber.Ctx) error
+	EvaluasiSuratPeringatan(c *fiber.Ctx) error
+}
+
+type suratPeringatanService struct {
+	DB       *gorm.DB
+	Notifier utils.Notifier
+}
+
+func NewSuratPeringatanService(db *gorm.DB, notifier utils.Notifier) SuratPeringatanService {
+	return &suratPeringatanService{DB: db, Notifier: notifier}
+}
+
+// getSPThresholds membaca ambang batas alpa dari SP_ALPA_THRESHOLDS (contoh: "3,6,9")
+func getSPThresholds() []int {
+	raw := os.Getenv("SP_ALPA_THRESHOLDS")
+	if raw == "" {
+		return defaultSPThresholds
+	}
+
+	parts := strings.Split(raw, ",")
+	if len(parts) != 3 {
+		logrus.WithField("SP_ALPA_THRESHOLDS", raw).Warn("Format ambang batas SP tidak valid, menggunakan nilai default")
+		return defaultSPThresholds
+	}
+
+	thresholds := make([]int, len(parts))
+	for i, part := range parts {
+		val, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || val < 1 || (i > 0 && val <= thresholds[i-1]) {
+			logrus.WithField("SP_ALPA_THRESHOLDS", raw).Warn("Ambang batas SP harus berupa angka positif yang terus naik, menggunakan nilai default")
+			return defaultSPThresholds
+		}
+		thresholds[i] = val
+	}
+	return thresholds
+}
+
+// getSemesterAkademik menentukan semester (Ganjil: Agustus-Januari, Genap: Februari-Juli) beserta rentang tanggalnya
+func getSemesterAkademik(t time.Time) (semester string, tahunAjaran string, start time.Time, end time.Time) {
+	year := t.Year()
+	loc := t.Location()
+
+	switch {
+	case t.Month() >= time.August:
+		semester = "Ganjil"
+		tahunAjaran = fmt.Sprintf("%d/%d", year, year+1)
+		start = time.Date(year, time.August, 1, 0, 0, 0, 0, loc)
+		end = time.Date(year+1, time.January, 31, 0, 0, 0, 0, loc)
+	case t.Month() == time.January:
+		semester = "Ganjil"
+		tahunAjaran = fmt.Sprintf("%d/%d", year-1, year)
+		start = time.Date(year-1, time.August, 1, 0, 0, 0, 0, loc)
+		end = time.Date(year, time.January, 31, 0, 0, 0, 0, loc)
+	default:
+		semester = "Genap"
+		tahunAjaran = fmt.Sprintf("%d/%d", year-1, year)
+		start = time.Date(year, time.February, 1, 0, 0, 0, 0, loc)
+		end = time.Date(year, time.July, 31, 0, 0, 0, 0, loc)
+	}
+	return
+}
+
+// evaluasiSuratPeringatan menghitung jumlah alpa mahasantri dalam semester dari tanggal yang diberikan
+// dan menerbitkan SP yang belum pernah diterbitkan. Dipanggil di dalam transaksi absensi.
+func evaluasiSuratPeringatan(tx *gorm.DB, mahasantriID uint, tanggal time.Time) ([]models.SuratPeringatan, error) {
+	semester, tahunAjaran, start, end := getSemesterAkademik(tanggal)
+
+	var mahasantri models.Mahasantri
+	if err := tx.Select("id", "mentor_id", "status").First(&mahasantri, mahasantriID).Error; err != nil {
+		return nil, err
+	}
+	// Surat peringatan hanya berlaku untuk mahasantri yang sedang aktif
+	if mahasantri.Status != models.StatusMahasantriAktif {
+		return nil, nil
+	}
+
+	var jumlahAlpa int64
+	if err := tx.Model(&models.Absensi{}).
+		Where("mahasantri_id = ? AND LOWER(status) = ? AND tanggal BETWEEN ? AND ?", mahasantriID, "alpa", start, end).
+		Count(&jumlahAlpa).Error; err != nil {
+		return nil, err
+	}
+
+	var existing []models.SuratPeringatan
+	if err := tx.Where("mahasantri_id = ? AND semester = ? AND tahun_ajaran = ?", mahasantriID, semester, tahunAjaran).
+		Find(&existing).Error; err != nil {
+		return nil, err
+	}
+
+	var issued []models.SuratPeringatan
+	for i, threshold := range getSPThresholds() {
+		level := i + 1
+		if int(jumlahAlpa) < threshold {
+			break
+		}
+		if isSuratPeringatanIssued(existing, level, int(jumlahAlpa)) {
+			continue
+		}
+
+		sp := models.SuratPeringatan{
+			MahasantriID: mahasantriID,
+			MentorID:     mahasantri.MentorID,
+			Level:        level,
+			Semester:     semester,
+			TahunAjaran:  tahunAjaran,
+			JumlahAlpa:   int(jumlahAlpa),
+			Status:       models.StatusSPAktif,
+		}
+		// Absensi lain untuk mahasantri yang sama bisa menerbitkan SP ini bersamaan, unique index menahan duplikatnya
+		result := tx.Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "mahasantri_id"}, {Name: "level"}, {Name: "semester"}, {Name: "tahun_ajaran"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "status <> 'dicabut'"}}},
+			DoNothing:   true,
+		}).Create(&sp)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		issued = append(issued, sp)
+	}
+
+	return issued, nil
+}
+
+// isSuratPeringatanIssued mengecek apakah SP dengan level tertentu sudah pernah diterbitkan.
+// SP yang dicabut hanya diterbitkan ulang jika jumlah alpa bertambah setelah pencabutan.
+func isSuratPeringatanIssued(existing []models.SuratPeringatan, level int, jumlahAlpa int) bool {
+	for _, sp := range existing {
+		if sp.Level != level {
+			continue
+		}
+		if sp.Status != models.StatusSPDicabut || sp.JumlahAlpa >= jumlahAlpa {
+			return true
+		}
+	}
+	return false
+}
+
+// buildSuratPeringatanNotifications menyusun notifikasi SP untuk mentor dan mahasantri
+func buildSuratPeringatanNotifications(sp models.SuratPeringatan) []utils.Notification {
+	data := map[string]interface{}{
+		"surat_peringatan_id": sp.ID,
+		"kode":                sp.GetKodeLevel(),
+		"semester":            sp.Semester,
+		"tahun_ajaran":        sp.TahunAjaran,
+		"jumlah_alpa":         sp.JumlahAlpa,
+		"mahasantri_id":       sp.MahasantriID,
+	}
+
+	return []utils.Notification{
+		{
+			RecipientRole: RoleMentor,
+			RecipientID:   sp.MentorID,
+			Title:         fmt.Sprintf("%s diterbitkan", sp.GetKodeLevel()),
+			Message:       fmt.Sprintf("Mahasantri bimbingan Anda menerima %s karena %d kali alpa pada semester %s %s", sp.GetKodeLevel(), sp.JumlahAlpa, sp.Semester, sp.TahunAjaran),
+			Data:          data,
+		},
+		{
+			RecipientRole: RoleMahasantri,
+			RecipientID:   sp.MahasantriID,
+			Title:         fmt.Sprintf("Anda menerima %s", sp.GetKodeLevel()),
+			Message:       fmt.Sprintf("Anda menerima %s karena %d kali alpa pada semester %s %s", sp.GetKodeLevel(), sp.JumlahAlpa, sp.Semester, sp.TahunAjaran),
+			Data:          data,
+		},
+	}
+}
+
+func notifySuratPeringatan(notifier utils.Notifier, issued []models.SuratPeringatan) {
+	for _, sp := range issued {
+		logrus.WithFields(logrus.Fields{
+			"surat_peringatan_id": sp.ID,
+			"mahasantri_id":       sp.MahasantriID,
+			"level":               sp.Level,
+			"jumlah_alpa":         sp.JumlahAlpa,
+		}).Info("Surat peringatan diterbitkan")
+		utils.SendNotifications(notifier, buildSuratPeringatanNotifications(sp)...)
+	}
+}
+
+func toSuratPeringatanResponse(sp models.SuratPeringatan) dto.SuratPeringatanResponse {
+	return dto.SuratPeringatanResponse{
+		ID:               sp.ID,
+		Kode:             sp.GetKodeLevel(),
+		Level:            sp.Level,
+		Semester:         sp.Semester,
+		TahunAjaran:      sp.TahunAjaran,
+		JumlahAlpa:       sp.JumlahAlpa,
+		Status:           string(sp.Status),
+		DiakuiPada:       sp.DiakuiPada,
+		DicabutPada:      sp.DicabutPada,
+		AlasanPencabutan: sp.AlasanPencabutan,
+		CreatedAt:        sp.CreatedAt,
+		MentorID:         sp.MentorID,
+		Mahasantri: dto.MahasantriResponseDTO{
+			ID:      sp.Mahasantri.ID,
+			Nama:    sp.Mahasantri.Nama,
+			NIM:     sp.Mahasantri.NIM,
+			Jurusan: sp.Mahasantri.Jurusan,
+			Gender:  sp.Mahasantri.Gender,
+		},
+	}
+}
+
+// findSuratPeringatanForMentor mengambil SP berdasarkan ID dan memastikan mahasantrinya adalah bimbingan mentor
+func (s *suratPeringatanService) findSuratPeringatanForMentor(id int, mentorID uint) (models.SuratPeringatan, error) {
+	var sp models.SuratPeringatan
+	err := s.DB.Preload("Mahasantri").
+		Joins("JOIN mahasantris ON mahasantris.id = surat_peringatans.mahasantri_id").
+		Where("surat_peringatans.id = ? AND mahasantris.mentor_id = ?", id, mentorID).
+		First(&sp).Error
+	return sp, err
+}
+
+// GetAllSuratPeringatan - Mengambil daftar surat peringatan
+// @Summary Mengambil daftar surat peringatan (SP)
+// @Description Mentor melihat SP milik mahasantri bimbingannya, mahasantri hanya melihat SP miliknya sendiri.
+// @Tags Surat Peringatan
+// @Accept json
+// @Produce json
+// @Param page query int false "Nomor halaman" default(1)
+// @Param limit query int false "Jumlah data per halaman" default(10)
+// @Param mahasantri_id query int false "Filter berdasarkan ID Mahasantri (hanya untuk Mentor)"
+// @Param status query string false "Filter berdasarkan status" Enums(aktif, diakui, dicabut)
+// @Param level query int false "Filter berdasarkan level SP" Enums(1, 2, 3)
+// @Param semester query string false "Filter berdasarkan semester" Enums(Ganjil, Genap)
+// @Param tahun_ajaran query string false "Filter berdasarkan tahun ajaran (YYYY/YYYY)"
+// @Success 200 {object} utils.Response "Daftar surat peringatan berhasil diambil"
+// @Failure 500 {object} utils.Response "Gagal mengambil surat peringatan"
+// @Security BearerAuth
+// @Router /api/v1/surat-peringatan [get]
+func (s *suratPeringatanService) GetAllSuratPeringatan(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+
+	log := logrus.WithFields(logrus.Fields{
+		"handler":  "GetAllSuratPeringatan",
+		"userID":   claims.ID,
+		"userRole": claims.Role,
+	})
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	offset := (page - 1) * limit
+
+	query := s.DB.Model(&models.SuratPeringatan{})
+
+	switch claims.Role {
+	case RoleMahasantri:
+		query = query.Where("surat_peringatans.mahasantri_id = ?", claims.ID)
+	case RoleMentor:
+		query = query.Joins("JOIN mahasantris ON mahasantris.id = surat_peringatans.mahasantri_id").
+			Where("mahasantris.mentor_id = ?", claims.ID)
+		if mahasantriID := c.Query("mahasantri_id"); mahasantriID != "" {
+			query = query.Where("surat_peringatans.mahasantri_id = ?", mahasantriID)
+		}
+	case RoleWali:
+		query = query.Joins("JOIN wali_mahasantris ON wali_mahasantris.mahasantri_id = surat_peringatans.mahasantri_id").
+			Where("wali_mahasantris.wali_id = ?", claims.ID)
+		if mahasantriID := c.Params("mahasantri_id"); mahasantriID != "" {
+			query = query.Where("surat_peringatans.mahasantri_id = ?", mahasantriID)
+		}
+	}
+
+	if status := c.Query("status"); status != "" {
+		query = query.Where("surat_peringatans.status = ?", status)
+	}
+	if level := c.Query("level"); level != "" {
+		query = query.Where("surat_peringatans.level = ?", level)
+	}
+	if semester := c.Query("semester"); semester != "" {
+		query = query.Where("surat_peringatans.semester = ?", semester)
+	}
+	if tahunAjaran := c.Query("tahun_ajaran"); tahunAjaran != "" {
+		query = query.Where("surat_peringatans.tahun_ajaran = ?", tahunAjaran)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		log.WithError(err).Error("Gagal menghitung surat peringatan")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil surat peringatan", err.Error())
+	}
+
+	var suratPeringatan []models.SuratPeringatan
+	if err := query.Preload("Mahasantri").
+		Order("surat_peringatans.created_at DESC").
+		Limit(limit).Offset(offset).
+		Find(&suratPeringatan).Error; err != nil {
+		log.WithError(err).Error("Gagal mengambil surat peringatan")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil surat peringatan", err.Error())
+	}
+
+	response := make([]dto.SuratPeringatanResponse, len(suratPeringatan))
+	for i, sp := range suratPeringatan {
+		response[i] = toSuratPeringatanResponse(sp)
+	}
+
+	log.WithField("total_data", total).Info("Berhasil mengambil daftar surat peringatan")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Daftar surat peringatan berhasil diambil", fiber.Map{
+		"pagination": fiber.Map{
+			"current_page": page,
+			"total_data":   total,
+			"total_pages":  int(math.Ceil(float64(total) / float64(limit))),
+		},
+		"surat_peringatan": response,
+	})
+}
+
+// GetSuratPeringatanByID - Mengambil detail surat peringatan
+// @Summary Mengambil detail surat peringatan
+// @Description Mengambil detail SP berdasarkan ID. Mentor hanya dapat melihat SP milik mahasantri bimbingannya. Wali mengakses SP anaknya melalui /api/v1/wali/anak/{mahasantri_id}/surat-peringatan/{id}.
+// @Tags Surat Peringatan
+// @Accept json
+// @Produce json
+// @Param id path int true "ID Surat Peringatan"
+// @Success 200 {object} utils.Response "Surat peringatan ditemukan"
+// @Failure 400 {object} utils.Response "ID tidak valid"
+// @Failure 404 {object} utils.Response "Surat peringatan tidak ditemukan"
+// @Security BearerAuth
+// @Router /api/v1/surat-peringatan/{id} [get]
+func (s *suratPeringatanService) GetSuratPeringatanByID(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "ID surat peringatan tidak valid", nil)
+	}
+
+	var sp models.SuratPeringatan
+	switch claims.Role {
+	case RoleMentor:
+		sp, err = s.findSuratPeringatanForMentor(id, claims.ID)
+	case RoleWali:
+		// Diakses melalui portal wali /wali/anak/:mahasantri_id/surat-peringatan/:id
+		err = s.DB.Preload("Mahasantri").
+			Where("id = ? AND mahasantri_id = ?", id, c.Params("mahasantri_id")).
+			Where("mahasantri_id IN (?)", s.DB.Model(&models.WaliMahasantri{}).Select("mahasantri_id").Where("wali_id = ?", claims.ID)).
+			First(&sp).Error
+	default:
+		err = s.DB.Preload("Mahasantri").Where("id = ? AND mahasantri_id = ?", id, claims.ID).First(&sp).Error
+	}
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.ResponseError(c, fiber.StatusNotFound, "Surat peringatan tidak ditemukan", nil)
+		}
+		logrus.WithError(err).WithField("surat_peringatan_id", id).Error("Gagal mengambil surat peringatan")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil surat peringatan", err.Error())
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Surat peringatan ditemukan", toSuratPeringatanResponse(sp))
+}
+
+// AkuiSuratPeringatan - Mentor mengakui (menindaklanjuti) surat peringatan
+// @Summary Mengakui surat peringatan
+// @Description Menandai SP sebagai sudah diakui/ditindaklanjuti oleh mentor.
+// @Tags Surat Peringatan
+// @Accept json
+// @Produce json
+// @Param id path int true "ID Surat Peringatan"
+// @Success 200 {object} utils.Response "Surat peringatan berhasil diakui"
+// @Failure 400 {object} utils.Response "Surat peringatan sudah dicabut atau diakui"
+// @Failure 404 {object} utils.Response "Surat peringatan tidak ditemukan"
+// @Security BearerAuth
+// @Router /api/v1/surat-peringatan/{id}/akui [put]
+func (s *suratPeringatanService) AkuiSuratPeringatan(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "ID surat peringatan tidak valid", nil)
+	}
+
+	log := logrus.WithFields(logrus.Fields{"handler": "AkuiSuratPeringatan", "mentorID": claims.ID, "surat_peringatan_id": id})
+
+	sp, err := s.findSuratPeringatanForMentor(id, claims.ID)
+	if err != nil {
+		log.WithError(err).Warn("Surat peringatan tidak ditemukan")
+		return utils.ResponseError(c, fiber.StatusNotFound, "Surat peringatan tidak ditemukan", nil)
+	}
+
+	if sp.Status != models.StatusSPAktif {
+		return utils.ResponseError(c, fiber.StatusBadRequest, fmt.Sprintf("Surat peringatan berstatus %s dan tidak dapat diakui", sp.Status), nil)
+	}
+
+	now := time.Now()
+	sp.Status = models.StatusSPDiakui
+	sp.DiakuiPada = &now
+	if err := s.DB.Save(&sp).Error; err != nil {
+		log.WithError(err).Error("Gagal mengakui surat peringatan")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengakui surat peringatan", err.Error())
+	}
+
+	log.Info("Surat peringatan berhasil diakui")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Surat peringatan berhasil diakui", toSuratPeringatanResponse(sp))
+}
+
+// CabutSuratPeringatan - Mentor mencabut surat peringatan
+// @Summary Mencabut surat peringatan
+// @Description Mencabut SP (misalnya karena koreksi data absensi). Alasan pencabutan wajib diisi dan dikirimkan ke mahasantri.
+// @Tags Surat Peringatan
+// @Accept json
+// @Produce json
+// @Param id path int true "ID Surat Peringatan"
+// @Param request body dto.CabutSuratPeringatanRequest true "Alasan pencabutan"
+// @Success 200 {object} utils.Response "Surat peringatan berhasil dicabut"
+// @Failure 400 {object} utils.Response "Alasan wajib diisi atau SP sudah dicabut"
+// @Failure 404 {object} utils.Response "Surat peringatan tidak ditemukan"
+// @Security BearerAuth
+// @Router /api/v1/surat-peringatan/{id}/cabut [put]
+func (s *suratPeringatanService) CabutSuratPeringatan(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "ID surat peringatan tidak valid", nil)
+	}
+
+	log := logrus.WithFields(logrus.Fields{"handler": "CabutSuratPeringatan", "mentorID": claims.ID, "surat_peringatan_id": id})
+
+	var req dto.CabutSuratPeringatanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Request body tidak valid", err.Error())
+	}
+	if strings.TrimSpace(req.Alasan) == "" {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Alasan pencabutan wajib diisi", nil)
+	}
+
+	sp, err := s.findSuratPeringatanForMentor(id, claims.ID)
+	if err != nil {
+		log.WithError(err).Warn("Surat peringatan tidak ditemukan")
+		return utils.ResponseError(c, fiber.StatusNotFound, "Surat peringatan tidak ditemukan", nil)
+	}
+
+	if sp.Status == models.StatusSPDicabut {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Surat peringatan sudah dicabut", nil)
+	}
+
+	now := time.Now()
+	sp.Status = models.StatusSPDicabut
+	sp.DicabutPada = &now
+	sp.AlasanPencabutan = req.Alasan
+	if err := s.DB.Save(&sp).Error; err != nil {
+		log.WithError(err).Error("Gagal mencabut surat peringatan")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mencabut surat peringatan", err.Error())
+	}
+
+	utils.SendNotifications(s.Notifier, utils.Notification{
+		RecipientRole: RoleMahasantri,
+		RecipientID:   sp.MahasantriID,
+		Title:         fmt.Sprintf("%s dicabut", sp.GetKodeLevel()),
+		Message:       fmt.Sprintf("%s Anda untuk semester %s %s telah dicabut: %s", sp.GetKodeLevel(), sp.Semester, sp.TahunAjaran, sp.AlasanPencabutan),
+		Data: map[string]interface{}{
+			"surat_peringatan_id": sp.ID,
+			"kode":                sp.GetKodeLevel(),
+		},
+	})
+
+	log.Info("Surat peringatan berhasil dicabut")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Surat peringatan berhasil dicabut", toSuratPeringatanResponse(sp))
+}
+
+// EvaluasiSuratPeringatan - Menjalankan ulang evaluasi SP untuk seorang mahasantri
+// @Summary Evaluasi ulang surat peringatan
+// @Description Menghitung ulang jumlah alpa mahasantri pada semester berjalan (atau semester dari parameter tanggal) dan menerbitkan SP yang belum diterbitkan.
+// @Tags Surat Peringatan
+// @Accept json
+// @Produce json
+// @Param mahasantri_id path int true "ID Mahasantri"
+// @Param tanggal query string false "Tanggal acuan semester (DD-MM-YYYY), default hari ini"
+// @Success 200 {object} utils.Response "Evaluasi surat peringatan selesai"
+// @Failure 400 {object} utils.Response "Parameter tidak valid"
+// @Failure 403 {object} utils.Response "Mahasantri bukan bimbingan mentor"
+// @Security BearerAuth
+// @Router /api/v1/surat-peringatan/evaluasi/{mahasantri_id} [post]
+func (s *suratPeringatanService) EvaluasiSuratPeringatan(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	mahasantriID, err := c.ParamsInt("mahasantri_id")
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "ID mahasantri tidak valid", nil)
+	}
+
+	log := logrus.WithFields(logrus.Fields{"handler": "EvaluasiSuratPeringatan", "mentorID": claims.ID, "mahasantriID": mahasantriID})
+
+	tanggal := time.Now()
+	if tanggalStr := c.Query("tanggal"); tanggalStr != "" {
+		tanggal, err = time.Parse("02-01-2006", tanggalStr)
+		if err != nil {
+			return utils.ResponseError(c, fiber.StatusBadRequest, "Format tanggal tidak valid, gunakan DD-MM-YYYY", nil)
+		}
+	}
+
+	var mahasantri models.Mahasantri
+	if err := s.DB.First(&mahasantri, mahasantriID).Error; err != nil || mahasantri.MentorID != claims.ID {
+		log.Warn("Upaya evaluasi SP untuk mahasantri yang bukan bimbingan mentor")
+		return utils.ResponseError(c, fiber.StatusForbidden, "Anda tidak memiliki hak akses untuk mahasantri ini", nil)
+	}
+
+	var issued []models.SuratPeringatan
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		issued, err = evaluasiSuratPeringatan(tx, uint(mahasantriID), tanggal)
+		return err
+	})
+	if err != nil {
+		log.WithError(err).Error("Gagal mengevaluasi surat peringatan")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengevaluasi surat peringatan", err.Error())
+	}
+
+	notifySuratPeringatan(s.Notifier, issued)
+
+	response := make([]dto.SuratPeringatanResponse, len(issued))
+	for i, sp := range issued {
+		sp.Mahasantri = mahasantri
+		response[i] = toSuratPeringatanResponse(sp)
+	}
+
+	log.WithField("jumlah_sp_baru", len(issued)).Info("Evaluasi surat peringatan selesai")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Evaluasi surat peringatan selesai", fiber.Map{
+		"surat_peringatan_baru": response,
+	})
+}
@@ -50,6 +50,9 @@ func (s *TargetSemesterService) CreateTargetSemester(c *fiber.Ctx) error {
 		}).Warn("Mahasantri not found")
 		return utils.ResponseError(c, fiber.StatusNotFound, "Mahasantri not found", nil)
 	}
+	if mahasantri.Status == models.StatusMahasantriLulus {
+		return responseMahasantriFrozen(c, errMahasantriAlumni)
+	}
 
 	// Cek apakah sudah ada target semester yang sama (MahasantriID + Semester + TahunAjaran)
 	var existingTarget models.TargetSemester
@@ -329,6 +332,9 @@ func (s *TargetSemesterService) UpdateTargetSemester(c *fiber.Ctx) error {
 		logrus.WithField("target_semester_id", id).Warn("Target semester not found")
 		return utils.ResponseError(c, fiber.StatusNotFound, "Target semester not found", nil)
 	}
+	if err := ensureMahasantriWritable(s.DB, targetSemester.MahasantriID); err != nil {
+		return responseMahasantriFrozen(c, err)
+	}
 
 	// Bind request body
 	var updateRequest dto.UpdateTargetSemesterRequest
@@ -400,6 +406,9 @@ func (s *TargetSemesterService) DeleteTargetSemester(c *fiber.Ctx) error {
 		logrus.WithField("target_semester_id", id).Warn("Target semester not found")
 		return utils.ResponseError(c, fiber.StatusNotFound, "Target semester not found", nil)
 	}
+	if err := ensureMahasantriWritable(s.DB, targetSemester.MahasantriID); err != nil {
+		return responseMahasantriFrozen(c, err)
+	}
 
 	// Hapus target semester
 	if err := s.DB.Delete(&targetSemester).Error; err != nil {
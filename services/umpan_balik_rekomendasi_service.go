@@ -0,0 +1,233 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/mahad-service-go/dto"
+	"github.com/habbazettt/mahad-service-go/models"
+	"github.com/habbazettt/mahad-service-go/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateUmpanBalikRekomendasi - Memberi umpan balik untuk rekomendasi yang pernah diterima
+// @Summary Memberi umpan balik rekomendasi jadwal
+// @Description Pengguna menilai rekomendasi jadwal miliknya: apakah jadwal tersebut diterapkan, skor efektivitas (1-5, wajib jika diterapkan), dan komentar bebas. Mengirim ulang akan memperbarui umpan balik sebelumnya. Skor efektivitas dipakai sebagai reward untuk memperbarui nilai Q satu kali per rekomendasi; skor yang diubah hanya mengoreksi reward sebelumnya.
+// @Tags Rekomendasi
+// @Accept json
+// @Produce json
+// @Param id path int true "ID riwayat rekomendasi"
+// @Param umpanBalikRequest body dto.UmpanBalikRekomendasiRequest true "Data umpan balik"
+// @Success 200 {object} utils.Response "Umpan balik berhasil disimpan"
+// @Failure 400 {object} utils.Response "Request tidak valid"
+// @Failure 404 {object} utils.Response "Riwayat rekomendasi tidak ditemukan"
+// @Failure 500 {object} utils.Response "Gagal menyimpan umpan balik"
+// @Security BearerAuth
+// @Router /api/v1/rekomendasi/{id}/umpan-balik [post]
+func (s *rekomendasiService) CreateUmpanBalikRekomendasi(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+
+	rekomendasiID, err := c.ParamsInt("id")
+	if err != nil || rekomendasiID <= 0 {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "ID rekomendasi tidak valid", nil)
+	}
+
+	log := logrus.WithFields(logrus.Fields{
+		"handler":       "CreateUmpanBalikRekomendasi",
+		"userID":        claims.ID,
+		"userRole":      claims.Role,
+		"rekomendasiID": rekomendasiID,
+	})
+
+	var req dto.UmpanBalikRekomendasiRequest
+	if err := c.BodyParser(&req); err != nil {
+		log.WithError(err).Error("Gagal mem-parsing request body")
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Request body tidak valid", err.Error())
+	}
+	if req.Diterapkan == nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Field diterapkan wajib diisi", nil)
+	}
+	if *req.Diterapkan {
+		if req.Efektivitas == nil || *req.Efektivitas < 1 || *req.Efektivitas > 5 {
+			return utils.ResponseError(c, fiber.StatusBadRequest, "Efektivitas wajib diisi dengan nilai 1-5 jika jadwal diterapkan", nil)
+		}
+	} else {
+		// Jadwal yang tidak dipakai tidak bisa dinilai efektivitasnya
+		req.Efektivitas = nil
+	}
+	req.Komentar = strings.TrimSpace(req.Komentar)
+
+	ownerColumn := "mahasantri_id"
+	if claims.Role == RoleMentor {
+		ownerColumn = "mentor_id"
+	}
+
+	var rekomendasi models.JadwalRekomendasi
+	var umpanBalik models.UmpanBalikRekomendasi
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND "+ownerColumn+" = ?", rekomendasiID, claims.ID).First(&rekomendasi).Error; err != nil {
+			return err
+		}
+		if rekomendasi.TipeRekomendasi == TipeRekomendasiTidakAda {
+			return errRekomendasiTanpaJadwal
+		}
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("jadwal_rekomendasi_id = ?", rekomendasi.ID).
+			First(&umpanBalik).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		baru := errors.Is(err, gorm.ErrRecordNotFound)
+
+		umpanBalik.JadwalRekomendasiID = rekomendasi.ID
+		umpanBalik.Diterapkan = *req.Diterapkan
+		umpanBalik.Efektivitas = req.Efektivitas
+		umpanBalik.Komentar = req.Komentar
+		if baru {
+			return tx.Create(&umpanBalik).Error
+		}
+		return tx.Select("diterapkan", "efektivitas", "komentar", "updated_at").Save(&umpanBalik).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Warn("Riwayat rekomendasi tidak ditemukan atau bukan milik pengguna")
+			return utils.ResponseError(c, fiber.StatusNotFound, "Riwayat rekomendasi tidak ditemukan atau bukan milik anda", nil)
+		}
+		if errors.Is(err, errRekomendasiTanpaJadwal) {
+			return utils.ResponseError(c, fiber.StatusBadRequest, err.Error(), nil)
+		}
+		log.WithError(err).Error("Gagal menyimpan umpan balik rekomendasi")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal menyimpan umpan balik", err.Error())
+	}
+
+	rewardUmpanBalikRekomendasi(s.DB, umpanBalik.ID)
+
+	log.WithFields(logrus.Fields{
+		"diterapkan":  umpanBalik.Diterapkan,
+		"efektivitas": umpanBalik.Efektivitas,
+	}).Info("Umpan balik rekomendasi berhasil disimpan")
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Umpan balik berhasil disimpan", dto.UmpanBalikRekomendasiResponse{
+		ID:                  umpanBalik.ID,
+		JadwalRekomendasiID: umpanBalik.JadwalRekomendasiID,
+		State:               rekomendasi.State,
+		RekomendasiJadwal:   rekomendasi.RekomendasiJadwal,
+		Diterapkan:          umpanBalik.Diterapkan,
+		Efektivitas:         umpanBalik.Efektivitas,
+		Komentar:            umpanBalik.Komentar,
+		UpdatedAt:           umpanBalik.UpdatedAt,
+	})
+}
+
+// GetStatistikRekomendasi - Statistik penerimaan dan keberhasilan rekomendasi per state
+// @Summary Statistik penerimaan dan keberhasilan rekomendasi
+// @Description Menggabungkan riwayat rekomendasi dengan umpan balik pengguna dan sesi murojaah yang dibuat dari rekomendasi (detail log) untuk menghitung tingkat penerimaan dan keberhasilan per state. Rekomendasi dianggap diadopsi jika pengguna menyatakan menerapkannya atau jika rekomendasi dipakai untuk membuat sesi murojaah.
+// @Tags Rekomendasi
+// @Produce json
+// @Param dari query string false "Tanggal awal rekomendasi (DD-MM-YYYY)"
+// @Param sampai query string false "Tanggal akhir rekomendasi (DD-MM-YYYY)"
+// @Success 200 {object} utils.Response "Statistik rekomendasi berhasil diambil"
+// @Failure 400 {object} utils.Response "Format tanggal tidak valid"
+// @Failure 500 {object} utils.Response "Gagal mengambil statistik rekomendasi"
+// @Security BearerAuth
+// @Router /api/v1/rekomendasi/statistik [get]
+func (s *rekomendasiService) GetStatistikRekomendasi(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	log := logrus.WithFields(logrus.Fields{"handler": "GetStatistikRekomendasi", "userID": claims.ID})
+
+	sesiPerRekomendasi := sesiPerRekomendasiQuery(s.DB)
+
+	query := s.DB.Table("jadwal_rekomendasis AS jr").
+		Select(`jr.state,
+			COUNT(*) AS total_rekomendasi,
+			COUNT(ub.id) AS total_umpan_balik,
+			COUNT(*) FILTER (WHERE ub.diterapkan OR COALESCE(dl.jumlah_sesi, 0) > 0) AS total_diadopsi,
+			COUNT(*) FILTER (WHERE COALESCE(dl.jumlah_sesi, 0) > 0) AS total_diterapkan_ke_log,
+			COALESCE(SUM(dl.jumlah_sesi), 0) AS total_sesi,
+			COALESCE(SUM(dl.sesi_selesai), 0) AS total_sesi_selesai,
+			COUNT(ub.efektivitas) AS total_dinilai,
+			AVG(ub.efektivitas) AS rata_rata_efektivitas,
+			COUNT(*) FILTER (WHERE ub.efektivitas >= 4) AS total_dinilai_efektif`).
+		Joins("LEFT JOIN umpan_balik_rekomendasis ub ON ub.jadwal_rekomendasi_id = jr.id").
+		Joins("LEFT JOIN (?) AS dl ON dl.jadwal_rekomendasi_id = jr.id", sesiPerRekomendasi).
+		Group("jr.state").
+		Order("jr.state ASC")
+
+	dari, sampai, err := parseRentangTanggal(c)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, err.Error(), nil)
+	}
+	query = filterRentangTanggal(query, "jr.created_at", dari, sampai)
+
+	var rows []struct {
+		dto.StatistikRekomendasiState
+		TotalDinilaiEfektif int64
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		log.WithError(err).Error("Gagal mengambil statistik rekomendasi")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil statistik rekomendasi", err.Error())
+	}
+
+	hasil := make([]dto.StatistikRekomendasiState, len(rows))
+	for i, row := range rows {
+		stat := row.StatistikRekomendasiState
+		if stat.TotalRekomendasi > 0 {
+			stat.TingkatPenerimaan = float64(stat.TotalDiadopsi) / float64(stat.TotalRekomendasi) * 100
+		}
+		if stat.TotalSesi > 0 {
+			stat.TingkatKeberhasilan = float64(stat.TotalSesiSelesai) / float64(stat.TotalSesi) * 100
+		}
+		if stat.TotalDinilai > 0 {
+			stat.PersentaseDinilaiEfektif = float64(row.TotalDinilaiEfektif) / float64(stat.TotalDinilai) * 100
+		}
+		hasil[i] = stat
+	}
+
+	log.WithField("jumlah_state", len(hasil)).Info("Berhasil mengambil statistik rekomendasi")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Statistik rekomendasi berhasil diambil", hasil)
+}
+
+// parseRentangTanggal membaca query dari dan sampai (DD-MM-YYYY). Keduanya opsional, sampai bersifat inklusif.
+func parseRentangTanggal(c *fiber.Ctx) (dari, sampai *time.Time, err error) {
+	if dariStr := c.Query("dari"); dariStr != "" {
+		t, err := time.Parse("02-01-2006", dariStr)
+		if err != nil {
+			return nil, nil, errors.New("format tanggal dari tidak valid, gunakan DD-MM-YYYY")
+		}
+		dari = &t
+	}
+	if sampaiStr := c.Query("sampai"); sampaiStr != "" {
+		t, err := time.Parse("02-01-2006", sampaiStr)
+		if err != nil {
+			return nil, nil, errors.New("format tanggal sampai tidak valid, gunakan DD-MM-YYYY")
+		}
+		sampai = &t
+	}
+	if dari != nil && sampai != nil && sampai.Before(*dari) {
+		return nil, nil, errors.New("tanggal sampai tidak boleh sebelum tanggal dari")
+	}
+	return dari, sampai, nil
+}
+
+func filterRentangTanggal(query *gorm.DB, kolom string, dari, sampai *time.Time) *gorm.DB {
+	if dari != nil {
+		query = query.Where(kolom+" >= ?", *dari)
+	}
+	if sampai != nil {
+		query = query.Where(kolom+" < ?", sampai.AddDate(0, 0, 1))
+	}
+	return query
+}
+
+// sesiPerRekomendasiQuery menghitung jumlah sesi murojaah dan sesi selesai yang dibuat dari setiap rekomendasi
+func sesiPerRekomendasiQuery(db *gorm.DB) *gorm.DB {
+	return db.Table("detail_logs").
+		Select("jadwal_rekomendasi_id, COUNT(*) AS jumlah_sesi, COUNT(*) FILTER (WHERE status = ?) AS sesi_selesai", models.StatusSesiSelesai).
+		Where("jadwal_rekomendasi_id IS NOT NULL").
+		Group("jadwal_rekomendasi_id")
+}
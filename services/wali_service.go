@@ -0,0 +1,436 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/mahad-service-go/dto"
+	"github.com/habbazettt/mahad-service-go/models"
+	"github.com/habbazettt/mahad-service-go/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var hubunganWaliValid = map[string]bool{"ayah": true, "ibu": true, "wali": true}
+
+type WaliService interface {
+	CreateWali(c *fiber.Ctx) error
+	GetAllWali(c *fiber.Ctx) error
+	HubungkanMahasantri(c *fiber.Ctx) error
+	PutuskanMahasantri(c *fiber.Ctx) error
+	GetAnakWali(c *fiber.Ctx) error
+	RequireAnakWali(c *fiber.Ctx) error
+}
+
+type waliService struct {
+	DB       *gorm.DB
+	Notifier utils.Notifier
+}
+
+func NewWaliService(db *gorm.DB, notifier utils.Notifier) WaliService {
+	return &waliService{DB: db, Notifier: notifier}
+}
+
+// errBukanMahasantriBimbingan dikembalikan jika mentor mengelola wali untuk mahasantri yang bukan bimbingannya
+var errBukanMahasantriBimbingan = errors.New("mahasantri bukan bimbingan anda")
+
+// cekMahasantriBimbingan memastikan seluruh mahasantri ada dan dibimbing oleh mentor tersebut
+func cekMahasantriBimbingan(db *gorm.DB, mentorID uint, mahasantriIDs []uint) error {
+	var mahasantris []models.Mahasantri
+	if err := db.Select("id", "mentor_id").Where("id IN ?", mahasantriIDs).Find(&mahasantris).Error; err != nil {
+		return err
+	}
+	if len(mahasantris) != len(mahasantriIDs) {
+		return gorm.ErrRecordNotFound
+	}
+	for _, m := range mahasantris {
+		if m.MentorID != mentorID {
+			return errBukanMahasantriBimbingan
+		}
+	}
+	return nil
+}
+
+// responseCekMahasantriBimbingan mengubah error cekMahasantriBimbingan menjadi response
+func responseCekMahasantriBimbingan(c *fiber.Ctx, err error, log *logrus.Entry) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return utils.ResponseError(c, fiber.StatusNotFound, "Mahasantri tidak ditemukan", nil)
+	case errors.Is(err, errBukanMahasantriBimbingan):
+		log.Warn("Mentor mencoba mengelola wali untuk mahasantri yang bukan bimbingannya")
+		return utils.ResponseError(c, fiber.StatusForbidden, "Anda hanya dapat mengelola wali untuk mahasantri bimbingan anda", nil)
+	}
+	log.WithError(err).Error("Gagal memeriksa mahasantri")
+	return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal memeriksa mahasantri", err.Error())
+}
+
+// isAnakWali memastikan mahasantri terhubung dengan wali tersebut
+func isAnakWali(db *gorm.DB, waliID, mahasantriID uint) (bool, error) {
+	var count int64
+	err := db.Model(&models.WaliMahasantri{}).
+		Where("wali_id = ? AND mahasantri_id = ?", waliID, mahasantriID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// findAnakWali mengambil seluruh mahasantri yang terhubung dengan wali
+func findAnakWali(db *gorm.DB, waliID uint) ([]dto.AnakWaliResponse, error) {
+	anak := []dto.AnakWaliResponse{}
+	err := db.Table("wali_mahasantris wm").
+		Select("m.id, m.nama, m.nim, m.jurusan, m.gender, m.status, m.mentor_id, wm.hubungan").
+		Joins("JOIN mahasantris m ON m.id = wm.mahasantri_id AND m.deleted_at IS NULL").
+		Where("wm.wali_id = ?", waliID).
+		Order("m.nama ASC").
+		Scan(&anak).Error
+	return anak, err
+}
+
+// toWaliResponse menyusun data wali untuk mentor. Hanya anak yang dibimbing mentor tersebut yang ditampilkan.
+func toWaliResponse(db *gorm.DB, wali models.Wali, mentorID uint) (dto.WaliResponse, error) {
+	semuaAnak, err := findAnakWali(db, wali.ID)
+	anak := make([]dto.AnakWaliResponse, 0, len(semuaAnak))
+	for _, a := range semuaAnak {
+		if a.MentorID == mentorID {
+			anak = append(anak, a)
+		}
+	}
+	return dto.WaliResponse{
+		ID:         wali.ID,
+		Nama:       wali.Nama,
+		NoHP:       wali.NoHP,
+		Mahasantri: anak,
+		CreatedAt:  wali.CreatedAt,
+	}, err
+}
+
+func normalizeHubunganWali(hubungan string) (string, error) {
+	hubungan = strings.ToLower(strings.TrimSpace(hubungan))
+	if hubungan == "" {
+		return "wali", nil
+	}
+	if !hubunganWaliValid[hubungan] {
+		return "", errors.New("hubungan harus salah satu dari ayah, ibu, atau wali")
+	}
+	return hubungan, nil
+}
+
+// CreateWali godoc
+// @Summary Membuat akun wali
+// @Description Mentor membuat akun wali (orang tua) dan menghubungkannya dengan satu atau lebih mahasantri bimbingannya. Password awal dibuat acak dan dikirim melalui notifikasi ke wali.
+// @Tags Wali
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateWaliRequest true "Data wali"
+// @Success 201 {object} utils.Response{data=dto.WaliResponse} "Akun wali berhasil dibuat"
+// @Failure 400 {object} utils.Response "Request tidak valid"
+// @Failure 403 {object} utils.Response "Mahasantri bukan bimbingan mentor"
+// @Failure 404 {object} utils.Response "Mahasantri tidak ditemukan"
+// @Failure 409 {object} utils.Response "Nomor HP sudah terdaftar"
+// @Failure 500 {object} utils.Response "Gagal membuat akun wali"
+// @Security BearerAuth
+// @Router /api/v1/wali [post]
+func (s *waliService) CreateWali(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+
+	var req dto.CreateWaliRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Request body tidak valid", err.Error())
+	}
+
+	req.Nama = strings.TrimSpace(req.Nama)
+	req.NoHP = strings.TrimSpace(req.NoHP)
+	if req.Nama == "" {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Nama wali wajib diisi", nil)
+	}
+	if !utils.IsValidPhone(req.NoHP) {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Nomor HP tidak valid", nil)
+	}
+
+	seen := make(map[uint]bool)
+	var mahasantriIDs []uint
+	for _, id := range req.MahasantriIDs {
+		if id != 0 && !seen[id] {
+			seen[id] = true
+			mahasantriIDs = append(mahasantriIDs, id)
+		}
+	}
+	if len(mahasantriIDs) == 0 {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Minimal satu mahasantri harus dihubungkan", nil)
+	}
+	hubungan, err := normalizeHubunganWali(req.Hubungan)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, err.Error(), nil)
+	}
+
+	log := logrus.WithFields(logrus.Fields{"handler": "CreateWali", "mentorID": claims.ID, "no_hp": req.NoHP})
+
+	var exists int64
+	if err := s.DB.Model(&models.Wali{}).Where("no_hp = ?", req.NoHP).Count(&exists).Error; err != nil {
+		log.WithError(err).Error("Gagal memeriksa nomor HP wali")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal membuat akun wali", err.Error())
+	}
+	if exists > 0 {
+		return utils.ResponseError(c, fiber.StatusConflict, "Nomor HP sudah terdaftar sebagai wali", nil)
+	}
+
+	if err := cekMahasantriBimbingan(s.DB, claims.ID, mahasantriIDs); err != nil {
+		return responseCekMahasantriBimbingan(c, err, log)
+	}
+
+	password, err := utils.GenerateRandomPassword(importPasswordLength)
+	if err != nil {
+		log.WithError(err).Error("Gagal membuat password wali")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal membuat akun wali", err.Error())
+	}
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		log.WithError(err).Error("Gagal hash password wali")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal membuat akun wali", err.Error())
+	}
+
+	wali := models.Wali{Nama: req.Nama, NoHP: req.NoHP, Password: hashedPassword}
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&wali).Error; err != nil {
+			return err
+		}
+		for _, mahasantriID := range mahasantriIDs {
+			link := models.WaliMahasantri{WaliID: wali.ID, MahasantriID: mahasantriID, Hubungan: hubungan}
+			if err := tx.Create(&link).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("Gagal membuat akun wali")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal membuat akun wali", err.Error())
+	}
+
+	utils.SendNotifications(s.Notifier, utils.Notification{
+		RecipientRole: RoleWali,
+		RecipientID:   wali.ID,
+		Title:         "Akun wali dibuat",
+		Message:       fmt.Sprintf("Akun wali Anda telah dibuat. Silakan login menggunakan nomor HP %s dan segera ganti password awal Anda.", wali.NoHP),
+		Data: map[string]interface{}{
+			"no_hp":    wali.NoHP,
+			"password": password,
+		},
+	})
+
+	response, err := toWaliResponse(s.DB, wali, claims.ID)
+	if err != nil {
+		log.WithError(err).Error("Gagal mengambil data anak wali")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil data wali", err.Error())
+	}
+
+	log.WithField("wali_id", wali.ID).Info("Akun wali berhasil dibuat")
+	return utils.SuccessResponse(c, fiber.StatusCreated, "Akun wali berhasil dibuat", response)
+}
+
+// GetAllWali godoc
+// @Summary Daftar wali
+// @Description Mengambil daftar wali yang terhubung dengan mahasantri bimbingan mentor yang sedang login, beserta mahasantri bimbingan yang terhubung. Dapat difilter dengan mahasantri_id.
+// @Tags Wali
+// @Produce json
+// @Param mahasantri_id query int false "Filter berdasarkan mahasantri"
+// @Param page query int false "Halaman" default(1)
+// @Param limit query int false "Jumlah data per halaman" default(10)
+// @Success 200 {object} utils.Response "Daftar wali berhasil diambil"
+// @Failure 500 {object} utils.Response "Gagal mengambil daftar wali"
+// @Security BearerAuth
+// @Router /api/v1/wali [get]
+func (s *waliService) GetAllWali(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	page, limit, offset := paginationParams(c)
+
+	waliBimbingan := s.DB.Table("wali_mahasantris wm").
+		Select("wm.wali_id").
+		Joins("JOIN mahasantris m ON m.id = wm.mahasantri_id AND m.deleted_at IS NULL").
+		Where("m.mentor_id = ?", claims.ID)
+	if mahasantriID := c.Query("mahasantri_id"); mahasantriID != "" {
+		waliBimbingan = waliBimbingan.Where("wm.mahasantri_id = ?", mahasantriID)
+	}
+	query := s.DB.Model(&models.Wali{}).Where("id IN (?)", waliBimbingan)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logrus.WithError(err).Error("Gagal menghitung wali")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil daftar wali", err.Error())
+	}
+
+	var walis []models.Wali
+	if err := query.Order("nama ASC").Limit(limit).Offset(offset).Find(&walis).Error; err != nil {
+		logrus.WithError(err).Error("Gagal mengambil wali")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil daftar wali", err.Error())
+	}
+
+	responses := make([]dto.WaliResponse, 0, len(walis))
+	for _, wali := range walis {
+		response, err := toWaliResponse(s.DB, wali, claims.ID)
+		if err != nil {
+			logrus.WithError(err).WithField("wali_id", wali.ID).Error("Gagal mengambil data anak wali")
+			return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil daftar wali", err.Error())
+		}
+		responses = append(responses, response)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Daftar wali berhasil diambil", fiber.Map{
+		"pagination": fiber.Map{
+			"current_page": page,
+			"total_data":   total,
+			"total_pages":  (total + int64(limit) - 1) / int64(limit),
+		},
+		"wali": responses,
+	})
+}
+
+// HubungkanMahasantri godoc
+// @Summary Menghubungkan wali dengan mahasantri
+// @Description Menambahkan mahasantri bimbingan mentor yang sedang login ke daftar anak yang dapat dipantau oleh wali.
+// @Tags Wali
+// @Accept json
+// @Produce json
+// @Param id path int true "ID Wali"
+// @Param request body dto.HubungkanWaliRequest true "Mahasantri yang dihubungkan"
+// @Success 200 {object} utils.Response{data=dto.WaliResponse} "Mahasantri berhasil dihubungkan"
+// @Failure 400 {object} utils.Response "Request tidak valid"
+// @Failure 403 {object} utils.Response "Mahasantri bukan bimbingan mentor"
+// @Failure 404 {object} utils.Response "Wali atau mahasantri tidak ditemukan"
+// @Failure 500 {object} utils.Response "Gagal menghubungkan mahasantri"
+// @Security BearerAuth
+// @Router /api/v1/wali/{id}/mahasantri [post]
+func (s *waliService) HubungkanMahasantri(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "ID wali tidak valid", nil)
+	}
+
+	var req dto.HubungkanWaliRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "Request body tidak valid", err.Error())
+	}
+	if req.MahasantriID == 0 {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "mahasantri_id wajib diisi", nil)
+	}
+	hubungan, err := normalizeHubunganWali(req.Hubungan)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, err.Error(), nil)
+	}
+
+	var wali models.Wali
+	if err := s.DB.First(&wali, id).Error; err != nil {
+		return utils.ResponseError(c, fiber.StatusNotFound, "Wali tidak ditemukan", nil)
+	}
+	log := logrus.WithFields(logrus.Fields{"handler": "HubungkanMahasantri", "mentorID": claims.ID, "wali_id": wali.ID, "mahasantri_id": req.MahasantriID})
+	if err := cekMahasantriBimbingan(s.DB, claims.ID, []uint{req.MahasantriID}); err != nil {
+		return responseCekMahasantriBimbingan(c, err, log)
+	}
+
+	link := models.WaliMahasantri{WaliID: wali.ID, MahasantriID: req.MahasantriID, Hubungan: hubungan}
+	err = s.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "wali_id"}, {Name: "mahasantri_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"hubungan"}),
+	}).Create(&link).Error
+	if err != nil {
+		log.WithError(err).Error("Gagal menghubungkan mahasantri ke wali")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal menghubungkan mahasantri", err.Error())
+	}
+
+	response, err := toWaliResponse(s.DB, wali, claims.ID)
+	if err != nil {
+		log.WithError(err).Error("Gagal mengambil data anak wali")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil data wali", err.Error())
+	}
+
+	log.Info("Mahasantri berhasil dihubungkan ke wali")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Mahasantri berhasil dihubungkan", response)
+}
+
+// PutuskanMahasantri godoc
+// @Summary Memutus hubungan wali dengan mahasantri
+// @Description Menghapus mahasantri bimbingan mentor yang sedang login dari daftar anak wali sehingga wali tidak lagi dapat melihat datanya.
+// @Tags Wali
+// @Produce json
+// @Param id path int true "ID Wali"
+// @Param mahasantri_id path int true "ID Mahasantri"
+// @Success 200 {object} utils.Response "Hubungan berhasil diputus"
+// @Failure 403 {object} utils.Response "Mahasantri bukan bimbingan mentor"
+// @Failure 404 {object} utils.Response "Hubungan tidak ditemukan"
+// @Failure 500 {object} utils.Response "Gagal memutus hubungan"
+// @Security BearerAuth
+// @Router /api/v1/wali/{id}/mahasantri/{mahasantri_id} [delete]
+func (s *waliService) PutuskanMahasantri(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "ID wali tidak valid", nil)
+	}
+	mahasantriID, err := c.ParamsInt("mahasantri_id")
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "ID mahasantri tidak valid", nil)
+	}
+
+	log := logrus.WithFields(logrus.Fields{"handler": "PutuskanMahasantri", "mentorID": claims.ID, "wali_id": id, "mahasantri_id": mahasantriID})
+	if err := cekMahasantriBimbingan(s.DB, claims.ID, []uint{uint(mahasantriID)}); err != nil {
+		return responseCekMahasantriBimbingan(c, err, log)
+	}
+
+	result := s.DB.Where("wali_id = ? AND mahasantri_id = ?", id, mahasantriID).Delete(&models.WaliMahasantri{})
+	if result.Error != nil {
+		logrus.WithError(result.Error).WithField("wali_id", id).Error("Gagal memutus hubungan wali")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal memutus hubungan", result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return utils.ResponseError(c, fiber.StatusNotFound, "Hubungan wali dengan mahasantri tidak ditemukan", nil)
+	}
+
+	logrus.WithFields(logrus.Fields{"wali_id": id, "mahasantri_id": mahasantriID}).Info("Hubungan wali dengan mahasantri diputus")
+	return utils.SuccessResponse(c, fiber.StatusOK, "Hubungan berhasil diputus", nil)
+}
+
+// GetAnakWali godoc
+// @Summary Daftar anak wali
+// @Description Mengambil mahasantri yang terhubung dengan wali yang sedang login.
+// @Tags Wali
+// @Produce json
+// @Success 200 {object} utils.Response{data=[]dto.AnakWaliResponse} "Daftar anak berhasil diambil"
+// @Failure 500 {object} utils.Response "Gagal mengambil daftar anak"
+// @Security BearerAuth
+// @Router /api/v1/wali/anak [get]
+func (s *waliService) GetAnakWali(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+
+	anak, err := findAnakWali(s.DB, claims.ID)
+	if err != nil {
+		logrus.WithError(err).WithField("wali_id", claims.ID).Error("Gagal mengambil daftar anak wali")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal mengambil daftar anak", err.Error())
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, "Daftar anak berhasil diambil", anak)
+}
+
+// RequireAnakWali adalah middleware untuk route portal wali. Route tersebut memakai handler yang sama
+// dengan mentor/mahasantri, sehingga akses dibatasi di sini hanya untuk mahasantri yang terhubung.
+func (s *waliService) RequireAnakWali(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+
+	mahasantriID, err := strconv.ParseUint(c.Params("mahasantri_id"), 10, 64)
+	if err != nil {
+		return utils.ResponseError(c, fiber.StatusBadRequest, "ID mahasantri tidak valid", nil)
+	}
+
+	ok, err := isAnakWali(s.DB, claims.ID, uint(mahasantriID))
+	if err != nil {
+		logrus.WithError(err).WithField("wali_id", claims.ID).Error("Gagal memeriksa hubungan wali")
+		return utils.ResponseError(c, fiber.StatusInternalServerError, "Gagal memeriksa hak akses", err.Error())
+	}
+	if !ok {
+		logrus.WithFields(logrus.Fields{"wali_id": claims.ID, "mahasantri_id": mahasantriID}).Warn("Wali mencoba mengakses mahasantri yang tidak terhubung")
+		return utils.ResponseError(c, fiber.StatusForbidden, "Anda tidak memiliki hak akses untuk melihat data mahasantri ini", nil)
+	}
+	return c.Next()
+}
@@ -261,3 +261,31 @@ func TestLoginMahasantri_NotRegistered(t *testing.T) {
 		passed = false
 	}
 }
+
+func TestLoginMahasantri_Archived(t *testing.T) {
+	app, db := SetupTestApp()
+	mentor := createTestMentor(db, "arsip@dummy.com", "dummy123")
+	santri := createTestMahasantri(db, "777777", "mahasantripass", mentor.ID)
+	db.Delete(&santri)
+
+	name := "TestLoginMahasantri_Archived"
+	passed := true
+	recordTestResult(t, name, &passed)
+
+	resp, body, err := sendJSONRequest(app, http.MethodPost, "/api/v1/auth/login/mahasantri", `{"nim":"777777","password":"mahasantripass"}`)
+	if !assert.NoError(t, err) || !assert.Equal(t, http.StatusUnauthorized, resp.StatusCode) {
+		passed = false
+		return
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		passed = false
+		return
+	}
+
+	if !assert.False(t, result["status"].(bool)) ||
+		!assert.Equal(t, "Invalid NIM or password", result["message"]) {
+		passed = false
+	}
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	_ "image/png" // registrasi decoder PNG untuk image.Decode
+	"net/http"
+
+	"golang.org/x/image/draw"
+)
+
+// ErrUnsupportedImage dikembalikan jika file bukan gambar JPEG atau PNG
+var ErrUnsupportedImage = errors.New("format gambar harus JPEG atau PNG")
+
+// ErrImageTooLarge dikembalikan jika dimensi gambar melebihi batas yang wajar untuk foto profil
+var ErrImageTooLarge = errors.New("dimensi gambar terlalu besar")
+
+const (
+	// maxImageDimension dan maxImagePixels membatasi memori saat decode (sekitar 4 byte per piksel),
+	// cukup untuk foto kamera ponsel 12 MP
+	maxImageDimension = 6000
+	maxImagePixels    = 16_000_000
+)
+
+// DetectImageType mengembalikan content type dan ekstensi berdasarkan isi file, bukan nama file
+func DetectImageType(data []byte) (contentType, ext string, err error) {
+	switch contentType = http.DetectContentType(data); contentType {
+	case "image/jpeg":
+		return contentType, ".jpg", nil
+	case "image/png":
+		return contentType, ".png", nil
+	default:
+		return "", "", ErrUnsupportedImage
+	}
+}
+
+// MakeThumbnail mengecilkan gambar agar sisi terpanjang maksimal maxSize piksel dan mengembalikannya dalam format JPEG
+func MakeThumbnail(data []byte, maxSize int) ([]byte, error) {
+	// Cek dimensi sebelum decode penuh agar file kecil dengan dimensi raksasa tidak menghabiskan memori
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	if cfg.Width > maxImageDimension || cfg.Height > maxImageDimension || cfg.Width*cfg.Height > maxImagePixels {
+		return nil, ErrImageTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w > maxSize || h > maxSize {
+		if w >= h {
+			h = h * maxSize / w
+			w = maxSize
+		} else {
+			w = w * maxSize / h
+			h = maxSize
+		}
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	// Latar putih agar area transparan PNG tidak menjadi hitam di JPEG
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
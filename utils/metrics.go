@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const metricsNamespace = "mahad"
+
+// Metrik HTTP, diisi oleh middleware.Metrics
+var (
+	HTTPRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "http_requests_total",
+		Help:      "Jumlah request HTTP per method, route, dan status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latensi request HTTP per method dan route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+// Metrik domain, dinaikkan oleh service setelah data berhasil disimpan
+var (
+	SetoranCreatedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "setoran_created_total",
+		Help:      "Jumlah setoran hafalan yang dicatat.",
+	})
+
+	AbsensiRecordedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "absensi_recorded_total",
+		Help:      "Jumlah absensi baru yang dicatat per status.",
+	}, []string{"status"})
+
+	RekomendasiServedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "rekomendasi_served_total",
+		Help:      "Jumlah rekomendasi jadwal yang diberikan per tipe rekomendasi.",
+	}, []string{"tipe"})
+
+	LogSesiSelesaiTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "log_sesi_selesai_total",
+		Help:      "Jumlah sesi murojaah yang mencapai status Selesai.",
+	})
+)
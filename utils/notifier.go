@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Notification adalah pesan yang dikirim ke pengguna (mentor atau mahasantri)
+type Notification struct {
+	RecipientRole string                 `json:"recipient_role"`
+	RecipientID   uint                   `json:"recipient_id"`
+	Title         string                 `json:"title"`
+	Message       string                 `json:"message"`
+	Data          map[string]interface{} `json:"data,omitempty"`
+}
+
+// Notifier adalah kontrak untuk mengirim notifikasi ke pengguna
+type Notifier interface {
+	Notify(n Notification) error
+}
+
+// secretDataKeys adalah key pada Notification.Data yang tidak boleh tercatat di log
+var secretDataKeys = map[string]bool{"password": true}
+
+// redactData menyalin data notifikasi dengan nilai rahasia disamarkan
+func redactData(data map[string]interface{}) map[string]interface{} {
+	if len(data) == 0 {
+		return data
+	}
+	redacted := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		if secretDataKeys[k] {
+			v = "[REDACTED]"
+		}
+		redacted[k] = v
+	}
+	return redacted
+}
+
+// LogNotifier hanya mencatat notifikasi ke log, digunakan sebagai default.
+// Nilai rahasia seperti password awal disamarkan, sehingga pengiriman password hanya dapat dilakukan melalui webhook.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(n Notification) error {
+	logrus.WithFields(logrus.Fields{
+		"recipient_role": n.RecipientRole,
+		"recipient_id":   n.RecipientID,
+		"title":          n.Title,
+		"data":           redactData(n.Data),
+	}).Info(n.Message)
+	return nil
+}
+
+// WebhookNotifier mengirim notifikasi dalam bentuk JSON ke URL webhook
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func (w WebhookNotifier) Notify(n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.Client.Post(w.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook notifikasi mengembalikan status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// NewNotifier memilih implementasi notifier berdasarkan NOTIFIER_DRIVER (log atau webhook)
+func NewNotifier() Notifier {
+	switch os.Getenv("NOTIFIER_DRIVER") {
+	case "webhook":
+		url := os.Getenv("NOTIFIER_WEBHOOK_URL")
+		if url == "" {
+			logrus.Warn("NOTIFIER_WEBHOOK_URL kosong, menggunakan notifier log")
+			return LogNotifier{}
+		}
+		return WebhookNotifier{URL: url, Client: &http.Client{Timeout: 5 * time.Second}}
+	default:
+		return LogNotifier{}
+	}
+}
+
+// CanDeliverSecrets bernilai true jika notifier benar-benar mengirim Data ke penerima, termasuk nilai rahasia
+// seperti password awal. LogNotifier menyamarkan nilai tersebut sehingga tidak pernah sampai ke pengguna.
+func CanDeliverSecrets(notifier Notifier) bool {
+	_, ok := notifier.(WebhookNotifier)
+	return ok
+}
+
+// pendingNotifications menghitung pengiriman notifikasi yang masih berjalan di background
+var pendingNotifications sync.WaitGroup
+
+// SendNotifications mengirim semua notifikasi di background agar request tidak menunggu webhook,
+// dan hanya mencatat kegagalan tanpa menggagalkan request
+func SendNotifications(notifier Notifier, notifications ...Notification) {
+	if len(notifications) == 0 {
+		return
+	}
+	if notifier == nil {
+		notifier = LogNotifier{}
+	}
+
+	pendingNotifications.Add(1)
+	go func() {
+		defer pendingNotifications.Done()
+		for _, n := range notifications {
+			if err := notifier.Notify(n); err != nil {
+				logrus.WithError(err).WithFields(logrus.Fields{
+					"recipient_role": n.RecipientRole,
+					"recipient_id":   n.RecipientID,
+				}).Error("Gagal mengirim notifikasi")
+			}
+		}
+	}()
+}
+
+// WaitNotifications menunggu notifikasi yang masih dikirim di background, paling lama selama timeout.
+// Dipanggil saat server berhenti agar notifikasi yang sudah diantrekan tidak hilang.
+func WaitNotifications(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		pendingNotifications.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
@@ -9,3 +9,9 @@ func IsValidEmail(email string) bool {
 	re := regexp.MustCompile(regex)
 	return re.MatchString(email)
 }
+
+func IsValidPhone(phone string) bool {
+	regex := `^\+?[0-9]{8,15}$`
+	re := regexp.MustCompile(regex)
+	return re.MatchString(phone)
+}